@@ -1,19 +1,24 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"github.com/gabriel-98/bingo-backend/internal/application"
+	"github.com/gabriel-98/bingo-backend/internal/application/jobs"
 	"github.com/gabriel-98/bingo-backend/internal/application/services"
 	//"github.com/gabriel-98/bingo-backend/internal/application/types"
 	"github.com/gabriel-98/bingo-backend/internal/config"
 	"github.com/gabriel-98/bingo-backend/internal/domain"
 	"github.com/gabriel-98/bingo-backend/internal/domain/entities"
+	domports "github.com/gabriel-98/bingo-backend/internal/domain/ports"
 	"github.com/gabriel-98/bingo-backend/internal/infrastructure/api/rest"
 	"github.com/gabriel-98/bingo-backend/internal/infrastructure/providers"
+	"github.com/gabriel-98/bingo-backend/internal/infrastructure/repositories/memrepos"
 	"github.com/gabriel-98/bingo-backend/internal/infrastructure/repositories/pgrepos"
 	"github.com/sirupsen/logrus"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"time"
 )
 
 var (
@@ -32,7 +37,7 @@ func main() {
 	log.Info("Loading configuration ...")
 	cfg, err := config.LoadConfig(configFilepath)
 	if err != nil {
-		log.Error("Error loading configuration: %s", err)
+		log.Errorf("Error loading configuration: %s", err)
 		return
 	}
 	log.Info("Configuration loaded")
@@ -52,42 +57,106 @@ func main() {
 	)
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
 	if err != nil {
-		log.Error("Error opening database: %s", err)
+		log.Errorf("Error opening database: %s", err)
 		return
 	}
 	log.Info("Successful connection to the database")
 
 	// Migrate models to the database.
 	log.Info("Migrating models to the database ...")
+	if err := pgrepos.MigrateRefreshTokenHashes(db); err != nil {
+		log.Errorf("Error migrating refresh tokens to hashed storage: %s", err)
+		return
+	}
+	if err := pgrepos.MigrateRefreshTokenFamilies(db); err != nil {
+		log.Errorf("Error migrating refresh token families: %s", err)
+		return
+	}
+	if err := pgrepos.MigrateUserRoles(db); err != nil {
+		log.Errorf("Error migrating user roles: %s", err)
+		return
+	}
 	err = db.AutoMigrate(
 		&entities.User{},
+		&entities.UserRole{},
 		&entities.RefreshToken{},
+		&entities.RevokedToken{},
+		&entities.LoginAttempt{},
+		&entities.UserTotp{},
+		&entities.RecoveryCode{},
+		&entities.OneTimeToken{},
+		&entities.UserIdentity{},
+		&entities.OidcAuthRequest{},
+		&entities.Organization{},
+		&entities.OrganizationMember{},
+		&entities.ApiKey{},
+		&entities.LedgerAccount{},
+		&entities.LedgerTransaction{},
+		&entities.LedgerEntry{},
+		&entities.Game{},
+		&entities.GameCard{},
+		&entities.GameWinner{},
+		&entities.Jackpot{},
+		&entities.GameTemplate{},
 	)
 	if err != nil {
-		log.Error("Error migrating models to the database: %s", err)
+		log.Errorf("Error migrating models to the database: %s", err)
 		return
 	}
 	log.Info("Model migration to the database completed")
 
 	// Initialization of repositories.
 	log.Info("Initializing repositories ...")
-	dao := InitRepositories()
+	dao, err := InitRepositories(cfg)
+	if err != nil {
+		log.Errorf("Error initializing repositories: %s", err)
+		return
+	}
 	log.Info("Repositories initialized successfully")
 
 	// Initialization of providers.
 	log.Info("Initializing providers ...")
-	providerGroup := InitProviders(cfg)
+	providerGroup, err := InitProviders(cfg, log)
+	if err != nil {
+		log.Errorf("Error initializing providers: %s", err)
+		return
+	}
 	log.Info("Providers initialized successfully")
 
 	// Initialization of services.
 	log.Info("Initializing services ...")
-	serviceGroup := InitServices(dao, providerGroup)
+	serviceGroup := InitServices(cfg, dao, providerGroup)
 	log.Info("Services initialized successfully")
 
+	// Grant the admin role to the bootstrap admins.
+	if len(cfg.Auth.BootstrapAdmins) > 0 {
+		log.Info("Granting the admin role to the bootstrap admins ...")
+		bootstrapCtx := pgrepos.WithQueryExecutor(context.Background(), db)
+		missingUsernames, err := serviceGroup.UserService().BootstrapAdmins(bootstrapCtx, cfg.Auth.BootstrapAdmins)
+		if err != nil {
+			log.Errorf("Error granting the admin role to the bootstrap admins: %s", err)
+			return
+		}
+		for _, username := range missingUsernames {
+			log.Warnf("Bootstrap admin %q not found: the user must sign up first", username)
+		}
+		log.Info("Admin role granted to the bootstrap admins")
+	}
+
+	// Start the background jobs.
+	log.Info("Starting background jobs ...")
+	jobsCtx := pgrepos.WithQueryExecutor(context.Background(), db)
+	StartJobs(jobsCtx, cfg, dao, log)
+	log.Info("Background jobs started")
+
 	// Initialization of the rest server.
 	log.Info("Initializing REST server ...")
 	port := cfg.Server.Port
-	restServer := rest.NewServer(port, serviceGroup, db)
+	restServer, err := rest.NewServer(port, cfg.Server.TrustedProxies, serviceGroup, db)
+	if err != nil {
+		log.Errorf("Error initializing REST server: %s", err)
+		return
+	}
 	log.Info("REST server initialized successfully")
 	
 	// Run the REST server.
@@ -96,24 +165,168 @@ func main() {
 	log.Info("REST server has been stopped")
 }
 
-func InitRepositories() *domain.DAO {
+func InitRepositories(cfg *config.Config) (*domain.DAO, error) {
+	var revokedTokenRepo domports.RevokedTokenRepo
+	switch cfg.Auth.RevocationStore {
+		case "postgres":
+			revokedTokenRepo = pgrepos.NewRevokedTokenRepo()
+		case "memory":
+			revokedTokenRepo = memrepos.NewRevokedTokenRepo()
+		default:
+			return nil, fmt.Errorf("unsupported revocation store: %q", cfg.Auth.RevocationStore)
+	}
+	var loginAttemptRepo domports.LoginAttemptRepo
+	switch cfg.Auth.LoginThrottle.Store {
+		case "postgres":
+			loginAttemptRepo = pgrepos.NewLoginAttemptRepo()
+		case "memory":
+			loginAttemptRepo = memrepos.NewLoginAttemptRepo()
+		default:
+			return nil, fmt.Errorf("unsupported login throttle store: %q", cfg.Auth.LoginThrottle.Store)
+	}
 	return domain.NewDAO(
 		pgrepos.NewUserRepo(),
+		pgrepos.NewUserRoleRepo(),
 		pgrepos.NewRefreshTokenRepo(),
-	)
+		revokedTokenRepo,
+		loginAttemptRepo,
+		pgrepos.NewUserTotpRepo(),
+		pgrepos.NewRecoveryCodeRepo(),
+		pgrepos.NewOneTimeTokenRepo(),
+		pgrepos.NewUserIdentityRepo(),
+		pgrepos.NewOidcAuthRequestRepo(),
+		pgrepos.NewOrganizationRepo(),
+		pgrepos.NewOrganizationMemberRepo(),
+		pgrepos.NewApiKeyRepo(),
+		pgrepos.NewLedgerAccountRepo(),
+		pgrepos.NewLedgerTransactionRepo(),
+		pgrepos.NewTransactionManager(),
+		pgrepos.NewGameRepo(),
+		pgrepos.NewGameCardRepo(),
+		pgrepos.NewGameWinnerRepo(),
+		pgrepos.NewJackpotRepo(),
+		pgrepos.NewGameTemplateRepo(),
+		pgrepos.NewLockRepo(),
+	), nil
 }
 
-func InitProviders(cfg *config.Config) *application.ProviderGroup {
+func InitProviders(cfg *config.Config, log *logrus.Logger) (*application.ProviderGroup, error) {
+	passwordManager, err := providers.NewPasswordManager(cfg.PasswordHashing)
+	if err != nil {
+		return nil, err
+	}
+	authTokenManager, err := providers.NewAuthTokenManager(cfg.Auth)
+	if err != nil {
+		return nil, err
+	}
+	totpManager, err := providers.NewTotpManager(cfg.Auth.Totp)
+	if err != nil {
+		return nil, err
+	}
+	mailer, err := providers.NewMailer(cfg.Mail)
+	if err != nil {
+		return nil, err
+	}
+	oidcManager, err := providers.NewOidcManager(cfg.Auth.Oidc)
+	if err != nil {
+		return nil, err
+	}
 	return application.NewProviderGroup(
-		providers.NewPasswordManager(cfg.PasswordHashing.Bcrypt.Cost),
-		providers.NewAuthTokenManager(cfg.Auth),
-	)
+		passwordManager,
+		authTokenManager,
+		totpManager,
+		providers.NewAsyncMailer(mailer, log),
+		oidcManager,
+	), nil
 }
 
-func InitServices(dao *domain.DAO, providerGroup *application.ProviderGroup) *application.ServiceGroup {
+func InitServices(cfg *config.Config, dao *domain.DAO, providerGroup *application.ProviderGroup) *application.ServiceGroup {
 	passwordManager := providerGroup.PasswordManager()
 	authTokenManager := providerGroup.AuthTokenManager()
+	totpManager := providerGroup.TotpManager()
+	mailer := providerGroup.Mailer()
+	oidcManager := providerGroup.OidcManager()
+	throttleConfig := cfg.Auth.LoginThrottle
+	loginThrottle := services.NewLoginThrottle(dao, services.LoginThrottlePolicy{
+		FreeAttempts: throttleConfig.FreeAttempts,
+		BaseDelay: throttleConfig.BaseDelay,
+		MaxDelay: throttleConfig.MaxDelay,
+		LockoutThreshold: throttleConfig.LockoutThreshold,
+		LockoutDuration: throttleConfig.LockoutDuration,
+		FailureWindow: throttleConfig.FailureWindow,
+	})
+	ledger := services.NewLedger(dao)
 	return application.NewServiceGroup(
-		services.NewAuthService(dao, passwordManager, authTokenManager),
+		services.NewAuthService(
+			dao,
+			passwordManager,
+			authTokenManager,
+			totpManager,
+			mailer,
+			oidcManager,
+			loginThrottle,
+			services.AuthServiceConfig{
+				UsernameChangeCooldown: cfg.Account.UsernameChangeCooldown,
+				PasswordResetTokenDuration: cfg.Auth.PasswordReset.TokenDuration,
+				PasswordResetUrl: cfg.Auth.PasswordReset.Url,
+				PasswordResetResendCooldown: cfg.Auth.PasswordReset.ResendCooldown,
+				EmailVerificationTokenDuration: cfg.Auth.EmailVerification.TokenDuration,
+				EmailVerificationUrl: cfg.Auth.EmailVerification.Url,
+				EmailVerificationResendCooldown: cfg.Auth.EmailVerification.ResendCooldown,
+				VerifiedEmailRequiredForPaidGames: cfg.Auth.EmailVerification.RequiredForPaidGames,
+				OidcAuthRequestDuration: cfg.Auth.Oidc.AuthRequestDuration,
+				GuestLoginEnabled: cfg.Auth.Guest.Enabled,
+				GuestInactivityTimeout: cfg.Auth.Guest.InactivityTimeout,
+			},
+		),
+		services.NewApiKeyService(dao, services.ApiKeyServiceConfig{
+			VerifiedEmailRequiredForPaidGames: cfg.Auth.EmailVerification.RequiredForPaidGames,
+		}),
+		services.NewUserService(dao),
+		services.NewOrganizationService(dao),
+		services.NewWalletService(dao, ledger),
+		services.NewGameService(dao, ledger, services.GameServiceConfig{
+			HouseCutPercent: cfg.Games.HouseCutPercent,
+		}),
+		services.NewJackpotService(dao, ledger),
+		services.NewGameTemplateService(dao),
 	)
+}
+
+func StartJobs(ctx context.Context, cfg *config.Config, dao *domain.DAO, log *logrus.Logger) {
+	janitorConfig := cfg.Jobs.RefreshTokenJanitor
+	if janitorConfig.Enabled {
+		janitor := jobs.NewRefreshTokenJanitor(dao, janitorConfig.Interval, janitorConfig.BatchSize, log)
+		go janitor.Run(ctx)
+	}
+	anonymizerConfig := cfg.Jobs.AccountAnonymizer
+	if anonymizerConfig.Enabled {
+		anonymizer := jobs.NewAccountAnonymizer(
+			dao,
+			anonymizerConfig.Interval,
+			cfg.Account.DeletionGracePeriod,
+			anonymizerConfig.BatchSize,
+			log,
+		)
+		go anonymizer.Run(ctx)
+	}
+	guestReaperConfig := cfg.Jobs.GuestReaper
+	if guestReaperConfig.Enabled {
+		guestReaper := jobs.NewGuestReaper(dao, guestReaperConfig.Interval, guestReaperConfig.BatchSize, log)
+		go guestReaper.Run(ctx)
+	}
+	gameSchedulerConfig := cfg.Jobs.GameScheduler
+	if gameSchedulerConfig.Enabled {
+		location, _ := time.LoadLocation(cfg.Database.TimeZone) // Error is ignored (validated by config.LoadConfig).
+		gameScheduler := jobs.NewGameScheduler(
+			dao,
+			gameSchedulerConfig.Interval,
+			gameSchedulerConfig.ScheduleAhead,
+			gameSchedulerConfig.BatchSize,
+			location,
+			cfg.Games.HouseCutPercent,
+			log,
+		)
+		go gameScheduler.Run(ctx)
+	}
 }
\ No newline at end of file
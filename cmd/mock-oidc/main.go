@@ -0,0 +1,234 @@
+// Command mock-oidc runs a minimal OpenID Connect provider for local testing of the login
+// with external providers. Every authorization request is approved immediately, as the
+// user given by the "login_hint" parameter (by default "mock-user"), so no login page is
+// involved. It supports the authorization code flow with PKCE (S256), and signs the ID
+// tokens with an RSA key generated on start.
+//
+// Usage:
+//
+//	go run ./cmd/mock-oidc -port 9002 -client-id bingo -client-secret mock-secret
+//
+// and uncomment the "mock" provider example in config/config.yaml.
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sirupsen/logrus"
+	"math/big"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const keyId = "mock-oidc"
+
+// An authorization is an issued authorization code, waiting to be exchanged for tokens.
+type authorization struct {
+	clientId string
+	redirectUri string
+	nonce string
+	codeChallenge string
+	subject string
+	expiresAt time.Time
+}
+
+type mockProvider struct {
+	issuer string
+	clientId string
+	clientSecret string
+	key *rsa.PrivateKey
+	log *logrus.Logger
+
+	mutex sync.Mutex
+	authorizations map[string]authorization
+}
+
+func main() {
+	port := flag.Int("port", 9002, "port to listen on")
+	issuer := flag.String("issuer", "", "issuer URL (default http://localhost:{port})")
+	clientId := flag.String("client-id", "bingo", "accepted client id")
+	clientSecret := flag.String("client-secret", "mock-secret", "accepted client secret (empty for public clients)")
+	flag.Parse()
+
+	log := logrus.New()
+	log.SetFormatter(&logrus.TextFormatter{
+		ForceColors: true,
+		FullTimestamp: true,
+	})
+	if *issuer == "" {
+		*issuer = fmt.Sprintf("http://localhost:%d", *port)
+	}
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		log.Errorf("Error generating signing key: %s", err)
+		return
+	}
+	provider := &mockProvider{
+		issuer: *issuer,
+		clientId: *clientId,
+		clientSecret: *clientSecret,
+		key: key,
+		log: log,
+		authorizations: map[string]authorization{},
+	}
+
+	router := gin.New()
+	router.GET("/.well-known/openid-configuration", provider.DiscoveryEndPoint)
+	router.GET("/authorize", provider.AuthorizeEndPoint)
+	router.POST("/token", provider.TokenEndPoint)
+	router.GET("/jwks", provider.JWKSEndPoint)
+	log.Infof("Mock OpenID Connect provider running at %s", provider.issuer)
+	router.Run(fmt.Sprintf(":%d", *port))
+}
+
+func (p *mockProvider) DiscoveryEndPoint(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"issuer": p.issuer,
+		"authorization_endpoint": p.issuer + "/authorize",
+		"token_endpoint": p.issuer + "/token",
+		"jwks_uri": p.issuer + "/jwks",
+		"response_types_supported": []string{ "code" },
+		"subject_types_supported": []string{ "public" },
+		"id_token_signing_alg_values_supported": []string{ "RS256" },
+		"code_challenge_methods_supported": []string{ "S256" },
+	})
+}
+
+// AuthorizeEndPoint approves the authorization request and redirects back to the client
+// with an authorization code.
+func (p *mockProvider) AuthorizeEndPoint(c *gin.Context) {
+	redirectUri, err := url.Parse(c.Query("redirect_uri"))
+	if err != nil || redirectUri.Scheme == "" {
+		c.String(http.StatusBadRequest, "invalid redirect_uri")
+		return
+	}
+	if c.Query("client_id") != p.clientId || c.Query("response_type") != "code" {
+		c.String(http.StatusBadRequest, "invalid client_id or response_type")
+		return
+	}
+	if c.Query("code_challenge") == "" || c.Query("code_challenge_method") != "S256" {
+		c.String(http.StatusBadRequest, "PKCE (S256) is required")
+		return
+	}
+	subject := c.DefaultQuery("login_hint", "mock-user")
+
+	// Issue the code, valid for one minute.
+	code := randomString()
+	p.mutex.Lock()
+	p.authorizations[code] = authorization{
+		clientId: p.clientId,
+		redirectUri: c.Query("redirect_uri"),
+		nonce: c.Query("nonce"),
+		codeChallenge: c.Query("code_challenge"),
+		subject: subject,
+		expiresAt: time.Now().Add(time.Minute),
+	}
+	p.mutex.Unlock()
+	p.log.Infof("Authorization approved for %q", subject)
+
+	query := redirectUri.Query()
+	query.Set("code", code)
+	query.Set("state", c.Query("state"))
+	redirectUri.RawQuery = query.Encode()
+	c.Redirect(http.StatusFound, redirectUri.String())
+}
+
+// TokenEndPoint exchanges an authorization code for an ID token.
+func (p *mockProvider) TokenEndPoint(c *gin.Context) {
+	tokenError := func(code string, description string) {
+		c.JSON(http.StatusBadRequest, gin.H{ "error": code, "error_description": description })
+	}
+
+	// Authenticate the client (client_secret_basic or client_secret_post).
+	clientId, clientSecret, basicAuth := c.Request.BasicAuth()
+	if basicAuth {
+		clientId, _ = url.QueryUnescape(clientId)
+		clientSecret, _ = url.QueryUnescape(clientSecret)
+	} else {
+		clientId, clientSecret = c.PostForm("client_id"), c.PostForm("client_secret")
+	}
+	if clientId != p.clientId || subtle.ConstantTimeCompare([]byte(clientSecret), []byte(p.clientSecret)) != 1 {
+		c.JSON(http.StatusUnauthorized, gin.H{ "error": "invalid_client" })
+		return
+	}
+
+	// Validate the code, which can be used once, and the PKCE code verifier.
+	if c.PostForm("grant_type") != "authorization_code" {
+		tokenError("unsupported_grant_type", "only authorization_code is supported")
+		return
+	}
+	code := c.PostForm("code")
+	p.mutex.Lock()
+	auth, found := p.authorizations[code]
+	delete(p.authorizations, code)
+	p.mutex.Unlock()
+	if !found || time.Now().After(auth.expiresAt) || auth.clientId != clientId || auth.redirectUri != c.PostForm("redirect_uri") {
+		tokenError("invalid_grant", "invalid or expired code")
+		return
+	}
+	digest := sha256.Sum256([]byte(c.PostForm("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(digest[:]) != auth.codeChallenge {
+		tokenError("invalid_grant", "invalid code_verifier")
+		return
+	}
+
+	// Issue the ID token.
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss": p.issuer,
+		"sub": auth.subject,
+		"aud": clientId,
+		"iat": now.Unix(),
+		"exp": now.Add(5 * time.Minute).Unix(),
+		"nonce": auth.nonce,
+		"email": auth.subject + "@mock-oidc.local",
+		"email_verified": true,
+		"name": auth.subject,
+		"preferred_username": auth.subject,
+	}
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	idToken.Header["kid"] = keyId
+	signedIdToken, err := idToken.SignedString(p.key)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{ "error": "server_error" })
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"access_token": randomString(),
+		"token_type": "Bearer",
+		"expires_in": 300,
+		"id_token": signedIdToken,
+	})
+}
+
+func (p *mockProvider) JWKSEndPoint(c *gin.Context) {
+	encode := func(b []byte) string {
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"keys": []gin.H{{
+			"kty": "RSA",
+			"use": "sig",
+			"kid": keyId,
+			"alg": "RS256",
+			"n": encode(p.key.N.Bytes()),
+			"e": encode(big.NewInt(int64(p.key.E)).Bytes()),
+		}},
+	})
+}
+
+func randomString() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
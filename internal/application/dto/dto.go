@@ -1,8 +1,14 @@
 package dto
 
+import (
+	"github.com/gabriel-98/bingo-backend/internal/application/types"
+	"time"
+)
+
 type SignupRequest struct {
 	Username string `json:"username" example:"username"`
 	Password string `json:"password" example:"MyPassword123"`
+	Email string `json:"email" example:"jdoe@example.com"`
 }
 
 type SignupResponse struct {
@@ -10,14 +16,143 @@ type SignupResponse struct {
 	Username string `json:"username" example:"jdoe65"`
 }
 
+// ClientInfo describes the client that sent a request. It is filled by the API layer,
+// never from the request body.
+type ClientInfo struct {
+	UserAgent string
+	IpAddress string
+}
+
 type LoginRequest struct {
 	Username string `json:"username" example:"username"`
 	Password string `json:"password" example:"MyPassword123"`
+	DeviceName string `json:"device_name" example:"My laptop"`
+	ClientInfo `json:"-"`
 }
 
+// A LoginResponse contains the tokens of the new session, or, if the user has two-factor
+// authentication enabled, the MFA token to send along with the second factor to
+// POST /auth/login/mfa.
 type LoginResponse struct {
-	AccessToken string `json:"access_token" example:"51bt4584hjfh16fw5..."`
-	RefreshToken string `json:"refresh_token" example:"f5t4gb61j65hf5g4d..."`
+	AccessToken string `json:"access_token,omitempty" example:"51bt4584hjfh16fw5..."`
+	RefreshToken string `json:"refresh_token,omitempty" example:"f5t4gb61j65hf5g4d..."`
+	MfaRequired bool `json:"mfa_required,omitempty" example:"false"`
+	MfaToken string `json:"mfa_token,omitempty" example:"a3c7d9e1f5b2h8k4m..."`
+}
+
+// A LoginMfaRequest completes the login of a user with two-factor authentication. Either
+// a TOTP code or a recovery code must be set.
+type LoginMfaRequest struct {
+	MfaToken string `json:"mfa_token" example:"a3c7d9e1f5b2h8k4m..."`
+	Code string `json:"code" example:"492039"`
+	RecoveryCode string `json:"recovery_code" example:"k7dm2-xq9pa"`
+	DeviceName string `json:"device_name" example:"My laptop"`
+	ClientInfo `json:"-"`
+}
+
+// An OidcLoginStartResponse contains the URL of the external provider where the user
+// must be redirected to log in.
+type OidcLoginStartResponse struct {
+	AuthorizationUrl string `json:"authorization_url" example:"https://accounts.example.com/authorize?..."`
+}
+
+// An OidcCallbackRequest contains the parameters of the redirection back from an
+// external provider: the authorization code and state, or the error if the login was
+// denied.
+type OidcCallbackRequest struct {
+	Provider string
+	State string
+	Code string
+	Error string
+	DeviceName string
+	ClientInfo
+}
+
+type TotpEnrollmentResponse struct {
+	Secret string `json:"secret" example:"JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"`
+	OtpauthUri string `json:"otpauth_uri" example:"otpauth://totp/Bingo:jdoe65?secret=JBSWY3DPEHPK3PXP..."`
+}
+
+type TotpConfirmRequest struct {
+	Code string `json:"code" example:"492039"`
+}
+
+type TotpDisableRequest struct {
+	Code string `json:"code" example:"492039"`
+	RecoveryCode string `json:"recovery_code" example:"k7dm2-xq9pa"`
+}
+
+// A ChangePasswordRequest contains the new password and the credentials that confirm
+// the identity of the user: the current password, or, for accounts without a password,
+// a TOTP or recovery code (see ReauthenticationRequest).
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" example:"MyPassword123"`
+	Code string `json:"code" example:"492039"`
+	RecoveryCode string `json:"recovery_code" example:"k7dm2-xq9pa"`
+	NewPassword string `json:"new_password" example:"MyNewPassword456"`
+	ClientInfo `json:"-"`
+}
+
+type GuestLoginRequest struct {
+	DeviceName string `json:"device_name" example:"My laptop"`
+	ClientInfo `json:"-"`
+}
+
+// An UpgradeGuestRequest contains the credentials of the full account a guest account
+// becomes. The email is optional.
+type UpgradeGuestRequest struct {
+	Username string `json:"username" example:"jdoe65"`
+	Password string `json:"password" example:"MyPassword123"`
+	Email string `json:"email" example:"jdoe@example.com"`
+	DeviceName string `json:"device_name" example:"My laptop"`
+	ClientInfo `json:"-"`
+}
+
+type ChangeUsernameRequest struct {
+	Username string `json:"username" example:"jdoe66"`
+}
+
+// A ReauthenticationRequest contains the credentials that confirm the identity of a user
+// for a sensitive change: the password, or, for accounts without a password, a TOTP or
+// recovery code.
+type ReauthenticationRequest struct {
+	Password string `json:"password" example:"MyPassword123"`
+	Code string `json:"code" example:"492039"`
+	RecoveryCode string `json:"recovery_code" example:"k7dm2-xq9pa"`
+}
+
+type DeleteAccountRequest struct {
+	ReauthenticationRequest
+}
+
+type ChangeEmailRequest struct {
+	Email string `json:"email" example:"jdoe@example.com"`
+	ReauthenticationRequest
+}
+
+type AccountResponse struct {
+	Id int64 `json:"id" example:"10253117"`
+	Username string `json:"username" example:"jdoe65"`
+	Email string `json:"email,omitempty" example:"jdoe@example.com"`
+	EmailVerified bool `json:"email_verified" example:"true"`
+	Guest bool `json:"guest,omitempty" example:"false"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" example:"Xq3v9TfK0bW2..."`
+}
+
+type PasswordResetRequest struct {
+	Email string `json:"email" example:"jdoe@example.com"`
+}
+
+type PasswordResetConfirmRequest struct {
+	Token string `json:"token" example:"Xq3v9TfK0bW2..."`
+	NewPassword string `json:"new_password" example:"MyNewPassword456"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes" example:"k7dm2-xq9pa,3hfwr-c8tzn"`
 }
 
 type LogoutRequest struct {
@@ -26,8 +161,275 @@ type LogoutRequest struct {
 
 type RefreshTokenRequest struct {
 	RefreshToken string `json:"refresh_token" example:"f5t4gb61j65hf5g4d..."`
+	ClientInfo `json:"-"`
 }
 
 type RefreshTokenResponse struct {
 	AccessToken string `json:"access_token" example:"51bt4584hjfh16fw5..."`
-}
\ No newline at end of file
+	RefreshToken string `json:"refresh_token" example:"f5t4gb61j65hf5g4d..."`
+}
+
+type SessionResponse struct {
+	Id string `json:"id" example:"9f86d081884c7d659a2feaa0c55ad015"`
+	DeviceName string `json:"device_name" example:"Chrome on Windows"`
+	UserAgent string `json:"user_agent" example:"Mozilla/5.0 (Windows NT 10.0; Win64; x64) ..."`
+	IpAddress string `json:"ip_address" example:"203.0.113.7"`
+	CreatedAt time.Time `json:"created_at" example:"2026-10-19T20:00:00Z"`
+	LastUsedAt time.Time `json:"last_used_at" example:"2026-10-19T20:05:00Z"`
+	ExpiresAt time.Time `json:"expires_at" example:"2026-10-19T20:15:00Z"`
+	Current bool `json:"current" example:"true"`
+}
+
+type JWKSResponse struct {
+	Keys []types.JSONWebKey `json:"keys"`
+}
+
+type UserRolesRequest struct {
+	Roles []string `json:"roles" example:"player,host"`
+}
+
+type UserRolesResponse struct {
+	UserId int64 `json:"user_id" example:"10253117"`
+	Roles []string `json:"roles" example:"player,host"`
+}
+
+// A CreateApiKeyRequest creates an API key of the user or, if OrganizationId is set, of
+// the organization. The scopes are permissions the creator has been granted.
+type CreateApiKeyRequest struct {
+	Name string `json:"name" example:"Hall #12 integration"`
+	Scopes []string `json:"scopes" example:"games:join"`
+	OrganizationId *int64 `json:"organization_id" example:"12"`
+	ExpiresAt *time.Time `json:"expires_at" example:"2027-01-01T00:00:00Z"`
+}
+
+type ApiKeyResponse struct {
+	Id int64 `json:"id" example:"381"`
+	Name string `json:"name" example:"Hall #12 integration"`
+	Prefix string `json:"prefix" example:"bgk_3fA9x2"`
+	Scopes []string `json:"scopes" example:"games:join"`
+	OrganizationId *int64 `json:"organization_id,omitempty" example:"12"`
+	CreatedAt time.Time `json:"created_at" example:"2026-05-01T18:03:11Z"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty" example:"2027-01-01T00:00:00Z"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" example:"2026-05-02T09:41:37Z"`
+	Revoked bool `json:"revoked" example:"false"`
+}
+
+// A CreateApiKeyResponse contains the created API key. The key is only returned here,
+// and cannot be retrieved again.
+type CreateApiKeyResponse struct {
+	ApiKeyResponse
+	Key string `json:"key" example:"bgk_3fA9x2Lq0v..."`
+}
+
+type CreateOrganizationRequest struct {
+	Name string `json:"name" example:"Lucky Hall"`
+}
+
+type OrganizationResponse struct {
+	Id int64 `json:"id" example:"12"`
+	Name string `json:"name" example:"Lucky Hall"`
+	Role string `json:"role" example:"owner"`
+}
+
+type OrganizationMemberRequest struct {
+	Role string `json:"role" example:"member"`
+}
+
+type OrganizationMemberResponse struct {
+	OrganizationId int64 `json:"organization_id" example:"12"`
+	UserId int64 `json:"user_id" example:"10253117"`
+	Role string `json:"role" example:"member"`
+}
+
+// A WalletResponse contains the balance of the user's wallet and its last movements.
+type WalletResponse struct {
+	Balance int64 `json:"balance" example:"2500"`
+	Entries []WalletEntryResponse `json:"entries"`
+}
+
+type WalletEntryResponse struct {
+	TransactionId int64 `json:"transaction_id" example:"8812"`
+	Type string `json:"type" example:"card_purchase"`
+	Amount int64 `json:"amount" example:"-500"`
+	Reference string `json:"reference,omitempty" example:"game:12"`
+	Description string `json:"description,omitempty" example:"5 cards"`
+	CreatedAt time.Time `json:"created_at" example:"2026-05-01T18:03:11Z"`
+}
+
+// A WalletAdjustmentRequest credits (positive amount) or debits (negative amount) the
+// wallet of a user.
+type WalletAdjustmentRequest struct {
+	Amount int64 `json:"amount" example:"1000"`
+	Reason string `json:"reason" example:"Welcome bonus"`
+	IdempotencyKey string `json:"-"`
+}
+
+type LedgerTransactionResponse struct {
+	Id int64 `json:"id" example:"8812"`
+	Type string `json:"type" example:"adjustment"`
+	Reference string `json:"reference,omitempty" example:"user:10253117"`
+	Description string `json:"description,omitempty" example:"Welcome bonus"`
+	CreatedAt time.Time `json:"created_at" example:"2026-05-01T18:03:11Z"`
+
+	// Replayed is set if the transaction had already been recorded with the same
+	// idempotency key, and nothing was recorded this time.
+	Replayed bool `json:"replayed" example:"false"`
+}
+
+// A CreateGameRequest creates a game. If BundleSize is set, every BundleSize cards of a
+// purchase cost BundlePrice instead of CardPrice each. A limit of 0 means no limit. The
+// pattern is "full_card" and the prize type is "fixed" unless otherwise set.
+type CreateGameRequest struct {
+	Name string `json:"name" example:"Friday night"`
+	CardPrice int64 `json:"card_price" example:"100"`
+	BundleSize int `json:"bundle_size" example:"6"`
+	BundlePrice int64 `json:"bundle_price" example:"500"`
+	MaxCardsPerPlayer int `json:"max_cards_per_player" example:"12"`
+	MaxCards int `json:"max_cards" example:"1000"`
+	Pattern string `json:"pattern" example:"line"`
+	PrizeType string `json:"prize_type" example:"guaranteed"`
+	PrizeAmount int64 `json:"prize_amount" example:"5000"`
+	PrizePercent int `json:"prize_percent" example:"70"`
+	JackpotId *int64 `json:"jackpot_id" example:"3"`
+}
+
+type GameResponse struct {
+	Id int64 `json:"id" example:"12"`
+	Name string `json:"name" example:"Friday night"`
+	HostId int64 `json:"host_id" example:"10253117"`
+	Status string `json:"status" example:"buying"`
+	CardPrice int64 `json:"card_price" example:"100"`
+	BundleSize int `json:"bundle_size" example:"6"`
+	BundlePrice int64 `json:"bundle_price" example:"500"`
+	MaxCardsPerPlayer int `json:"max_cards_per_player" example:"12"`
+	MaxCards int `json:"max_cards" example:"1000"`
+	Pattern string `json:"pattern" example:"line"`
+	PrizeType string `json:"prize_type" example:"guaranteed"`
+	PrizeAmount int64 `json:"prize_amount" example:"5000"`
+	PrizePercent int `json:"prize_percent" example:"70"`
+	HouseCutPercent int `json:"house_cut_percent" example:"10"`
+	DrawnNumbers []int `json:"drawn_numbers"`
+	JackpotId *int64 `json:"jackpot_id,omitempty" example:"3"`
+	TemplateId *int64 `json:"template_id,omitempty" example:"4"`
+	ScheduledAt *time.Time `json:"scheduled_at,omitempty" example:"2026-05-01T20:00:00-05:00"`
+	BuyingOpensAt *time.Time `json:"buying_opens_at,omitempty" example:"2026-05-01T19:30:00-05:00"`
+	CreatedAt time.Time `json:"created_at" example:"2026-05-01T18:00:00Z"`
+	StartedAt *time.Time `json:"started_at,omitempty" example:"2026-05-01T20:00:00Z"`
+	FinishedAt *time.Time `json:"finished_at,omitempty" example:"2026-05-01T20:14:52Z"`
+}
+
+// A PurchaseCardsRequest buys cards of a game. Retries with the same idempotency key
+// return the cards of the first purchase instead of buying new ones.
+type PurchaseCardsRequest struct {
+	Quantity int `json:"quantity" example:"6"`
+	IdempotencyKey string `json:"-"`
+}
+
+// A GameCardResponse contains the numbers of a card, row by row. The central cell is 0.
+type GameCardResponse struct {
+	Id int64 `json:"id" example:"5531"`
+	Numbers [5][5]int `json:"numbers"`
+}
+
+type CardPurchaseResponse struct {
+	GameId int64 `json:"game_id" example:"12"`
+	TransactionId *int64 `json:"transaction_id,omitempty" example:"8812"`
+	Price int64 `json:"price" example:"500"`
+	Cards []GameCardResponse `json:"cards"`
+
+	// Replayed is set if the purchase had already been made with the same idempotency
+	// key, and no new cards were bought this time.
+	Replayed bool `json:"replayed" example:"false"`
+}
+
+// A DrawResponse contains the number drawn, and the winners if the number finished the
+// game.
+type DrawResponse struct {
+	Number int `json:"number" example:"42"`
+	DrawnNumbers []int `json:"drawn_numbers"`
+	Status string `json:"status" example:"playing"`
+	Winners []GameWinnerResponse `json:"winners"`
+
+	// Jackpot is the progressive jackpot of the game after the draw, if it has one.
+	Jackpot *JackpotResponse `json:"jackpot,omitempty"`
+}
+
+type GameWinnerResponse struct {
+	CardId int64 `json:"card_id" example:"5531"`
+	UserId int64 `json:"user_id" example:"10253117"`
+	Prize int64 `json:"prize" example:"2250"`
+	BallCount int `json:"ball_count" example:"38"`
+	JackpotPrize int64 `json:"jackpot_prize,omitempty" example:"0"`
+}
+
+// A CreateJackpotRequest creates a progressive jackpot, fed by ContributionPercent of the
+// card sales of its games, and won by a full card within BallLimit balls.
+type CreateJackpotRequest struct {
+	Name string `json:"name" example:"Lucky Hall jackpot"`
+	ContributionPercent int `json:"contribution_percent" example:"5"`
+	BallLimit int `json:"ball_limit" example:"45"`
+}
+
+type JackpotResponse struct {
+	Id int64 `json:"id" example:"3"`
+	Name string `json:"name" example:"Lucky Hall jackpot"`
+	Value int64 `json:"value" example:"125000"`
+	ContributionPercent int `json:"contribution_percent" example:"5"`
+	BallLimit int `json:"ball_limit" example:"48"`
+	LastWonAt *time.Time `json:"last_won_at,omitempty" example:"2026-04-11T21:37:02Z"`
+}
+
+// A JackpotHistoryEntryResponse is a movement of a jackpot: a contribution of a game, or
+// a payout.
+type JackpotHistoryEntryResponse struct {
+	TransactionId int64 `json:"transaction_id" example:"9120"`
+	Type string `json:"type" example:"jackpot_contribution"`
+	Amount int64 `json:"amount" example:"350"`
+	Reference string `json:"reference" example:"game:12"`
+	CreatedAt time.Time `json:"created_at" example:"2026-05-01T20:14:52Z"`
+}
+
+// A CreateGameTemplateRequest creates a template of recurring games. Every time the
+// recurrence matches, GamesPerOccurrence games are scheduled GameInterval apart (e.g.,
+// "15m"), playing the patterns in turn. Their cards go on sale BuyingLeadTime (e.g.,
+// "30m") before each game. The other fields are the settings of the games.
+type CreateGameTemplateRequest struct {
+	Name string `json:"name" example:"Evening session"`
+	Variant string `json:"variant" example:"75-ball"`
+	Patterns []string `json:"patterns" example:"line,full_card"`
+	CardPrice int64 `json:"card_price" example:"100"`
+	BundleSize int `json:"bundle_size" example:"6"`
+	BundlePrice int64 `json:"bundle_price" example:"500"`
+	MaxCardsPerPlayer int `json:"max_cards_per_player" example:"12"`
+	MaxCards int `json:"max_cards" example:"1000"`
+	PrizeType string `json:"prize_type" example:"percentage"`
+	PrizeAmount int64 `json:"prize_amount" example:"0"`
+	PrizePercent int `json:"prize_percent" example:"70"`
+	JackpotId *int64 `json:"jackpot_id" example:"3"`
+	Recurrence string `json:"recurrence" example:"0 20 * * *"`
+	GamesPerOccurrence int `json:"games_per_occurrence" example:"10"`
+	GameInterval string `json:"game_interval" example:"15m"`
+	BuyingLeadTime string `json:"buying_lead_time" example:"30m"`
+}
+
+type GameTemplateResponse struct {
+	Id int64 `json:"id" example:"4"`
+	Name string `json:"name" example:"Evening session"`
+	Variant string `json:"variant" example:"75-ball"`
+	Patterns []string `json:"patterns" example:"line,full_card"`
+	CardPrice int64 `json:"card_price" example:"100"`
+	BundleSize int `json:"bundle_size" example:"6"`
+	BundlePrice int64 `json:"bundle_price" example:"500"`
+	MaxCardsPerPlayer int `json:"max_cards_per_player" example:"12"`
+	MaxCards int `json:"max_cards" example:"1000"`
+	PrizeType string `json:"prize_type" example:"percentage"`
+	PrizeAmount int64 `json:"prize_amount" example:"0"`
+	PrizePercent int `json:"prize_percent" example:"70"`
+	JackpotId *int64 `json:"jackpot_id,omitempty" example:"3"`
+	Recurrence string `json:"recurrence" example:"0 20 * * *"`
+	GamesPerOccurrence int `json:"games_per_occurrence" example:"10"`
+	GameInterval string `json:"game_interval" example:"15m0s"`
+	BuyingLeadTime string `json:"buying_lead_time" example:"30m0s"`
+	Enabled bool `json:"enabled" example:"true"`
+	ScheduledUntil time.Time `json:"scheduled_until" example:"2026-05-02T20:00:00-05:00"`
+}
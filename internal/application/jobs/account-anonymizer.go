@@ -0,0 +1,113 @@
+package jobs
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"github.com/gabriel-98/bingo-backend/internal/domain"
+	"time"
+)
+
+// Metrics of the AccountAnonymizer, published through expvar.
+var (
+	anonymizedAccounts = expvar.NewInt("account_anonymizer_anonymized_total")
+	accountAnonymizerRuns = expvar.NewInt("account_anonymizer_runs_total")
+	accountAnonymizerErrors = expvar.NewInt("account_anonymizer_errors_total")
+)
+
+// accountAnonymizerLock is the name of the lock that ensures that only one instance of
+// the application anonymizes the accounts at a time.
+const accountAnonymizerLock = "jobs:account-anonymizer"
+
+// An AccountAnonymizer periodically anonymizes the accounts deleted more than a grace
+// period ago: their personal data and credentials are erased, but the users are kept so
+// that the game history that references them stays intact.
+type AccountAnonymizer struct {
+	dao *domain.DAO
+	interval time.Duration
+	gracePeriod time.Duration
+	batchSize int
+	log Logger
+}
+
+func NewAccountAnonymizer(dao *domain.DAO, interval time.Duration, gracePeriod time.Duration, batchSize int, log Logger) *AccountAnonymizer {
+	return &AccountAnonymizer{
+		dao: dao,
+		interval: interval,
+		gracePeriod: gracePeriod,
+		batchSize: batchSize,
+		log: log,
+	}
+}
+
+// Run anonymizes the accounts past the grace period every interval until the context is
+// cancelled. The context must carry what the repositories need (e.g., the query
+// executor).
+func (j *AccountAnonymizer) Run(ctx context.Context) {
+	runPeriodically(ctx, j.interval, j.anonymize)
+}
+
+// anonymize anonymizes the accounts past the grace period, batch by batch, if no other
+// instance is doing it.
+func (j *AccountAnonymizer) anonymize(ctx context.Context) {
+	// Required repositories.
+	userRepo := j.dao.UserRepo()
+	lockRepo := j.dao.LockRepo()
+
+	// Acquire the lock, or skip this run if another instance holds it.
+	release, acquired, err := lockRepo.TryLock(ctx, accountAnonymizerLock)
+	if err != nil {
+		accountAnonymizerErrors.Add(1)
+		j.log.Errorf("Account anonymizer: failed to acquire the lock: %s", err)
+		return
+	}
+	if !acquired {
+		return
+	}
+	defer release()
+	accountAnonymizerRuns.Add(1)
+
+	// Anonymize the accounts until a batch is not full.
+	now := time.Now()
+	var anonymized int64
+	for {
+		users, err := userRepo.FindDeletedBefore(ctx, now.Add(-j.gracePeriod), j.batchSize)
+		if err != nil {
+			accountAnonymizerErrors.Add(1)
+			j.log.Errorf("Account anonymizer: failed to find deleted accounts: %s", err)
+			break
+		}
+		for _, user := range users {
+			if err := j.anonymizeUser(ctx, user.Id, now); err != nil {
+				accountAnonymizerErrors.Add(1)
+				j.log.Errorf("Account anonymizer: failed to anonymize user %d: %s", user.Id, err)
+				return
+			}
+			anonymized++
+			anonymizedAccounts.Add(1)
+		}
+		if len(users) < j.batchSize || ctx.Err() != nil {
+			break
+		}
+	}
+	if anonymized > 0 {
+		j.log.Infof("Account anonymizer: %d deleted accounts anonymized", anonymized)
+	}
+}
+
+// anonymizeUser erases the personal data and credentials of a deleted user.
+func (j *AccountAnonymizer) anonymizeUser(ctx context.Context, userId int64, now time.Time) error {
+	if _, err := j.dao.UserRoleRepo().Replace(ctx, userId, nil); err != nil {
+		return err
+	}
+	if err := j.dao.RecoveryCodeRepo().DeleteByUserId(ctx, userId); err != nil {
+		return err
+	}
+	if err := j.dao.UserTotpRepo().Delete(ctx, userId); err != nil {
+		return err
+	}
+	if err := j.dao.UserIdentityRepo().DeleteByUserId(ctx, userId); err != nil {
+		return err
+	}
+	return j.dao.UserRepo().Anonymize(ctx, userId, fmt.Sprintf("deleted-user-%d", userId), now)
+}
@@ -0,0 +1,210 @@
+package jobs
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"github.com/gabriel-98/bingo-backend/internal/application/types"
+	"github.com/gabriel-98/bingo-backend/internal/domain"
+	"github.com/gabriel-98/bingo-backend/internal/domain/entities"
+	"strings"
+	"time"
+)
+
+// Metrics of the GameScheduler, published through expvar.
+var (
+	scheduledGames = expvar.NewInt("game_scheduler_created_total")
+	gameSchedulerRuns = expvar.NewInt("game_scheduler_runs_total")
+	gameSchedulerErrors = expvar.NewInt("game_scheduler_errors_total")
+)
+
+// gameSchedulerLock is the name of the lock that ensures that only one instance of the
+// application schedules the games at a time.
+const gameSchedulerLock = "jobs:game-scheduler"
+
+// A GameScheduler periodically creates the games of the enabled templates whose
+// recurrence matches within scheduleAhead, opens the buying of the scheduled games at
+// their lead time, and starts them at their scheduled time. The recurrences are
+// evaluated in the given location. Everything is stored, so the schedule survives
+// restarts, and a template cannot have two games at the same time, so games are never
+// created twice, even by several instances.
+type GameScheduler struct {
+	dao *domain.DAO
+	interval time.Duration
+	scheduleAhead time.Duration
+	batchSize int
+	location *time.Location
+	houseCutPercent int
+	log Logger
+}
+
+func NewGameScheduler(
+		dao *domain.DAO,
+		interval time.Duration,
+		scheduleAhead time.Duration,
+		batchSize int,
+		location *time.Location,
+		houseCutPercent int,
+		log Logger,
+		) *GameScheduler {
+			return &GameScheduler{
+				dao: dao,
+				interval: interval,
+				scheduleAhead: scheduleAhead,
+				batchSize: batchSize,
+				location: location,
+				houseCutPercent: houseCutPercent,
+				log: log,
+			}
+}
+
+// Run schedules the games every interval until the context is cancelled. The context
+// must carry what the repositories need (e.g., the query executor).
+func (j *GameScheduler) Run(ctx context.Context) {
+	runPeriodically(ctx, j.interval, j.run)
+}
+
+// run schedules the games, if no other instance is doing it.
+func (j *GameScheduler) run(ctx context.Context) {
+	// Required repositories.
+	lockRepo := j.dao.LockRepo()
+
+	// Acquire the lock, or skip this run if another instance holds it.
+	release, acquired, err := lockRepo.TryLock(ctx, gameSchedulerLock)
+	if err != nil {
+		gameSchedulerErrors.Add(1)
+		j.log.Errorf("Game scheduler: failed to acquire the lock: %s", err)
+		return
+	}
+	if !acquired {
+		return
+	}
+	defer release()
+	gameSchedulerRuns.Add(1)
+
+	now := time.Now()
+	j.createGames(ctx, now)
+	j.advanceGames(ctx, now, types.GameScheduled, types.GameBuying)
+	j.advanceGames(ctx, now, types.GameBuying, types.GamePlaying)
+}
+
+// createGames creates the games of the enabled templates scheduled up to scheduleAhead
+// from now. Occurrences that were missed (e.g., while no instance was running) are
+// skipped.
+func (j *GameScheduler) createGames(ctx context.Context, now time.Time) {
+	// Required repositories.
+	gameTemplateRepo := j.dao.GameTemplateRepo()
+
+	gameTemplates, err := gameTemplateRepo.FindEnabled(ctx)
+	if err != nil {
+		gameSchedulerErrors.Add(1)
+		j.log.Errorf("Game scheduler: failed to find the game templates: %s", err)
+		return
+	}
+	horizon := now.Add(j.scheduleAhead)
+	for _, gameTemplate := range gameTemplates {
+		recurrence, err := types.ParseRecurrence(gameTemplate.Recurrence)
+		if err != nil {
+			gameSchedulerErrors.Add(1)
+			j.log.Errorf("Game scheduler: template %d: %s", gameTemplate.Id, err)
+			continue
+		}
+		from := gameTemplate.ScheduledUntil
+		if from.Before(now) {
+			from = now
+		}
+		for occurrence := recurrence.Next(from.In(j.location)); !occurrence.IsZero() && !occurrence.After(horizon); occurrence = recurrence.Next(occurrence) {
+			if err := j.createOccurrence(ctx, &gameTemplate, occurrence); err != nil {
+				gameSchedulerErrors.Add(1)
+				j.log.Errorf("Game scheduler: failed to schedule the games of template %d at %s: %s", gameTemplate.Id, occurrence, err)
+				break
+			}
+		}
+	}
+}
+
+// createOccurrence creates the games of an occurrence of a template, and records that
+// the template has been scheduled up to it.
+func (j *GameScheduler) createOccurrence(ctx context.Context, gameTemplate *entities.GameTemplate, occurrence time.Time) error {
+	// Required repositories.
+	gameRepo := j.dao.GameRepo()
+	gameTemplateRepo := j.dao.GameTemplateRepo()
+	transactionManager := j.dao.TransactionManager()
+
+	patterns := strings.Split(gameTemplate.Patterns, ",")
+	return transactionManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		for i := 0; i < gameTemplate.GamesPerOccurrence; i++ {
+			scheduledAt := occurrence.Add(time.Duration(i) * gameTemplate.GameInterval)
+			buyingOpensAt := scheduledAt.Add(-gameTemplate.BuyingLeadTime)
+			created, err := gameRepo.CreateScheduled(ctx, entities.Game{
+				Name: fmt.Sprintf("%s %d/%d", gameTemplate.Name, i+1, gameTemplate.GamesPerOccurrence),
+				HostId: gameTemplate.HostId,
+				Status: string(types.GameScheduled),
+				CardPrice: gameTemplate.CardPrice,
+				BundleSize: gameTemplate.BundleSize,
+				BundlePrice: gameTemplate.BundlePrice,
+				MaxCardsPerPlayer: gameTemplate.MaxCardsPerPlayer,
+				MaxCards: gameTemplate.MaxCards,
+				Pattern: patterns[i % len(patterns)],
+				PrizeType: gameTemplate.PrizeType,
+				PrizeAmount: gameTemplate.PrizeAmount,
+				PrizePercent: gameTemplate.PrizePercent,
+				HouseCutPercent: j.houseCutPercent,
+				JackpotId: gameTemplate.JackpotId,
+				TemplateId: &gameTemplate.Id,
+				ScheduledAt: &scheduledAt,
+				BuyingOpensAt: &buyingOpensAt,
+			})
+			if err != nil {
+				return err
+			}
+			if created {
+				scheduledGames.Add(1)
+			}
+		}
+		return gameTemplateRepo.UpdateScheduledUntil(ctx, gameTemplate.Id, occurrence)
+	})
+}
+
+// advanceGames moves the games of templates that are due from one status to the next:
+// from scheduled to buying at their lead time, and from buying to playing at their
+// scheduled time. Each game is locked and its status checked again, so that a change
+// made meanwhile (e.g., by the host) is not overwritten.
+func (j *GameScheduler) advanceGames(ctx context.Context, now time.Time, from types.GameStatus, to types.GameStatus) {
+	// Required repositories.
+	gameRepo := j.dao.GameRepo()
+	transactionManager := j.dao.TransactionManager()
+
+	var games []entities.Game
+	var err error
+	if from == types.GameScheduled {
+		games, err = gameRepo.FindBuyingDue(ctx, now, j.batchSize)
+	} else {
+		games, err = gameRepo.FindStartDue(ctx, now, j.batchSize)
+	}
+	if err != nil {
+		gameSchedulerErrors.Add(1)
+		j.log.Errorf("Game scheduler: failed to find the %s games that are due: %s", from, err)
+		return
+	}
+	for _, dueGame := range games {
+		err := transactionManager.WithinTransaction(ctx, func(ctx context.Context) error {
+			game, err := gameRepo.LockById(ctx, dueGame.Id)
+			if err != nil {
+				return err
+			}
+			if game.Status != string(from) {
+				return nil
+			}
+			game.Status = string(to)
+			if to == types.GamePlaying {
+				game.StartedAt = &now
+			}
+			return gameRepo.Update(ctx, *game)
+		})
+		if err != nil {
+			gameSchedulerErrors.Add(1)
+			j.log.Errorf("Game scheduler: failed to move game %d to %s: %s", dueGame.Id, to, err)
+		}
+	}
+}
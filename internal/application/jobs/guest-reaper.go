@@ -0,0 +1,98 @@
+package jobs
+
+import (
+	"context"
+	"expvar"
+	"github.com/gabriel-98/bingo-backend/internal/domain"
+	"time"
+)
+
+// Metrics of the GuestReaper, published through expvar.
+var (
+	expiredGuests = expvar.NewInt("guest_reaper_deleted_total")
+	guestReaperRuns = expvar.NewInt("guest_reaper_runs_total")
+	guestReaperErrors = expvar.NewInt("guest_reaper_errors_total")
+)
+
+// guestReaperLock is the name of the lock that ensures that only one instance of the
+// application deletes the expired guests at a time.
+const guestReaperLock = "jobs:guest-reaper"
+
+// A GuestReaper periodically deletes the guest accounts that have expired after a period
+// of inactivity. Their sessions are ended and the accounts are soft deleted, so they are
+// anonymized later like any other deleted account.
+type GuestReaper struct {
+	dao *domain.DAO
+	interval time.Duration
+	batchSize int
+	log Logger
+}
+
+func NewGuestReaper(dao *domain.DAO, interval time.Duration, batchSize int, log Logger) *GuestReaper {
+	return &GuestReaper{
+		dao: dao,
+		interval: interval,
+		batchSize: batchSize,
+		log: log,
+	}
+}
+
+// Run deletes the expired guests every interval until the context is cancelled. The
+// context must carry what the repositories need (e.g., the query executor).
+func (j *GuestReaper) Run(ctx context.Context) {
+	runPeriodically(ctx, j.interval, j.reap)
+}
+
+// reap deletes the expired guests, batch by batch, if no other instance is doing it.
+func (j *GuestReaper) reap(ctx context.Context) {
+	// Required repositories.
+	userRepo := j.dao.UserRepo()
+	refreshTokenRepo := j.dao.RefreshTokenRepo()
+	lockRepo := j.dao.LockRepo()
+
+	// Acquire the lock, or skip this run if another instance holds it.
+	release, acquired, err := lockRepo.TryLock(ctx, guestReaperLock)
+	if err != nil {
+		guestReaperErrors.Add(1)
+		j.log.Errorf("Guest reaper: failed to acquire the lock: %s", err)
+		return
+	}
+	if !acquired {
+		return
+	}
+	defer release()
+	guestReaperRuns.Add(1)
+
+	// Delete the guests until a batch is not full. Deleted guests are no longer found, so
+	// every batch starts from the beginning.
+	now := time.Now()
+	var deleted int64
+	for {
+		users, err := userRepo.FindExpiredGuests(ctx, now, j.batchSize)
+		if err != nil {
+			guestReaperErrors.Add(1)
+			j.log.Errorf("Guest reaper: failed to find expired guests: %s", err)
+			break
+		}
+		for _, user := range users {
+			if err := refreshTokenRepo.DeleteByUserId(ctx, user.Id); err != nil {
+				guestReaperErrors.Add(1)
+				j.log.Errorf("Guest reaper: failed to end the sessions of user %d: %s", user.Id, err)
+				return
+			}
+			if err := userRepo.Delete(ctx, user.Id); err != nil {
+				guestReaperErrors.Add(1)
+				j.log.Errorf("Guest reaper: failed to delete user %d: %s", user.Id, err)
+				return
+			}
+			deleted++
+			expiredGuests.Add(1)
+		}
+		if len(users) < j.batchSize || ctx.Err() != nil {
+			break
+		}
+	}
+	if deleted > 0 {
+		j.log.Infof("Guest reaper: %d expired guests deleted", deleted)
+	}
+}
@@ -0,0 +1,29 @@
+// Package jobs provides the background jobs of the application, which run periodically
+// outside of any request.
+package jobs
+
+import (
+	"context"
+	"time"
+)
+
+// A Logger receives the messages of the background jobs.
+type Logger interface {
+	Infof(format string, args ...any)
+	Errorf(format string, args ...any)
+}
+
+// runPeriodically calls run every interval until the context is cancelled. The first
+// call happens immediately.
+func runPeriodically(ctx context.Context, interval time.Duration, run func(ctx context.Context)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		run(ctx)
+		select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package jobs
+
+import (
+	"context"
+	"expvar"
+	"github.com/gabriel-98/bingo-backend/internal/domain"
+	"time"
+)
+
+// Metrics of the RefreshTokenJanitor, published through expvar.
+var (
+	purgedRefreshTokens = expvar.NewInt("refresh_token_janitor_purged_total")
+	refreshTokenJanitorRuns = expvar.NewInt("refresh_token_janitor_runs_total")
+	refreshTokenJanitorErrors = expvar.NewInt("refresh_token_janitor_errors_total")
+)
+
+// refreshTokenJanitorLock is the name of the lock that ensures that only one instance
+// of the application purges the refresh tokens at a time.
+const refreshTokenJanitorLock = "jobs:refresh-token-janitor"
+
+// A RefreshTokenJanitor periodically deletes the expired refresh tokens, in batches.
+type RefreshTokenJanitor struct {
+	dao *domain.DAO
+	interval time.Duration
+	batchSize int
+	log Logger
+}
+
+func NewRefreshTokenJanitor(dao *domain.DAO, interval time.Duration, batchSize int, log Logger) *RefreshTokenJanitor {
+	return &RefreshTokenJanitor{
+		dao: dao,
+		interval: interval,
+		batchSize: batchSize,
+		log: log,
+	}
+}
+
+// Run purges the expired refresh tokens every interval until the context is cancelled.
+// The context must carry what the repositories need (e.g., the query executor).
+func (j *RefreshTokenJanitor) Run(ctx context.Context) {
+	runPeriodically(ctx, j.interval, j.purge)
+}
+
+// purge deletes the expired refresh tokens, batch by batch, if no other instance is
+// doing it.
+func (j *RefreshTokenJanitor) purge(ctx context.Context) {
+	// Required repositories.
+	refreshTokenRepo := j.dao.RefreshTokenRepo()
+	lockRepo := j.dao.LockRepo()
+
+	// Acquire the lock, or skip this run if another instance holds it.
+	release, acquired, err := lockRepo.TryLock(ctx, refreshTokenJanitorLock)
+	if err != nil {
+		refreshTokenJanitorErrors.Add(1)
+		j.log.Errorf("Refresh token janitor: failed to acquire the lock: %s", err)
+		return
+	}
+	if !acquired {
+		return
+	}
+	defer release()
+	refreshTokenJanitorRuns.Add(1)
+
+	// Delete the expired tokens until a batch is not full.
+	now := time.Now()
+	var purged int64
+	for {
+		deleted, err := refreshTokenRepo.DeleteExpired(ctx, now, j.batchSize)
+		purged += deleted
+		purgedRefreshTokens.Add(deleted)
+		if err != nil {
+			refreshTokenJanitorErrors.Add(1)
+			j.log.Errorf("Refresh token janitor: failed to delete expired tokens: %s", err)
+			break
+		}
+		if deleted < int64(j.batchSize) || ctx.Err() != nil {
+			break
+		}
+	}
+	if purged > 0 {
+		j.log.Infof("Refresh token janitor: %d expired refresh tokens purged", purged)
+	}
+}
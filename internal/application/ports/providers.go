@@ -1,13 +1,17 @@
 package ports
 
 import (
+	"context"
 	"github.com/gabriel-98/bingo-backend/internal/application/types"
 	"time"
 )
 
 type PasswordManager interface {
+	ValidatePassword(username string, password string) error
 	HashPassword(password string) (string, error)
 	CheckPassword(hashedPassword string, password string) bool
+	SimulateCheckPassword(password string)
+	NeedsRehash(hashedPassword string) bool
 }
 
 type AuthTokenManager interface {
@@ -15,5 +19,25 @@ type AuthTokenManager interface {
 	NewAccessToken(userAuthData types.UserAuthData) (string, error)
 	ValidateRefreshToken(tokenString string) (*types.UserAuthData, error)
 	ValidateAccessToken(tokenString string) (*types.UserAuthData, error)
+	NewMfaToken(userAuthData types.UserAuthData) (string, error)
+	ValidateMfaToken(tokenString string) (*types.UserAuthData, error)
+	AccessTokenPublicKeys() []types.JSONWebKey
 	IssuedAtAndExpiresAt(token string) (time.Time, time.Time, error)
+	TokenId(token string) (string, error)
+}
+
+type TotpManager interface {
+	NewSecret() (string, error)
+	ProvisioningUri(accountName string, secret string) string
+	ValidateCode(secret string, code string, at time.Time) (int64, bool)
+	NewRecoveryCodes() ([]string, error)
+}
+
+type Mailer interface {
+	Send(ctx context.Context, message types.MailMessage) error
+}
+
+type OidcManager interface {
+	AuthorizationUrl(ctx context.Context, provider string, state string, nonce string, codeChallenge string) (string, error)
+	ExchangeCode(ctx context.Context, provider string, code string, codeVerifier string) (*types.OidcIdentity, error)
 }
\ No newline at end of file
@@ -3,11 +3,84 @@ package ports
 import (
 	"context"
 	"github.com/gabriel-98/bingo-backend/internal/application/dto"
+	"github.com/gabriel-98/bingo-backend/internal/application/types"
 )
 
 type AuthService interface {
 	Signup(ctx context.Context, signupRequest dto.SignupRequest) (*dto.SignupResponse, error)
 	Login(ctx context.Context, loginRequest dto.LoginRequest) (*dto.LoginResponse, error)
+	LoginMfa(ctx context.Context, loginMfaRequest dto.LoginMfaRequest) (*dto.LoginResponse, error)
+	LoginGuest(ctx context.Context, guestLoginRequest dto.GuestLoginRequest) (*dto.LoginResponse, error)
+	UpgradeGuest(ctx context.Context, userAuthData types.UserAuthData, upgradeGuestRequest dto.UpgradeGuestRequest) (*dto.LoginResponse, error)
+	StartOidcLogin(ctx context.Context, provider string) (*dto.OidcLoginStartResponse, error)
+	CompleteOidcLogin(ctx context.Context, oidcCallbackRequest dto.OidcCallbackRequest) (*dto.LoginResponse, error)
+	EnrollTotp(ctx context.Context, userAuthData types.UserAuthData) (*dto.TotpEnrollmentResponse, error)
+	ConfirmTotp(ctx context.Context, userAuthData types.UserAuthData, totpConfirmRequest dto.TotpConfirmRequest) (*dto.RecoveryCodesResponse, error)
+	DisableTotp(ctx context.Context, userAuthData types.UserAuthData, totpDisableRequest dto.TotpDisableRequest) error
 	Logout(ctx context.Context, logoutRequest dto.LogoutRequest) error
+	LogoutAll(ctx context.Context, userAuthData types.UserAuthData) error
+	ListSessions(ctx context.Context, userAuthData types.UserAuthData) ([]dto.SessionResponse, error)
+	RevokeSession(ctx context.Context, userAuthData types.UserAuthData, sessionId string) error
+	RevokeOtherSessions(ctx context.Context, userAuthData types.UserAuthData) error
 	RefreshToken(ctx context.Context, refreshTokenRequest dto.RefreshTokenRequest) (*dto.RefreshTokenResponse, error)
-}
\ No newline at end of file
+	Authenticate(ctx context.Context, accessToken string) (*types.UserAuthData, error)
+	JWKS(ctx context.Context) (*dto.JWKSResponse, error)
+	ChangePassword(ctx context.Context, userAuthData types.UserAuthData, changePasswordRequest dto.ChangePasswordRequest) error
+	ChangeUsername(ctx context.Context, userAuthData types.UserAuthData, changeUsernameRequest dto.ChangeUsernameRequest) (*dto.AccountResponse, error)
+	ChangeEmail(ctx context.Context, userAuthData types.UserAuthData, changeEmailRequest dto.ChangeEmailRequest) (*dto.AccountResponse, error)
+	VerifyEmail(ctx context.Context, verifyEmailRequest dto.VerifyEmailRequest) error
+	ResendEmailVerification(ctx context.Context, userAuthData types.UserAuthData) error
+	RequestPasswordReset(ctx context.Context, passwordResetRequest dto.PasswordResetRequest) error
+	ConfirmPasswordReset(ctx context.Context, passwordResetConfirmRequest dto.PasswordResetConfirmRequest) error
+	DeleteAccount(ctx context.Context, userAuthData types.UserAuthData, deleteAccountRequest dto.DeleteAccountRequest) error
+}
+
+type ApiKeyService interface {
+	AuthenticateApiKey(ctx context.Context, key string) (*types.UserAuthData, error)
+	CreateApiKey(ctx context.Context, userAuthData types.UserAuthData, createApiKeyRequest dto.CreateApiKeyRequest) (*dto.CreateApiKeyResponse, error)
+	ListApiKeys(ctx context.Context, userAuthData types.UserAuthData, organizationId *int64) ([]dto.ApiKeyResponse, error)
+	RevokeApiKey(ctx context.Context, userAuthData types.UserAuthData, apiKeyId int64) error
+}
+
+type UserService interface {
+	GetRoles(ctx context.Context, userId int64) (*dto.UserRolesResponse, error)
+	SetRoles(ctx context.Context, userId int64, userRolesRequest dto.UserRolesRequest) (*dto.UserRolesResponse, error)
+	BootstrapAdmins(ctx context.Context, usernames []string) ([]string, error)
+}
+
+type OrganizationService interface {
+	CreateOrganization(ctx context.Context, userAuthData types.UserAuthData, createOrganizationRequest dto.CreateOrganizationRequest) (*dto.OrganizationResponse, error)
+	ListOrganizations(ctx context.Context, userAuthData types.UserAuthData) ([]dto.OrganizationResponse, error)
+	SetMember(ctx context.Context, userAuthData types.UserAuthData, organizationId int64, userId int64, organizationMemberRequest dto.OrganizationMemberRequest) (*dto.OrganizationMemberResponse, error)
+	RemoveMember(ctx context.Context, userAuthData types.UserAuthData, organizationId int64, userId int64) error
+}
+
+type WalletService interface {
+	GetWallet(ctx context.Context, userAuthData types.UserAuthData) (*dto.WalletResponse, error)
+	AdjustBalance(ctx context.Context, userAuthData types.UserAuthData, userId int64, walletAdjustmentRequest dto.WalletAdjustmentRequest) (*dto.LedgerTransactionResponse, error)
+	RefundTransaction(ctx context.Context, userAuthData types.UserAuthData, transactionId int64) (*dto.LedgerTransactionResponse, error)
+}
+
+type GameService interface {
+	CreateGame(ctx context.Context, userAuthData types.UserAuthData, createGameRequest dto.CreateGameRequest) (*dto.GameResponse, error)
+	GetGame(ctx context.Context, gameId int64) (*dto.GameResponse, error)
+	OpenBuying(ctx context.Context, userAuthData types.UserAuthData, gameId int64) (*dto.GameResponse, error)
+	StartGame(ctx context.Context, userAuthData types.UserAuthData, gameId int64) (*dto.GameResponse, error)
+	CancelGame(ctx context.Context, userAuthData types.UserAuthData, gameId int64) (*dto.GameResponse, error)
+	DrawNumber(ctx context.Context, userAuthData types.UserAuthData, gameId int64) (*dto.DrawResponse, error)
+	GetWinners(ctx context.Context, gameId int64) ([]dto.GameWinnerResponse, error)
+	PurchaseCards(ctx context.Context, userAuthData types.UserAuthData, gameId int64, purchaseCardsRequest dto.PurchaseCardsRequest) (*dto.CardPurchaseResponse, error)
+	ListCards(ctx context.Context, userAuthData types.UserAuthData, gameId int64) ([]dto.GameCardResponse, error)
+}
+
+type JackpotService interface {
+	CreateJackpot(ctx context.Context, userAuthData types.UserAuthData, createJackpotRequest dto.CreateJackpotRequest) (*dto.JackpotResponse, error)
+	GetJackpot(ctx context.Context, jackpotId int64) (*dto.JackpotResponse, error)
+	GetJackpotHistory(ctx context.Context, jackpotId int64) ([]dto.JackpotHistoryEntryResponse, error)
+}
+
+type GameTemplateService interface {
+	CreateTemplate(ctx context.Context, userAuthData types.UserAuthData, createGameTemplateRequest dto.CreateGameTemplateRequest) (*dto.GameTemplateResponse, error)
+	ListTemplates(ctx context.Context, userAuthData types.UserAuthData) ([]dto.GameTemplateResponse, error)
+	DisableTemplate(ctx context.Context, userAuthData types.UserAuthData, gameTemplateId int64) error
+}
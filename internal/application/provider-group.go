@@ -7,15 +7,24 @@ import (
 type ProviderGroup struct {
 	passwordManager aports.PasswordManager
 	authTokenManager aports.AuthTokenManager
+	totpManager aports.TotpManager
+	mailer aports.Mailer
+	oidcManager aports.OidcManager
 }
 
 func NewProviderGroup(
 		passwordManager aports.PasswordManager,
 		authTokenManager aports.AuthTokenManager,
+		totpManager aports.TotpManager,
+		mailer aports.Mailer,
+		oidcManager aports.OidcManager,
 		) *ProviderGroup {
 			return &ProviderGroup{
 				passwordManager: passwordManager,
 				authTokenManager: authTokenManager,
+				totpManager: totpManager,
+				mailer: mailer,
+				oidcManager: oidcManager,
 			}
 }
 
@@ -25,4 +34,16 @@ func (group *ProviderGroup) PasswordManager() aports.PasswordManager {
 
 func (group *ProviderGroup) AuthTokenManager() aports.AuthTokenManager {
 	return group.authTokenManager
+}
+
+func (group *ProviderGroup) TotpManager() aports.TotpManager {
+	return group.totpManager
+}
+
+func (group *ProviderGroup) Mailer() aports.Mailer {
+	return group.mailer
+}
+
+func (group *ProviderGroup) OidcManager() aports.OidcManager {
+	return group.oidcManager
 }
\ No newline at end of file
@@ -6,14 +6,65 @@ import (
 
 type ServiceGroup struct {
 	authService aports.AuthService
+	apiKeyService aports.ApiKeyService
+	userService aports.UserService
+	organizationService aports.OrganizationService
+	walletService aports.WalletService
+	gameService aports.GameService
+	jackpotService aports.JackpotService
+	gameTemplateService aports.GameTemplateService
 }
 
-func NewServiceGroup(authService aports.AuthService) *ServiceGroup {
-	return &ServiceGroup{
-		authService: authService,
-	}
+func NewServiceGroup(
+		authService aports.AuthService,
+		apiKeyService aports.ApiKeyService,
+		userService aports.UserService,
+		organizationService aports.OrganizationService,
+		walletService aports.WalletService,
+		gameService aports.GameService,
+		jackpotService aports.JackpotService,
+		gameTemplateService aports.GameTemplateService,
+		) *ServiceGroup {
+			return &ServiceGroup{
+				authService: authService,
+				apiKeyService: apiKeyService,
+				userService: userService,
+				organizationService: organizationService,
+				walletService: walletService,
+				gameService: gameService,
+				jackpotService: jackpotService,
+				gameTemplateService: gameTemplateService,
+			}
 }
 
 func (group *ServiceGroup) AuthService() aports.AuthService {
 	return group.authService
-}
\ No newline at end of file
+}
+
+func (group *ServiceGroup) ApiKeyService() aports.ApiKeyService {
+	return group.apiKeyService
+}
+
+func (group *ServiceGroup) UserService() aports.UserService {
+	return group.userService
+}
+
+func (group *ServiceGroup) OrganizationService() aports.OrganizationService {
+	return group.organizationService
+}
+
+func (group *ServiceGroup) WalletService() aports.WalletService {
+	return group.walletService
+}
+
+func (group *ServiceGroup) GameService() aports.GameService {
+	return group.gameService
+}
+
+func (group *ServiceGroup) JackpotService() aports.JackpotService {
+	return group.jackpotService
+}
+
+func (group *ServiceGroup) GameTemplateService() aports.GameTemplateService {
+	return group.gameTemplateService
+}
@@ -0,0 +1,232 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gabriel-98/bingo-backend/internal/application/dto"
+	"github.com/gabriel-98/bingo-backend/internal/application/types"
+	"github.com/gabriel-98/bingo-backend/internal/domain"
+	"github.com/gabriel-98/bingo-backend/internal/domain/entities"
+	domports "github.com/gabriel-98/bingo-backend/internal/domain/ports"
+	"slices"
+	"strings"
+	"time"
+)
+
+// apiKeyLastUsedPrecision is the precision of the time of the last use of the API keys,
+// which is updated at most once per period.
+const apiKeyLastUsedPrecision = time.Minute
+
+// An ApiKeyServiceConfig contains the settings of the ApiKeyService.
+type ApiKeyServiceConfig struct {
+	// If set, the keys of users without a verified email are not granted the permission
+	// to join paid games (see AuthServiceConfig).
+	VerifiedEmailRequiredForPaidGames bool
+}
+
+type ApiKeyService struct {
+	dao *domain.DAO
+	config ApiKeyServiceConfig
+}
+
+func NewApiKeyService(dao *domain.DAO, config ApiKeyServiceConfig) *ApiKeyService {
+	return &ApiKeyService{
+		dao: dao,
+		config: config,
+	}
+}
+
+// AuthenticateApiKey validates an API key and returns the authentication data of the
+// user it acts on behalf of (its creator), whose permissions are restricted to the
+// scopes of the key. The keys of an organization only work while their creator is an
+// owner of it.
+func (s *ApiKeyService) AuthenticateApiKey(ctx context.Context, key string) (*types.UserAuthData, error) {
+	// Required repositories and providers.
+	userRepo := s.dao.UserRepo()
+	apiKeyRepo := s.dao.ApiKeyRepo()
+
+	// Validate the API key is registered, not revoked and not expired.
+	apiKey, err := apiKeyRepo.FindByKey(ctx, hashToken(key))
+	if errors.Is(err, domports.ErrNotFound) {
+		return nil, fmt.Errorf("unauthenticated user: %w", types.ErrInvalidToken)
+	}
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if apiKey.RevokedAt != nil {
+		return nil, fmt.Errorf("unauthenticated user: %w", types.ErrTokenRevoked)
+	}
+	if apiKey.ExpiresAt != nil && !apiKey.ExpiresAt.After(now) {
+		return nil, fmt.Errorf("unauthenticated user: %w", types.ErrTokenExpired)
+	}
+
+	// Validate the creator can still act on behalf of the owner of the key.
+	if _, err := userRepo.FindById(ctx, apiKey.CreatedBy); err != nil {
+		if errors.Is(err, domports.ErrNotFound) {
+			return nil, fmt.Errorf("unauthenticated user: %w", types.ErrTokenRevoked)
+		}
+		return nil, err
+	}
+	if apiKey.OrganizationId != nil {
+		err := requireOrganizationOwner(ctx, s.dao, *apiKey.OrganizationId, apiKey.CreatedBy)
+		if errors.Is(err, types.ErrNotOrganizationOwner) {
+			return nil, fmt.Errorf("unauthenticated user: %w", types.ErrTokenRevoked)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Restrict the current permissions of the creator to the scopes of the key.
+	userAuthData, err := loadUserAuthData(ctx, s.dao, apiKey.CreatedBy, s.config.VerifiedEmailRequiredForPaidGames)
+	if err != nil {
+		return nil, err
+	}
+	scopes := []types.Permission{}
+	for _, scope := range strings.Fields(apiKey.Scopes) {
+		scopes = append(scopes, types.Permission(scope))
+	}
+	userAuthData.Permissions = slices.DeleteFunc(userAuthData.Permissions, func(permission types.Permission) bool {
+		return !slices.Contains(scopes, permission)
+	})
+	userAuthData.ApiKeyId = apiKey.Id
+	userAuthData.OrganizationId = apiKey.OrganizationId
+	userAuthData.Scopes = scopes
+
+	// Register the use of the key.
+	if err := apiKeyRepo.TouchLastUsed(ctx, apiKey.Id, now, apiKeyLastUsedPrecision); err != nil {
+		return nil, err
+	}
+	return userAuthData, nil
+}
+
+// CreateApiKey creates an API key of the user or, if an organization is given, of the
+// organization, which requires being one of its owners. The scopes of the key must be
+// permissions the user has been granted. The key is returned only once: only its digest
+// is stored.
+func (s *ApiKeyService) CreateApiKey(ctx context.Context, userAuthData types.UserAuthData, createApiKeyRequest dto.CreateApiKeyRequest) (*dto.CreateApiKeyResponse, error) {
+	// Required repositories and providers.
+	apiKeyRepo := s.dao.ApiKeyRepo()
+
+	// Validate the name, the owner, the expiration and the scopes of the key.
+	name := strings.TrimSpace(createApiKeyRequest.Name)
+	if name == "" {
+		return nil, fmt.Errorf("invalid API key name: name is empty")
+	}
+	if createApiKeyRequest.OrganizationId != nil {
+		if err := requireOrganizationOwner(ctx, s.dao, *createApiKeyRequest.OrganizationId, userAuthData.UserId); err != nil {
+			return nil, err
+		}
+	}
+	if createApiKeyRequest.ExpiresAt != nil && !createApiKeyRequest.ExpiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("invalid API key expiration: it is in the past")
+	}
+	scopes := []string{}
+	for _, scope := range createApiKeyRequest.Scopes {
+		permission, err := types.ParsePermission(scope)
+		if err != nil {
+			return nil, err
+		}
+		if !userAuthData.HasPermission(permission) {
+			return nil, fmt.Errorf("invalid API key scope: missing permission %q", permission)
+		}
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("invalid API key scopes: no scope")
+	}
+
+	// Generate and register the key (its digest).
+	secret, err := newRandomToken()
+	if err != nil {
+		return nil, err
+	}
+	key := types.ApiKeyPrefix + secret
+	apiKey := entities.ApiKey{
+		KeyHash: hashToken(key),
+		Prefix: key[:len(types.ApiKeyPrefix) + 6],
+		Name: name,
+		OrganizationId: createApiKeyRequest.OrganizationId,
+		CreatedBy: userAuthData.UserId,
+		Scopes: strings.Join(scopes, " "),
+		ExpiresAt: createApiKeyRequest.ExpiresAt,
+	}
+	if apiKey.OrganizationId == nil {
+		apiKey.UserId = &userAuthData.UserId
+	}
+	createdApiKey, err := apiKeyRepo.Create(ctx, apiKey)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.CreateApiKeyResponse{ ApiKeyResponse: apiKeyResponse(createdApiKey), Key: key }, nil
+}
+
+// ListApiKeys returns the API keys of the user or, if an organization is given, of the
+// organization, which requires being one of its owners. Revoked keys are included.
+func (s *ApiKeyService) ListApiKeys(ctx context.Context, userAuthData types.UserAuthData, organizationId *int64) ([]dto.ApiKeyResponse, error) {
+	// Required repositories and providers.
+	apiKeyRepo := s.dao.ApiKeyRepo()
+
+	var apiKeys []entities.ApiKey
+	var err error
+	if organizationId != nil {
+		if err := requireOrganizationOwner(ctx, s.dao, *organizationId, userAuthData.UserId); err != nil {
+			return nil, err
+		}
+		apiKeys, err = apiKeyRepo.FindByOrganizationId(ctx, *organizationId)
+	} else {
+		apiKeys, err = apiKeyRepo.FindByUserId(ctx, userAuthData.UserId)
+	}
+	if err != nil {
+		return nil, err
+	}
+	apiKeyResponses := make([]dto.ApiKeyResponse, len(apiKeys))
+	for i := range apiKeys {
+		apiKeyResponses[i] = apiKeyResponse(&apiKeys[i])
+	}
+	return apiKeyResponses, nil
+}
+
+// RevokeApiKey revokes an API key of the user, or of an organization the user owns.
+// Revoked keys are rejected immediately.
+func (s *ApiKeyService) RevokeApiKey(ctx context.Context, userAuthData types.UserAuthData, apiKeyId int64) error {
+	// Required repositories and providers.
+	apiKeyRepo := s.dao.ApiKeyRepo()
+
+	// Validate the key belongs to the user or to an organization it owns. The keys of
+	// other users are reported as not found.
+	apiKey, err := apiKeyRepo.FindById(ctx, apiKeyId)
+	if err != nil {
+		return err
+	}
+	if apiKey.OrganizationId != nil {
+		err := requireOrganizationOwner(ctx, s.dao, *apiKey.OrganizationId, userAuthData.UserId)
+		if errors.Is(err, types.ErrNotOrganizationOwner) {
+			return domports.ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+	} else if apiKey.UserId == nil || *apiKey.UserId != userAuthData.UserId {
+		return domports.ErrNotFound
+	}
+	return apiKeyRepo.Revoke(ctx, apiKey.Id, time.Now())
+}
+
+func apiKeyResponse(apiKey *entities.ApiKey) dto.ApiKeyResponse {
+	return dto.ApiKeyResponse{
+		Id: apiKey.Id,
+		Name: apiKey.Name,
+		Prefix: apiKey.Prefix,
+		Scopes: strings.Fields(apiKey.Scopes),
+		OrganizationId: apiKey.OrganizationId,
+		CreatedAt: apiKey.CreatedAt,
+		ExpiresAt: apiKey.ExpiresAt,
+		LastUsedAt: apiKey.LastUsedAt,
+		Revoked: apiKey.RevokedAt != nil,
+	}
+}
@@ -2,107 +2,664 @@ package services
 
 import (
 	"context"
+	"crypto/subtle"
+	"errors"
 	"fmt"
 	"github.com/gabriel-98/bingo-backend/internal/application/dto"
 	aports "github.com/gabriel-98/bingo-backend/internal/application/ports"
 	"github.com/gabriel-98/bingo-backend/internal/application/types"
 	"github.com/gabriel-98/bingo-backend/internal/domain"
 	"github.com/gabriel-98/bingo-backend/internal/domain/entities"
+	domports "github.com/gabriel-98/bingo-backend/internal/domain/ports"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
 )
 
+// recentLoginMaxAge is how recently a session of an account without a password must
+// have started to confirm a sensitive change (see reauthenticate).
+const recentLoginMaxAge = 5 * time.Minute
+
+// passwordResetResponseTime is the minimum time taken by RequestPasswordReset, so that
+// its response time does not reveal whether a reset link was issued.
+const passwordResetResponseTime = 250 * time.Millisecond
+
+// Purposes of the one-time tokens issued by the AuthService.
+const (
+	passwordResetPurpose = "password_reset"
+	emailVerificationPurpose = "email_verification"
+)
+
+// An AuthServiceConfig contains the settings of the AuthService.
+type AuthServiceConfig struct {
+	// Minimum time between two username changes of a user.
+	UsernameChangeCooldown time.Duration
+
+	// Validity of the password reset tokens, URL of the password reset page sent by
+	// email, where "{token}" is replaced by the token, and minimum time between two
+	// password reset emails of a user.
+	PasswordResetTokenDuration time.Duration
+	PasswordResetUrl string
+	PasswordResetResendCooldown time.Duration
+
+	// Validity of the email verification tokens, URL of the email verification page
+	// (as PasswordResetUrl), and minimum time between two verification emails.
+	EmailVerificationTokenDuration time.Duration
+	EmailVerificationUrl string
+	EmailVerificationResendCooldown time.Duration
+
+	// If set, users without a verified email are not granted the permission to join
+	// paid games.
+	VerifiedEmailRequiredForPaidGames bool
+
+	// Maximum time between the start of a login with an external provider and its
+	// callback.
+	OidcAuthRequestDuration time.Duration
+
+	// Whether guest accounts can be created, and the inactivity after which they expire.
+	GuestLoginEnabled bool
+	GuestInactivityTimeout time.Duration
+}
+
 type AuthService struct {
 	dao *domain.DAO
 
 	// Providers
 	passwordManager aports.PasswordManager
 	authTokenManager aports.AuthTokenManager
+	totpManager aports.TotpManager
+	mailer aports.Mailer
+	oidcManager aports.OidcManager
+
+	loginThrottle *LoginThrottle
+	config AuthServiceConfig
 }
 
 func NewAuthService(
 		dao *domain.DAO,
 		passwordManager aports.PasswordManager,
 		authTokenManager aports.AuthTokenManager,
+		totpManager aports.TotpManager,
+		mailer aports.Mailer,
+		oidcManager aports.OidcManager,
+		loginThrottle *LoginThrottle,
+		config AuthServiceConfig,
 		) *AuthService {
 			return &AuthService{
 				dao: dao,
 				passwordManager: passwordManager,
 				authTokenManager: authTokenManager,
+				totpManager: totpManager,
+				mailer: mailer,
+				oidcManager: oidcManager,
+				loginThrottle: loginThrottle,
+				config: config,
 			}
 }
 
 func (s *AuthService) Signup(ctx context.Context, signupRequest dto.SignupRequest) (*dto.SignupResponse, error) {
 	// Required repositories and providers.
 	userRepo := s.dao.UserRepo()
+	userRoleRepo := s.dao.UserRoleRepo()
+	transactionManager := s.dao.TransactionManager()
 	passwordManager := s.passwordManager
 
-	// Hash password.
+	// Validate the password against the password policy and hash it.
+	if err := passwordManager.ValidatePassword(signupRequest.Username, signupRequest.Password); err != nil {
+		return nil, err
+	}
 	hashedPassword, err := passwordManager.HashPassword(signupRequest.Password)
 	if err != nil {
 		return nil, err
 	}
 
-	// Create an user account and return the user id.
+	// Validate the username is not used by another user.
+	usernameTaken, err := userRepo.ExistsByUsername(ctx, signupRequest.Username)
+	if err != nil {
+		return nil, err
+	}
+	if usernameTaken {
+		return nil, types.ErrUsernameTaken
+	}
+
+	// Validate the email (optional) is valid and not used by another user.
+	var email *string
+	if signupRequest.Email != "" {
+		normalizedEmail, err := normalizeEmail(signupRequest.Email)
+		if err != nil {
+			return nil, err
+		}
+		emailTaken, err := userRepo.ExistsByEmail(ctx, normalizedEmail)
+		if err != nil {
+			return nil, err
+		}
+		if emailTaken {
+			return nil, types.ErrEmailTaken
+		}
+		email = &normalizedEmail
+	}
+
+	// Create an user account, as a player (new users are players), and return the user
+	// id.
 	user := &entities.User{
 		Username: signupRequest.Username,
 		Password: hashedPassword,
+		Email: email,
 	}
-	user, err = userRepo.Create(ctx, *user)
+	err = transactionManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		user, err = userRepo.Create(ctx, *user)
+		if err != nil {
+			return err
+		}
+		_, err = userRoleRepo.Replace(ctx, user.Id, []string{ string(types.RolePlayer) })
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
-	return &dto.SignupResponse{ user.Id, user.Username } , nil
+
+	// Send the email verification link.
+	if user.Email != nil {
+		if err := s.sendEmailVerification(ctx, user); err != nil {
+			return nil, err
+		}
+	}
+	return &dto.SignupResponse{ Id: user.Id, Username: user.Username }, nil
 }
 
 func (s *AuthService) Login(ctx context.Context, loginRequest dto.LoginRequest) (*dto.LoginResponse, error) {
 	// Required repositories and providers.
 	userRepo := s.dao.UserRepo()
-	refreshTokenRepo := s.dao.RefreshTokenRepo()
 	passwordManager := s.passwordManager
 	authTokenManager := s.authTokenManager
+	loginThrottle := s.loginThrottle
+
+	// Reject the login if the account is locked or the username or IP address failed too
+	// many times recently. Otherwise, the attempt is reserved until the password is
+	// checked.
+	now := time.Now()
+	if err := loginThrottle.Reserve(ctx, loginRequest.Username, loginRequest.IpAddress, now); err != nil {
+		return nil, err
+	}
 
 	// Retrieve the user with this username.
+	// An unknown username follows the same path as a wrong password: a password hash is
+	// still checked and the same error is returned, so that valid usernames cannot be
+	// discovered from the response or its time.
 	user, err := userRepo.FindByUsername(ctx, loginRequest.Username)
-	if err != nil {
+	if err != nil && !errors.Is(err, domports.ErrNotFound) {
+		loginThrottle.Release(ctx, loginRequest.Username, loginRequest.IpAddress) // Error is ignored.
 		return nil, err
 	}
 
 	// Validate password.
-	if !passwordManager.CheckPassword(user.Password, loginRequest.Password) {
-		return nil, fmt.Errorf("password validation failed")
+	validPassword := false
+	if user != nil {
+		validPassword = passwordManager.CheckPassword(user.Password, loginRequest.Password)
+	} else {
+		passwordManager.SimulateCheckPassword(loginRequest.Password)
+	}
+	if !validPassword {
+		if err := loginThrottle.RecordFailure(ctx, loginRequest.Username, loginRequest.IpAddress, now); err != nil {
+			return nil, err
+		}
+		return nil, types.ErrInvalidCredentials
 	}
 
-	// Generate refresh and access tokens.
-	userAuthData := types.UserAuthData{ UserId: user.Id }
-	refreshToken, err := authTokenManager.NewRefreshToken(userAuthData)
+	// The password is valid, so the reserved attempt is not a failure.
+	if err := loginThrottle.Release(ctx, loginRequest.Username, loginRequest.IpAddress); err != nil {
+		return nil, err
+	}
+
+	// Transparently rehash the password if its hash was generated with an outdated
+	// algorithm or cost. Only the password is written, and only if it was not changed
+	// since it was read (otherwise the rehash is skipped).
+	if passwordManager.NeedsRehash(user.Password) {
+		hashedPassword, err := passwordManager.HashPassword(loginRequest.Password)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := userRepo.ReplacePassword(ctx, user.Id, user.Password, hashedPassword); err != nil {
+			return nil, err
+		}
+	}
+
+	// Users with two-factor authentication must pass the second factor before getting
+	// their tokens, so an MFA token is returned instead (see LoginMfa). The failure
+	// counter is not reset yet, otherwise knowing the password would allow guessing the
+	// codes without limit.
+	mfaEnabled, err := s.mfaEnabled(ctx, user.Id)
 	if err != nil {
 		return nil, err
 	}
-	accessToken, err := authTokenManager.NewAccessToken(userAuthData)
+	if mfaEnabled {
+		mfaToken, err := authTokenManager.NewMfaToken(types.UserAuthData{ UserId: user.Id })
+		if err != nil {
+			return nil, err
+		}
+		return &dto.LoginResponse{ MfaRequired: true, MfaToken: mfaToken }, nil
+	}
+	if err := loginThrottle.RecordSuccess(ctx, loginRequest.Username); err != nil {
+		return nil, err
+	}
+
+	// Start a new session.
+	return s.startSession(ctx, user.Id, loginRequest.DeviceName, loginRequest.ClientInfo)
+}
+
+// LoginMfa completes the login of a user with two-factor authentication: it validates
+// the MFA token returned by Login and the TOTP or recovery code, and starts a new
+// session. Wrong codes count as failed logins of the user.
+func (s *AuthService) LoginMfa(ctx context.Context, loginMfaRequest dto.LoginMfaRequest) (*dto.LoginResponse, error) {
+	// Required repositories and providers.
+	userRepo := s.dao.UserRepo()
+	authTokenManager := s.authTokenManager
+	loginThrottle := s.loginThrottle
+
+	// Validate the MFA token, which proves the user passed the first factor.
+	mfaUserAuthData, err := authTokenManager.ValidateMfaToken(loginMfaRequest.MfaToken)
+	if err != nil {
+		return nil, fmt.Errorf("unauthenticated user: %w", err)
+	}
+	user, err := userRepo.FindById(ctx, mfaUserAuthData.UserId)
+	if err != nil {
+		return nil, fmt.Errorf("unauthenticated user: %w", err)
+	}
+
+	// Validate the second factor, unless the account is locked or failed too many times
+	// recently.
+	now := time.Now()
+	if err := loginThrottle.Reserve(ctx, user.Username, loginMfaRequest.IpAddress, now); err != nil {
+		return nil, err
+	}
+	err = s.verifySecondFactor(ctx, user.Id, loginMfaRequest.Code, loginMfaRequest.RecoveryCode, now)
+	if errors.Is(err, types.ErrInvalidMfaCode) {
+		if throttleErr := loginThrottle.RecordFailure(ctx, user.Username, loginMfaRequest.IpAddress, now); throttleErr != nil {
+			return nil, throttleErr
+		}
+		return nil, err
+	}
+	if releaseErr := loginThrottle.Release(ctx, user.Username, loginMfaRequest.IpAddress); releaseErr != nil {
+		return nil, releaseErr
+	}
 	if err != nil {
 		return nil, err
 	}
+	if err := loginThrottle.RecordSuccess(ctx, user.Username); err != nil {
+		return nil, err
+	}
 
-	// Register refresh token.
-	createdAt, expiredAt, err := authTokenManager.IssuedAtAndExpiresAt(refreshToken)
+	// Start a new session.
+	return s.startSession(ctx, user.Id, loginMfaRequest.DeviceName, loginMfaRequest.ClientInfo)
+}
+
+// LoginGuest creates a guest account and starts a session of it. Guests have a generated
+// username, no password and the guest role, which only allows joining free games. The
+// account expires after a period of inactivity, unless it is upgraded (see
+// UpgradeGuest).
+func (s *AuthService) LoginGuest(ctx context.Context, guestLoginRequest dto.GuestLoginRequest) (*dto.LoginResponse, error) {
+	// Required repositories and providers.
+	userRepo := s.dao.UserRepo()
+	userRoleRepo := s.dao.UserRoleRepo()
+	transactionManager := s.dao.TransactionManager()
+
+	if !s.config.GuestLoginEnabled {
+		return nil, types.ErrGuestLoginDisabled
+	}
+
+	// Create the guest account with a random username.
+	suffix, err := newRandomId()
 	if err != nil {
 		return nil, err
 	}
-	rt := entities.RefreshToken{
-		Token: refreshToken,
-		UserId: user.Id,
-		CreatedAt: createdAt, 
-		ExpiresAt: expiredAt,
+	expiresAt := time.Now().Add(s.config.GuestInactivityTimeout)
+	user := entities.User{
+		Username: "guest-" + suffix[:10],
+		GuestExpiresAt: &expiresAt,
 	}
-	if _, err := refreshTokenRepo.Create(ctx, rt); err !=  nil {
+	var createdUser *entities.User
+	err = transactionManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		createdUser, err = userRepo.Create(ctx, user)
+		if err != nil {
+			return err
+		}
+		_, err = userRoleRepo.Replace(ctx, createdUser.Id, []string{ string(types.RoleGuest) })
+		return err
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	// Build the response and return it.
-	loginResponse := dto.LoginResponse{		
-		RefreshToken: refreshToken,
-		AccessToken: accessToken,
+	// Start a new session.
+	return s.startSession(ctx, createdUser.Id, guestLoginRequest.DeviceName, guestLoginRequest.ClientInfo)
+}
+
+// UpgradeGuest turns a guest account into a full account with the chosen username,
+// password and (optional) email. The user keeps its id, so its game history is kept.
+// The sessions of the guest are ended, since their tokens carry the guest role, and a
+// new session is started.
+func (s *AuthService) UpgradeGuest(ctx context.Context, userAuthData types.UserAuthData, upgradeGuestRequest dto.UpgradeGuestRequest) (*dto.LoginResponse, error) {
+	// Required repositories and providers.
+	userRepo := s.dao.UserRepo()
+	userRoleRepo := s.dao.UserRoleRepo()
+	transactionManager := s.dao.TransactionManager()
+	passwordManager := s.passwordManager
+
+	// Retrieve the guest account.
+	user, err := userRepo.FindById(ctx, userAuthData.UserId)
+	if err != nil {
+		return nil, err
 	}
-	return &loginResponse, nil
+	if user.GuestExpiresAt == nil {
+		return nil, types.ErrNotGuest
+	}
+
+	// Validate the username is valid and not used by another user.
+	username := strings.TrimSpace(upgradeGuestRequest.Username)
+	if username == "" {
+		return nil, fmt.Errorf("invalid username: username is empty")
+	}
+	if username != user.Username {
+		usernameTaken, err := userRepo.ExistsByUsername(ctx, username)
+		if err != nil {
+			return nil, err
+		}
+		if usernameTaken {
+			return nil, types.ErrUsernameTaken
+		}
+	}
+
+	// Validate the password against the password policy and hash it.
+	if err := passwordManager.ValidatePassword(username, upgradeGuestRequest.Password); err != nil {
+		return nil, err
+	}
+	hashedPassword, err := passwordManager.HashPassword(upgradeGuestRequest.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate the email (optional) is valid and not used by another user.
+	var email *string
+	if upgradeGuestRequest.Email != "" {
+		normalizedEmail, err := normalizeEmail(upgradeGuestRequest.Email)
+		if err != nil {
+			return nil, err
+		}
+		emailTaken, err := userRepo.ExistsByEmail(ctx, normalizedEmail)
+		if err != nil {
+			return nil, err
+		}
+		if emailTaken {
+			return nil, types.ErrEmailTaken
+		}
+		email = &normalizedEmail
+	}
+
+	// Save the credentials, so that the account no longer expires, and make the user a
+	// player.
+	user.Username = username
+	user.Password = hashedPassword
+	user.Email = email
+	user.EmailVerifiedAt = nil
+	user.GuestExpiresAt = nil
+	err = transactionManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		user, err = userRepo.Update(ctx, user.Id, *user)
+		if err != nil {
+			return err
+		}
+		_, err = userRoleRepo.Replace(ctx, user.Id, []string{ string(types.RolePlayer) })
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Send the email verification link.
+	if user.Email != nil {
+		if err := s.sendEmailVerification(ctx, user); err != nil {
+			return nil, err
+		}
+	}
+
+	// Replace the sessions of the guest by a new one.
+	if err := s.LogoutAll(ctx, userAuthData); err != nil {
+		return nil, err
+	}
+	return s.startSession(ctx, user.Id, upgradeGuestRequest.DeviceName, upgradeGuestRequest.ClientInfo)
+}
+
+// StartOidcLogin starts a login with an external OpenID Connect provider: it registers
+// a pending login and returns the URL of the provider where the user must be redirected.
+// The provider redirects the user back with the state and an authorization code, which
+// are passed to CompleteOidcLogin.
+func (s *AuthService) StartOidcLogin(ctx context.Context, provider string) (*dto.OidcLoginStartResponse, error) {
+	// Required repositories and providers.
+	oidcAuthRequestRepo := s.dao.OidcAuthRequestRepo()
+	oidcManager := s.oidcManager
+
+	// Generate the state, the nonce and the PKCE code verifier of the login.
+	state, err := newRandomToken()
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := newRandomToken()
+	if err != nil {
+		return nil, err
+	}
+	codeVerifier, err := newRandomToken()
+	if err != nil {
+		return nil, err
+	}
+	codeChallenge := pkceCodeChallenge(codeVerifier)
+
+	// Build the authorization URL (this validates the provider) and register the pending
+	// login.
+	authorizationUrl, err := oidcManager.AuthorizationUrl(ctx, provider, state, nonce, codeChallenge)
+	if err != nil {
+		return nil, err
+	}
+	oidcAuthRequest := entities.OidcAuthRequest{
+		StateHash: hashToken(state),
+		Provider: provider,
+		Nonce: nonce,
+		CodeVerifier: codeVerifier,
+		ExpiresAt: time.Now().Add(s.config.OidcAuthRequestDuration),
+	}
+	if err := oidcAuthRequestRepo.Create(ctx, oidcAuthRequest); err != nil {
+		return nil, err
+	}
+	return &dto.OidcLoginStartResponse{ AuthorizationUrl: authorizationUrl }, nil
+}
+
+// CompleteOidcLogin completes a login with an external OpenID Connect provider: it
+// exchanges the authorization code for the user's identity at the provider, and starts
+// a session of the user linked to it. A new account is created on the first login with
+// an identity. Accounts are never linked by email, since it would allow taking over an
+// account through a provider that does not verify emails.
+// Users with two-factor authentication get an MFA token instead, as in Login.
+func (s *AuthService) CompleteOidcLogin(ctx context.Context, oidcCallbackRequest dto.OidcCallbackRequest) (*dto.LoginResponse, error) {
+	// Required repositories and providers.
+	userRepo := s.dao.UserRepo()
+	userIdentityRepo := s.dao.UserIdentityRepo()
+	oidcAuthRequestRepo := s.dao.OidcAuthRequestRepo()
+	authTokenManager := s.authTokenManager
+	oidcManager := s.oidcManager
+
+	// The provider redirects with an error if the user denied the login.
+	if oidcCallbackRequest.Error != "" {
+		return nil, fmt.Errorf("%w: %s", types.ErrOidcLoginFailed, oidcCallbackRequest.Error)
+	}
+
+	// Retrieve the pending login of the state. It is deleted, so the state cannot be
+	// used again.
+	now := time.Now()
+	oidcAuthRequest, err := oidcAuthRequestRepo.Take(ctx, hashToken(oidcCallbackRequest.State))
+	if errors.Is(err, domports.ErrNotFound) {
+		return nil, types.ErrInvalidOidcState
+	}
+	if err != nil {
+		return nil, err
+	}
+	if oidcAuthRequest.Provider != oidcCallbackRequest.Provider || !oidcAuthRequest.ExpiresAt.After(now) {
+		return nil, types.ErrInvalidOidcState
+	}
+
+	// Exchange the code for the identity, which must be asserted for this login.
+	identity, err := oidcManager.ExchangeCode(ctx, oidcAuthRequest.Provider, oidcCallbackRequest.Code, oidcAuthRequest.CodeVerifier)
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(identity.Nonce), []byte(oidcAuthRequest.Nonce)) != 1 {
+		return nil, fmt.Errorf("%w: invalid nonce", types.ErrOidcLoginFailed)
+	}
+
+	// Retrieve the user linked to the identity, or create it.
+	var user *entities.User
+	userIdentity, err := userIdentityRepo.Find(ctx, identity.Provider, identity.Subject)
+	switch {
+		case err == nil:
+			user, err = userRepo.FindById(ctx, userIdentity.UserId)
+			if err != nil {
+				return nil, fmt.Errorf("unauthenticated user: %w", err)
+			}
+			if err := userIdentityRepo.UpdateLastLogin(ctx, identity.Provider, identity.Subject, identity.Email, now); err != nil {
+				return nil, err
+			}
+		case errors.Is(err, domports.ErrNotFound):
+			user, err = s.createOidcUser(ctx, identity, now)
+			if err != nil {
+				return nil, err
+			}
+		default:
+			return nil, err
+	}
+
+	// Users with two-factor authentication must pass the second factor (see Login).
+	mfaEnabled, err := s.mfaEnabled(ctx, user.Id)
+	if err != nil {
+		return nil, err
+	}
+	if mfaEnabled {
+		mfaToken, err := authTokenManager.NewMfaToken(types.UserAuthData{ UserId: user.Id })
+		if err != nil {
+			return nil, err
+		}
+		return &dto.LoginResponse{ MfaRequired: true, MfaToken: mfaToken }, nil
+	}
+
+	// Start a new session.
+	return s.startSession(ctx, user.Id, oidcCallbackRequest.DeviceName, oidcCallbackRequest.ClientInfo)
+}
+
+// EnrollTotp generates a new TOTP secret for the user, returned along with its
+// otpauth:// URI. The two-factor authentication is not enabled until the enrolment is
+// confirmed with a code (see ConfirmTotp); enrolling again replaces the pending secret.
+func (s *AuthService) EnrollTotp(ctx context.Context, userAuthData types.UserAuthData) (*dto.TotpEnrollmentResponse, error) {
+	// Required repositories and providers.
+	userRepo := s.dao.UserRepo()
+	userTotpRepo := s.dao.UserTotpRepo()
+	totpManager := s.totpManager
+
+	// Validate the two-factor authentication is not enabled yet.
+	mfaEnabled, err := s.mfaEnabled(ctx, userAuthData.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if mfaEnabled {
+		return nil, types.ErrMfaAlreadyEnabled
+	}
+
+	// Generate and register the secret, pending confirmation.
+	user, err := userRepo.FindById(ctx, userAuthData.UserId)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := totpManager.NewSecret()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := userTotpRepo.Save(ctx, entities.UserTotp{ UserId: user.Id, Secret: secret }); err != nil {
+		return nil, err
+	}
+	return &dto.TotpEnrollmentResponse{
+		Secret: secret,
+		OtpauthUri: totpManager.ProvisioningUri(user.Username, secret),
+	}, nil
+}
+
+// ConfirmTotp enables the two-factor authentication of the user, once a code generated
+// with the enrolled secret is verified. It returns the recovery codes, which are only
+// shown this time: they are stored hashed.
+func (s *AuthService) ConfirmTotp(ctx context.Context, userAuthData types.UserAuthData, totpConfirmRequest dto.TotpConfirmRequest) (*dto.RecoveryCodesResponse, error) {
+	// Required repositories and providers.
+	userTotpRepo := s.dao.UserTotpRepo()
+	recoveryCodeRepo := s.dao.RecoveryCodeRepo()
+	passwordManager := s.passwordManager
+	totpManager := s.totpManager
+
+	// Retrieve the pending enrolment.
+	userTotp, err := userTotpRepo.FindByUserId(ctx, userAuthData.UserId)
+	if errors.Is(err, domports.ErrNotFound) {
+		return nil, types.ErrMfaNotEnrolled
+	}
+	if err != nil {
+		return nil, err
+	}
+	if userTotp.ConfirmedAt != nil {
+		return nil, types.ErrMfaAlreadyEnabled
+	}
+
+	// Validate the code proves the authenticator holds the secret.
+	now := time.Now()
+	step, valid := totpManager.ValidateCode(userTotp.Secret, totpConfirmRequest.Code, now)
+	if !valid {
+		return nil, types.ErrInvalidMfaCode
+	}
+
+	// Generate and register the recovery codes (hashed), and enable the two-factor
+	// authentication.
+	recoveryCodes, err := totpManager.NewRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	codeHashes := make([]string, len(recoveryCodes))
+	for i, recoveryCode := range recoveryCodes {
+		codeHashes[i], err = passwordManager.HashPassword(normalizeRecoveryCode(recoveryCode))
+		if err != nil {
+			return nil, err
+		}
+	}
+	if _, err := recoveryCodeRepo.Replace(ctx, userAuthData.UserId, codeHashes); err != nil {
+		return nil, err
+	}
+	userTotp.ConfirmedAt = &now
+	userTotp.LastUsedStep = step
+	if _, err := userTotpRepo.Save(ctx, *userTotp); err != nil {
+		return nil, err
+	}
+	return &dto.RecoveryCodesResponse{ RecoveryCodes: recoveryCodes }, nil
+}
+
+// DisableTotp disables the two-factor authentication of the user, which requires a
+// valid TOTP or recovery code. The secret and the recovery codes are deleted.
+func (s *AuthService) DisableTotp(ctx context.Context, userAuthData types.UserAuthData, totpDisableRequest dto.TotpDisableRequest) error {
+	// Required repositories and providers.
+	userTotpRepo := s.dao.UserTotpRepo()
+	recoveryCodeRepo := s.dao.RecoveryCodeRepo()
+
+	// Validate the second factor.
+	now := time.Now()
+	err := s.verifySecondFactor(ctx, userAuthData.UserId, totpDisableRequest.Code, totpDisableRequest.RecoveryCode, now)
+	if err != nil {
+		return err
+	}
+
+	// Delete the secret and the recovery codes.
+	if err := recoveryCodeRepo.DeleteByUserId(ctx, userAuthData.UserId); err != nil {
+		return err
+	}
+	return userTotpRepo.Delete(ctx, userAuthData.UserId)
 }
 
 func (s *AuthService) Logout(ctx context.Context, logoutRequest dto.LogoutRequest) error {
@@ -110,14 +667,37 @@ func (s *AuthService) Logout(ctx context.Context, logoutRequest dto.LogoutReques
 	refreshTokenRepo := s.dao.RefreshTokenRepo()
 
 	// Validate the refresh token is registered.
-	if _, err := refreshTokenRepo.FindByToken(ctx, logoutRequest.RefreshToken); err != nil {
+	storedToken, err := refreshTokenRepo.FindByToken(ctx, hashToken(logoutRequest.RefreshToken))
+	if err != nil {
 		return fmt.Errorf("unauthenticated user: %w", err)
 	}
 	
-	// Delete the refresh token.
-	return refreshTokenRepo.Delete(ctx, logoutRequest.RefreshToken)
+	// Revoke the whole token family, which ends the session.
+	return s.revokeTokenFamily(ctx, storedToken.FamilyId)
+}
+
+// LogoutAll ends all the sessions of the user ("log out everywhere"): every refresh
+// token of the user is deleted and every access token issued along with them is
+// revoked, including the access token of the current request.
+func (s *AuthService) LogoutAll(ctx context.Context, userAuthData types.UserAuthData) error {
+	// Required repositories and providers.
+	refreshTokenRepo := s.dao.RefreshTokenRepo()
+
+	// Revoke the access tokens and delete the refresh tokens of every session.
+	refreshTokens, err := refreshTokenRepo.FindByUserId(ctx, userAuthData.UserId)
+	if err != nil {
+		return err
+	}
+	if err := s.revokeAccessTokens(ctx, refreshTokens); err != nil {
+		return err
+	}
+	return refreshTokenRepo.DeleteByUserId(ctx, userAuthData.UserId)
 }
 
+// RefreshToken rotates a refresh token: it issues a new refresh token (of the same
+// family) and a new access token, and invalidates the used refresh token.
+// Using an already rotated token is a sign that the token was stolen, so the whole
+// token family is revoked and authentication fails.
 func (s *AuthService) RefreshToken(ctx context.Context, refreshTokenRequest dto.RefreshTokenRequest) (*dto.RefreshTokenResponse, error) {
 	// Required repositories and providers.
 	refreshTokenRepo := s.dao.RefreshTokenRepo()
@@ -127,23 +707,1014 @@ func (s *AuthService) RefreshToken(ctx context.Context, refreshTokenRequest dto.
 	// If the token is invalid (malformed, expired, etc) or not registered, authentication
 	// will fail.
 	refreshToken := refreshTokenRequest.RefreshToken
-	userAuthData, err := authTokenManager.ValidateRefreshToken(refreshToken)
+	tokenUserAuthData, err := authTokenManager.ValidateRefreshToken(refreshToken)
 	if err != nil {
 		return nil, fmt.Errorf("unauthenticated user: %w", err)
 	}
-	if _, err := refreshTokenRepo.FindByToken(ctx, refreshToken); err != nil {
+	storedToken, err := refreshTokenRepo.FindByToken(ctx, hashToken(refreshToken))
+	if err != nil {
 		return nil, fmt.Errorf("unauthenticated user: %w", err)
 	}
 
-	// Generate an access token.
-	accessToken, err := authTokenManager.NewAccessToken(*userAuthData)
+	// Detect the reuse of a rotated token and revoke its family.
+	if storedToken.RotatedAt != nil {
+		return nil, s.revokeReusedTokenFamily(ctx, storedToken)
+	}
+
+	// Reload the authentication data, so that role changes take effect on refresh.
+	userAuthData, err := s.loadUserAuthData(ctx, tokenUserAuthData.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	// A refresh is an activity of the user, so it postpones the expiration of guests.
+	if err := s.dao.UserRepo().ExtendGuest(ctx, userAuthData.UserId, time.Now().Add(s.config.GuestInactivityTimeout)); err != nil {
+		return nil, err
+	}
+
+	// Generate the new refresh and access tokens, and invalidate the used token. If the
+	// token was rotated concurrently, it is handled as a reuse.
+	session := entities.RefreshToken{
+		FamilyId: storedToken.FamilyId,
+		ParentTokenHash: &storedToken.TokenHash,
+		DeviceName: storedToken.DeviceName,
+		UserAgent: refreshTokenRequest.UserAgent,
+		IpAddress: refreshTokenRequest.IpAddress,
+		SessionCreatedAt: storedToken.SessionCreatedAt,
+	}
+	newRefreshToken, accessToken, err := s.issueTokens(ctx, *userAuthData, session)
 	if err != nil {
 		return nil, err
 	}
+	rotated, err := refreshTokenRepo.MarkRotated(ctx, storedToken.TokenHash, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !rotated {
+		return nil, s.revokeReusedTokenFamily(ctx, storedToken)
+	}
 
 	// Build the response and return it.
 	refreshTokenResponse := dto.RefreshTokenResponse{		
 		AccessToken: accessToken,
+		RefreshToken: newRefreshToken,
 	}
 	return &refreshTokenResponse, nil
-}
\ No newline at end of file
+}
+
+// ListSessions returns the active sessions of the user, marking the session of the
+// current request.
+func (s *AuthService) ListSessions(ctx context.Context, userAuthData types.UserAuthData) ([]dto.SessionResponse, error) {
+	// Required repositories and providers.
+	refreshTokenRepo := s.dao.RefreshTokenRepo()
+
+	// Each active refresh token is the current token of a session.
+	refreshTokens, err := refreshTokenRepo.FindActiveByUserId(ctx, userAuthData.UserId, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	sessions := make([]dto.SessionResponse, len(refreshTokens))
+	for i, refreshToken := range refreshTokens {
+		sessions[i] = dto.SessionResponse{
+			Id: refreshToken.FamilyId,
+			DeviceName: refreshToken.DeviceName,
+			UserAgent: refreshToken.UserAgent,
+			IpAddress: refreshToken.IpAddress,
+			CreatedAt: refreshToken.SessionCreatedAt,
+			LastUsedAt: refreshToken.LastUsedAt,
+			ExpiresAt: refreshToken.ExpiresAt,
+			Current: refreshToken.FamilyId == userAuthData.SessionId,
+		}
+	}
+	return sessions, nil
+}
+
+// RevokeSession ends a session of the user. types.ErrSessionNotFound is returned if
+// the session does not exist or belongs to another user.
+func (s *AuthService) RevokeSession(ctx context.Context, userAuthData types.UserAuthData, sessionId string) error {
+	// Required repositories and providers.
+	refreshTokenRepo := s.dao.RefreshTokenRepo()
+
+	// Validate the session belongs to the user.
+	refreshTokens, err := refreshTokenRepo.FindByFamilyId(ctx, sessionId)
+	if err != nil {
+		return err
+	}
+	if len(refreshTokens) == 0 || refreshTokens[0].UserId != userAuthData.UserId {
+		return types.ErrSessionNotFound
+	}
+
+	// Revoke the session.
+	return s.revokeTokenFamily(ctx, sessionId)
+}
+
+// RevokeOtherSessions ends every session of the user except the current one.
+func (s *AuthService) RevokeOtherSessions(ctx context.Context, userAuthData types.UserAuthData) error {
+	// Required repositories and providers.
+	refreshTokenRepo := s.dao.RefreshTokenRepo()
+
+	// Revoke every token family other than the current one.
+	refreshTokens, err := refreshTokenRepo.FindByUserId(ctx, userAuthData.UserId)
+	if err != nil {
+		return err
+	}
+	revoked := map[string]bool{ userAuthData.SessionId: true }
+	for _, refreshToken := range refreshTokens {
+		if revoked[refreshToken.FamilyId] {
+			continue
+		}
+		if err := s.revokeTokenFamily(ctx, refreshToken.FamilyId); err != nil {
+			return err
+		}
+		revoked[refreshToken.FamilyId] = true
+	}
+	return nil
+}
+
+// Authenticate validates an access token and returns the authentication data of its
+// owner. The returned error wraps types.ErrTokenExpired, types.ErrInvalidToken or
+// types.ErrTokenRevoked if the token is expired, invalid or revoked.
+func (s *AuthService) Authenticate(ctx context.Context, accessToken string) (*types.UserAuthData, error) {
+	// Required repositories and providers.
+	revokedTokenRepo := s.dao.RevokedTokenRepo()
+	authTokenManager := s.authTokenManager
+
+	// Validate the access token and retrieve custom data (UserAuthData).
+	userAuthData, err := authTokenManager.ValidateAccessToken(accessToken)
+	if err != nil {
+		return nil, fmt.Errorf("unauthenticated user: %w", err)
+	}
+
+	// Validate the access token has not been revoked.
+	tokenId, err := authTokenManager.TokenId(accessToken)
+	if err != nil {
+		return nil, fmt.Errorf("unauthenticated user: %w: %w", types.ErrInvalidToken, err)
+	}
+	revoked, err := revokedTokenRepo.Exists(ctx, tokenId, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, fmt.Errorf("unauthenticated user: %w", types.ErrTokenRevoked)
+	}
+	return userAuthData, nil
+}
+
+// JWKS returns the JSON Web Key Set containing the public keys that verify access
+// tokens.
+func (s *AuthService) JWKS(ctx context.Context) (*dto.JWKSResponse, error) {
+	return &dto.JWKSResponse{ Keys: s.authTokenManager.AccessTokenPublicKeys() }, nil
+}
+
+// ChangePassword replaces the password of the user, which requires the current one
+// (accounts without a password reauthenticate as in DeleteAccount, and can set one this
+// way). Wrong attempts count as failed logins. The other sessions of the user are
+// revoked, since they may belong to whoever knew the old password.
+func (s *AuthService) ChangePassword(ctx context.Context, userAuthData types.UserAuthData, changePasswordRequest dto.ChangePasswordRequest) error {
+	// Required repositories and providers.
+	userRepo := s.dao.UserRepo()
+	passwordManager := s.passwordManager
+	loginThrottle := s.loginThrottle
+
+	// Reauthenticate the user. The attempt is reserved in the login throttle, so that a
+	// stolen session cannot be used to guess the password without limit.
+	user, err := userRepo.FindById(ctx, userAuthData.UserId)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if err := loginThrottle.Reserve(ctx, user.Username, changePasswordRequest.IpAddress, now); err != nil {
+		return err
+	}
+	reauthenticationRequest := dto.ReauthenticationRequest{
+		Password: changePasswordRequest.CurrentPassword,
+		Code: changePasswordRequest.Code,
+		RecoveryCode: changePasswordRequest.RecoveryCode,
+	}
+	err = s.reauthenticate(ctx, userAuthData, user, reauthenticationRequest)
+	if errors.Is(err, types.ErrInvalidCredentials) {
+		if err := loginThrottle.RecordFailure(ctx, user.Username, changePasswordRequest.IpAddress, now); err != nil {
+			return err
+		}
+		return types.ErrInvalidCredentials
+	}
+	if err := loginThrottle.Release(ctx, user.Username, changePasswordRequest.IpAddress); err != nil {
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	// Validate the new password against the password policy, hash it and save it.
+	if err := passwordManager.ValidatePassword(user.Username, changePasswordRequest.NewPassword); err != nil {
+		return err
+	}
+	hashedPassword, err := passwordManager.HashPassword(changePasswordRequest.NewPassword)
+	if err != nil {
+		return err
+	}
+	replaced, err := userRepo.ReplacePassword(ctx, user.Id, user.Password, hashedPassword)
+	if err != nil {
+		return err
+	}
+	if !replaced {
+		return types.ErrPasswordChangedConcurrently
+	}
+
+	// Revoke the other sessions.
+	return s.RevokeOtherSessions(ctx, userAuthData)
+}
+
+// ChangeUsername replaces the username of the user. The username must not be used by
+// another user, and it can only be changed once per cooldown.
+func (s *AuthService) ChangeUsername(ctx context.Context, userAuthData types.UserAuthData, changeUsernameRequest dto.ChangeUsernameRequest) (*dto.AccountResponse, error) {
+	// Required repositories and providers.
+	userRepo := s.dao.UserRepo()
+
+	// Validate the cooldown of the previous change has passed.
+	user, err := userRepo.FindById(ctx, userAuthData.UserId)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if user.UsernameChangedAt != nil {
+		nextChangeAt := user.UsernameChangedAt.Add(s.config.UsernameChangeCooldown)
+		if now.Before(nextChangeAt) {
+			return nil, fmt.Errorf("%w: the next change is allowed at %s",
+				types.ErrUsernameChangeTooSoon, nextChangeAt.Format(time.RFC3339))
+		}
+	}
+
+	// Validate the new username is valid and not used by another user.
+	username := strings.TrimSpace(changeUsernameRequest.Username)
+	if username == "" {
+		return nil, fmt.Errorf("invalid username: username is empty")
+	}
+	if username == user.Username {
+		return accountResponse(user), nil
+	}
+	usernameTaken, err := userRepo.ExistsByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if usernameTaken {
+		return nil, types.ErrUsernameTaken
+	}
+
+	// Save the new username.
+	user.Username = username
+	user.UsernameChangedAt = &now
+	user, err = userRepo.Update(ctx, user.Id, *user)
+	if err != nil {
+		return nil, err
+	}
+	return accountResponse(user), nil
+}
+
+// ChangeEmail replaces the email of the user, which requires the user to
+// reauthenticate (see reauthenticate). The email must not be used by another user.
+func (s *AuthService) ChangeEmail(ctx context.Context, userAuthData types.UserAuthData, changeEmailRequest dto.ChangeEmailRequest) (*dto.AccountResponse, error) {
+	// Required repositories and providers.
+	userRepo := s.dao.UserRepo()
+
+	// Validate the user reauthenticated.
+	user, err := userRepo.FindById(ctx, userAuthData.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.reauthenticate(ctx, userAuthData, user, changeEmailRequest.ReauthenticationRequest); err != nil {
+		return nil, err
+	}
+
+	// Validate the new email is valid and not used by another user.
+	email, err := normalizeEmail(changeEmailRequest.Email)
+	if err != nil {
+		return nil, err
+	}
+	if user.Email != nil && *user.Email == email {
+		return accountResponse(user), nil
+	}
+	emailTaken, err := userRepo.ExistsByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	if emailTaken {
+		return nil, types.ErrEmailTaken
+	}
+
+	// Save the new email, which must be verified again, and send its verification link.
+	user.Email = &email
+	user.EmailVerifiedAt = nil
+	user, err = userRepo.Update(ctx, user.Id, *user)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.sendEmailVerification(ctx, user); err != nil {
+		return nil, err
+	}
+	return accountResponse(user), nil
+}
+
+// VerifyEmail marks the email of a user as verified with an email verification token.
+// The token can only be used once, and only for the email it was sent to. The
+// permissions that require a verified email are granted on the next token refresh.
+func (s *AuthService) VerifyEmail(ctx context.Context, verifyEmailRequest dto.VerifyEmailRequest) error {
+	// Required repositories and providers.
+	userRepo := s.dao.UserRepo()
+	oneTimeTokenRepo := s.dao.OneTimeTokenRepo()
+	transactionManager := s.dao.TransactionManager()
+
+	// Validate the verification token. Changing the email deletes the tokens sent to the
+	// previous one, so a valid token always belongs to the current email.
+	now := time.Now()
+	tokenHash := hashToken(verifyEmailRequest.Token)
+	oneTimeToken, err := s.findOneTimeToken(ctx, tokenHash, emailVerificationPurpose, now)
+	if err != nil {
+		return err
+	}
+	user, err := userRepo.FindById(ctx, oneTimeToken.UserId)
+	if err != nil {
+		return err
+	}
+
+	if user.Email == nil {
+		return types.ErrInvalidToken
+	}
+
+	// Use the token and mark the email as verified. Only the verification time is
+	// written, and only if the email was not changed since it was read.
+	return transactionManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		used, err := oneTimeTokenRepo.MarkUsed(ctx, tokenHash, now)
+		if err != nil {
+			return err
+		}
+		if !used {
+			return types.ErrInvalidToken
+		}
+		verified, err := userRepo.MarkEmailVerified(ctx, user.Id, *user.Email, now)
+		if err != nil {
+			return err
+		}
+		if !verified {
+			return types.ErrInvalidToken
+		}
+		return nil
+	})
+}
+
+// ResendEmailVerification sends a new email verification link to the email of the user,
+// which invalidates the previous links. A new link can only be requested once per
+// resend cooldown.
+func (s *AuthService) ResendEmailVerification(ctx context.Context, userAuthData types.UserAuthData) error {
+	// Required repositories and providers.
+	userRepo := s.dao.UserRepo()
+	oneTimeTokenRepo := s.dao.OneTimeTokenRepo()
+
+	// Validate the user has an unverified email.
+	user, err := userRepo.FindById(ctx, userAuthData.UserId)
+	if err != nil {
+		return err
+	}
+	if user.Email == nil {
+		return types.ErrNoEmail
+	}
+	if user.EmailVerifiedAt != nil {
+		return types.ErrEmailAlreadyVerified
+	}
+
+	// Validate the cooldown of the previous link has passed.
+	lastToken, err := oneTimeTokenRepo.FindLatestByUserId(ctx, user.Id, emailVerificationPurpose)
+	if err != nil && !errors.Is(err, domports.ErrNotFound) {
+		return err
+	}
+	if lastToken != nil {
+		nextEmailAt := lastToken.CreatedAt.Add(s.config.EmailVerificationResendCooldown)
+		if time.Now().Before(nextEmailAt) {
+			return fmt.Errorf("%w: the next email can be requested at %s",
+				types.ErrVerificationEmailTooSoon, nextEmailAt.Format(time.RFC3339))
+		}
+	}
+
+	// Send a new link.
+	return s.sendEmailVerification(ctx, user)
+}
+
+// RequestPasswordReset sends a password reset link to the email, if it belongs to a
+// user and the cooldown of its previous link has passed. The result is the same whether
+// the email belongs to a user or not, so that the accounts cannot be discovered: the
+// response is the same, the email is sent in the background, and every request takes
+// at least passwordResetResponseTime, which hides the work done to issue the link.
+// Requesting a new link invalidates the previous ones.
+func (s *AuthService) RequestPasswordReset(ctx context.Context, passwordResetRequest dto.PasswordResetRequest) error {
+	// Required repositories and providers.
+	userRepo := s.dao.UserRepo()
+	oneTimeTokenRepo := s.dao.OneTimeTokenRepo()
+	mailer := s.mailer
+
+	// Take the same time on every path.
+	responseTime := time.NewTimer(passwordResetResponseTime)
+	defer func() {
+		select {
+			case <-responseTime.C:
+			case <-ctx.Done():
+		}
+	}()
+
+	// Retrieve the user with this email, if any.
+	email, err := normalizeEmail(passwordResetRequest.Email)
+	if err != nil {
+		return err
+	}
+	user, err := userRepo.FindByEmail(ctx, email)
+	if errors.Is(err, domports.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// Ignore the request if the cooldown of the previous link has not passed. No error is
+	// returned, since it would reveal the account.
+	lastToken, err := oneTimeTokenRepo.FindLatestByUserId(ctx, user.Id, passwordResetPurpose)
+	if err != nil && !errors.Is(err, domports.ErrNotFound) {
+		return err
+	}
+	if lastToken != nil && time.Now().Before(lastToken.CreatedAt.Add(s.config.PasswordResetResendCooldown)) {
+		return nil
+	}
+
+	// Generate the reset token, replacing the previous ones.
+	token, err := s.issueOneTimeToken(ctx, user.Id, passwordResetPurpose, s.config.PasswordResetTokenDuration)
+	if err != nil {
+		return err
+	}
+
+	// Send the reset link.
+	resetUrl := strings.ReplaceAll(s.config.PasswordResetUrl, "{token}", url.QueryEscape(token))
+	return mailer.Send(ctx, types.MailMessage{
+		To: email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(
+			"Hello %s,\n\n" +
+			"To choose a new password, open the following link:\n\n%s\n\n" +
+			"The link expires in %s. If you did not request it, you can ignore this email.",
+			user.Username, resetUrl, s.config.PasswordResetTokenDuration,
+		),
+	})
+}
+
+// ConfirmPasswordReset sets a new password with a password reset token. The token can
+// only be used once. Every session of the user is ended, and the account is unlocked.
+func (s *AuthService) ConfirmPasswordReset(ctx context.Context, passwordResetConfirmRequest dto.PasswordResetConfirmRequest) error {
+	// Required repositories and providers.
+	userRepo := s.dao.UserRepo()
+	oneTimeTokenRepo := s.dao.OneTimeTokenRepo()
+	transactionManager := s.dao.TransactionManager()
+	passwordManager := s.passwordManager
+	loginThrottle := s.loginThrottle
+
+	// Validate the reset token.
+	now := time.Now()
+	tokenHash := hashToken(passwordResetConfirmRequest.Token)
+	oneTimeToken, err := s.findOneTimeToken(ctx, tokenHash, passwordResetPurpose, now)
+	if err != nil {
+		return err
+	}
+
+	// Validate the new password against the password policy and hash it.
+	user, err := userRepo.FindById(ctx, oneTimeToken.UserId)
+	if err != nil {
+		return err
+	}
+	if err := passwordManager.ValidatePassword(user.Username, passwordResetConfirmRequest.NewPassword); err != nil {
+		return err
+	}
+	hashedPassword, err := passwordManager.HashPassword(passwordResetConfirmRequest.NewPassword)
+	if err != nil {
+		return err
+	}
+
+	// Use the token and save the new password. If the token was used concurrently, the
+	// password is not changed. Only the password is written, and only if it was not
+	// changed since it was read; otherwise the token is left unused.
+	err = transactionManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		used, err := oneTimeTokenRepo.MarkUsed(ctx, tokenHash, now)
+		if err != nil {
+			return err
+		}
+		if !used {
+			return types.ErrInvalidToken
+		}
+		replaced, err := userRepo.ReplacePassword(ctx, user.Id, user.Password, hashedPassword)
+		if err != nil {
+			return err
+		}
+		if !replaced {
+			return types.ErrPasswordChangedConcurrently
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// End every session, and unlock the account.
+	if err := s.LogoutAll(ctx, types.UserAuthData{ UserId: user.Id }); err != nil {
+		return err
+	}
+	return loginThrottle.RecordSuccess(ctx, user.Username)
+}
+
+// DeleteAccount deletes the account of the user, which requires the user to
+// reauthenticate (see reauthenticate), unless it is a guest account. Every session is
+// ended and the account is soft deleted: it can no longer be used, and it is anonymized
+// once the deletion grace period has passed (see jobs.AccountAnonymizer).
+func (s *AuthService) DeleteAccount(ctx context.Context, userAuthData types.UserAuthData, deleteAccountRequest dto.DeleteAccountRequest) error {
+	// Required repositories and providers.
+	userRepo := s.dao.UserRepo()
+
+	// Validate the user reauthenticated. Guests have no credentials to confirm.
+	user, err := userRepo.FindById(ctx, userAuthData.UserId)
+	if err != nil {
+		return err
+	}
+	if user.GuestExpiresAt == nil {
+		if err := s.reauthenticate(ctx, userAuthData, user, deleteAccountRequest.ReauthenticationRequest); err != nil {
+			return err
+		}
+	}
+
+	// End every session and delete the account.
+	if err := s.LogoutAll(ctx, userAuthData); err != nil {
+		return err
+	}
+	return userRepo.Delete(ctx, user.Id)
+}
+
+// reauthenticate validates that the user confirmed their identity for a sensitive
+// change. Users with a password must give it. Users without a password (e.g., accounts
+// created with an OpenID Connect login) must give a TOTP or recovery code if they
+// enabled two-factor authentication, or otherwise use a session started in the last
+// recentLoginMaxAge, that is, log in again with their provider.
+// types.ErrInvalidCredentials is returned if the password or code is wrong, and
+// types.ErrReauthenticationRequired if the session is not recent enough.
+func (s *AuthService) reauthenticate(ctx context.Context, userAuthData types.UserAuthData, user *entities.User, reauthenticationRequest dto.ReauthenticationRequest) error {
+	// Required repositories and providers.
+	refreshTokenRepo := s.dao.RefreshTokenRepo()
+	passwordManager := s.passwordManager
+
+	// Validate the password.
+	if user.Password != "" {
+		if !passwordManager.CheckPassword(user.Password, reauthenticationRequest.Password) {
+			return types.ErrInvalidCredentials
+		}
+		return nil
+	}
+
+	// Validate the second factor.
+	now := time.Now()
+	if reauthenticationRequest.Code != "" || reauthenticationRequest.RecoveryCode != "" {
+		err := s.verifySecondFactor(ctx, user.Id, reauthenticationRequest.Code, reauthenticationRequest.RecoveryCode, now)
+		if errors.Is(err, types.ErrInvalidMfaCode) || errors.Is(err, types.ErrMfaNotEnrolled) {
+			return types.ErrInvalidCredentials
+		}
+		return err
+	}
+
+	// Validate the session started recently.
+	if userAuthData.SessionId == "" {
+		return types.ErrReauthenticationRequired
+	}
+	refreshTokens, err := refreshTokenRepo.FindByFamilyId(ctx, userAuthData.SessionId)
+	if err != nil {
+		return err
+	}
+	if len(refreshTokens) == 0 || now.Sub(refreshTokens[0].SessionCreatedAt) > recentLoginMaxAge {
+		return types.ErrReauthenticationRequired
+	}
+	return nil
+}
+
+// startSession starts a new session of the user: it generates its refresh and access
+// tokens, which start a new token family.
+func (s *AuthService) startSession(
+		ctx context.Context,
+		userId int64,
+		chosenDeviceName string,
+		clientInfo dto.ClientInfo,
+		) (*dto.LoginResponse, error) {
+	// Generate refresh and access tokens. The refresh token starts a new token family.
+	familyId, err := newRandomId()
+	if err != nil {
+		return nil, err
+	}
+	userAuthData, err := s.loadUserAuthData(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	session := entities.RefreshToken{
+		FamilyId: familyId,
+		DeviceName: deviceName(chosenDeviceName, clientInfo.UserAgent),
+		UserAgent: clientInfo.UserAgent,
+		IpAddress: clientInfo.IpAddress,
+		SessionCreatedAt: time.Now(),
+	}
+	refreshToken, accessToken, err := s.issueTokens(ctx, *userAuthData, session)
+	if err != nil {
+		return nil, err
+	}
+
+	// Build the response and return it.
+	loginResponse := dto.LoginResponse{		
+		RefreshToken: refreshToken,
+		AccessToken: accessToken,
+	}
+	return &loginResponse, nil
+}
+
+// createOidcUser creates the account of a user logging in for the first time with an
+// external identity, and links the identity to it. The username is derived from the
+// profile of the identity; the email is set only if it is not used by another user, and
+// it is verified if the provider says so. The account has no password: one can be set
+// with the password reset.
+func (s *AuthService) createOidcUser(ctx context.Context, identity *types.OidcIdentity, now time.Time) (*entities.User, error) {
+	// Required repositories and providers.
+	userRepo := s.dao.UserRepo()
+	userRoleRepo := s.dao.UserRoleRepo()
+	userIdentityRepo := s.dao.UserIdentityRepo()
+	transactionManager := s.dao.TransactionManager()
+
+	// Choose an unused username: the preferred username of the identity, with a random
+	// suffix if it is taken.
+	baseUsername := oidcUsername(identity)
+	username := baseUsername
+	for attempt := 0; ; attempt++ {
+		usernameTaken, err := userRepo.ExistsByUsername(ctx, username)
+		if err != nil {
+			return nil, err
+		}
+		if !usernameTaken {
+			break
+		}
+		if attempt == 10 {
+			return nil, types.ErrUsernameTaken
+		}
+		suffix, err := newRandomId()
+		if err != nil {
+			return nil, err
+		}
+		username = baseUsername + "-" + suffix[:6]
+	}
+
+	// Use the email of the identity, unless it is invalid or used by another user.
+	user := entities.User{ Username: username }
+	if email, err := normalizeEmail(identity.Email); err == nil {
+		emailTaken, err := userRepo.ExistsByEmail(ctx, email)
+		if err != nil {
+			return nil, err
+		}
+		if !emailTaken {
+			user.Email = &email
+			if identity.EmailVerified {
+				user.EmailVerifiedAt = &now
+			}
+		}
+	}
+
+	// Create the account, as a player, and link the identity to it.
+	var createdUser *entities.User
+	err := transactionManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		createdUser, err = userRepo.Create(ctx, user)
+		if err != nil {
+			return err
+		}
+		if _, err := userRoleRepo.Replace(ctx, createdUser.Id, []string{ string(types.RolePlayer) }); err != nil {
+			return err
+		}
+		userIdentity := entities.UserIdentity{
+			Provider: identity.Provider,
+			Subject: identity.Subject,
+			UserId: createdUser.Id,
+			Email: identity.Email,
+			LastLoginAt: now,
+		}
+		_, err = userIdentityRepo.Create(ctx, userIdentity)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Send the email verification link if the provider did not verify the email.
+	if createdUser.Email != nil && createdUser.EmailVerifiedAt == nil {
+		if err := s.sendEmailVerification(ctx, createdUser); err != nil {
+			return nil, err
+		}
+	}
+	return createdUser, nil
+}
+
+// mfaEnabled reports whether the user has confirmed the enrolment of the two-factor
+// authentication.
+func (s *AuthService) mfaEnabled(ctx context.Context, userId int64) (bool, error) {
+	userTotp, err := s.dao.UserTotpRepo().FindByUserId(ctx, userId)
+	if errors.Is(err, domports.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return userTotp.ConfirmedAt != nil, nil
+}
+
+// verifySecondFactor validates a TOTP code or, if no TOTP code is given, a recovery code
+// of the user. Accepted codes cannot be used again. types.ErrInvalidMfaCode is returned
+// if the code is wrong or was already used.
+func (s *AuthService) verifySecondFactor(
+		ctx context.Context,
+		userId int64,
+		code string,
+		recoveryCode string,
+		now time.Time,
+		) error {
+	// Required repositories and providers.
+	userTotpRepo := s.dao.UserTotpRepo()
+	recoveryCodeRepo := s.dao.RecoveryCodeRepo()
+	passwordManager := s.passwordManager
+	totpManager := s.totpManager
+
+	// Retrieve the secret of the enabled two-factor authentication.
+	userTotp, err := userTotpRepo.FindByUserId(ctx, userId)
+	if errors.Is(err, domports.ErrNotFound) {
+		return types.ErrMfaNotEnrolled
+	}
+	if err != nil {
+		return err
+	}
+	if userTotp.ConfirmedAt == nil {
+		return types.ErrMfaNotEnrolled
+	}
+
+	// Validate the TOTP code, and register its time step so it cannot be used again.
+	if code != "" {
+		step, valid := totpManager.ValidateCode(userTotp.Secret, code, now)
+		if !valid {
+			return types.ErrInvalidMfaCode
+		}
+		used, err := userTotpRepo.UseStep(ctx, userId, step)
+		if err != nil {
+			return err
+		}
+		if !used {
+			return types.ErrInvalidMfaCode
+		}
+		return nil
+	}
+
+	// Otherwise, validate the recovery code against the unused ones, and mark it as used.
+	if recoveryCode == "" {
+		return types.ErrInvalidMfaCode
+	}
+	recoveryCodes, err := recoveryCodeRepo.FindUnusedByUserId(ctx, userId)
+	if err != nil {
+		return err
+	}
+	normalizedCode := normalizeRecoveryCode(recoveryCode)
+	for _, storedCode := range recoveryCodes {
+		if !passwordManager.CheckPassword(storedCode.CodeHash, normalizedCode) {
+			continue
+		}
+		marked, err := recoveryCodeRepo.MarkUsed(ctx, storedCode.Id, now)
+		if err != nil {
+			return err
+		}
+		if !marked {
+			return types.ErrInvalidMfaCode
+		}
+		return nil
+	}
+	return types.ErrInvalidMfaCode
+}
+
+// issueOneTimeToken generates a one-time token of the user for the purpose, valid for
+// the given duration, and registers its digest. The previous tokens of the user for the
+// same purpose are deleted.
+func (s *AuthService) issueOneTimeToken(ctx context.Context, userId int64, purpose string, duration time.Duration) (string, error) {
+	// Required repositories and providers.
+	oneTimeTokenRepo := s.dao.OneTimeTokenRepo()
+
+	// Generate and register the token (its digest), replacing the previous ones.
+	token, err := newRandomToken()
+	if err != nil {
+		return "", err
+	}
+	if err := oneTimeTokenRepo.DeleteByUserId(ctx, userId, purpose); err != nil {
+		return "", err
+	}
+	oneTimeToken := entities.OneTimeToken{
+		TokenHash: hashToken(token),
+		UserId: userId,
+		Purpose: purpose,
+		ExpiresAt: time.Now().Add(duration),
+	}
+	if _, err := oneTimeTokenRepo.Create(ctx, oneTimeToken); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// findOneTimeToken returns the unused and unexpired one-time token with this digest,
+// issued for the purpose. types.ErrInvalidToken or types.ErrTokenExpired is returned
+// otherwise.
+func (s *AuthService) findOneTimeToken(ctx context.Context, tokenHash string, purpose string, now time.Time) (*entities.OneTimeToken, error) {
+	oneTimeToken, err := s.dao.OneTimeTokenRepo().FindByToken(ctx, tokenHash, purpose)
+	if errors.Is(err, domports.ErrNotFound) {
+		return nil, types.ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if oneTimeToken.UsedAt != nil {
+		return nil, types.ErrInvalidToken
+	}
+	if !oneTimeToken.ExpiresAt.After(now) {
+		return nil, types.ErrTokenExpired
+	}
+	return oneTimeToken, nil
+}
+
+// sendEmailVerification sends an email verification link to the email of the user.
+func (s *AuthService) sendEmailVerification(ctx context.Context, user *entities.User) error {
+	token, err := s.issueOneTimeToken(ctx, user.Id, emailVerificationPurpose, s.config.EmailVerificationTokenDuration)
+	if err != nil {
+		return err
+	}
+	verificationUrl := strings.ReplaceAll(s.config.EmailVerificationUrl, "{token}", url.QueryEscape(token))
+	return s.mailer.Send(ctx, types.MailMessage{
+		To: *user.Email,
+		Subject: "Verify your email",
+		Body: fmt.Sprintf(
+			"Hello %s,\n\n" +
+			"To verify your email, open the following link:\n\n%s\n\n" +
+			"The link expires in %s.",
+			user.Username, verificationUrl, s.config.EmailVerificationTokenDuration,
+		),
+	})
+}
+
+func accountResponse(user *entities.User) *dto.AccountResponse {
+	response := &dto.AccountResponse{ Id: user.Id, Username: user.Username }
+	if user.Email != nil {
+		response.Email = *user.Email
+		response.EmailVerified = user.EmailVerifiedAt != nil
+	}
+	response.Guest = user.GuestExpiresAt != nil
+	return response
+}
+
+// loadUserAuthData builds the authentication data of a user with the settings of the
+// AuthService (see the loadUserAuthData function).
+func (s *AuthService) loadUserAuthData(ctx context.Context, userId int64) (*types.UserAuthData, error) {
+	return loadUserAuthData(ctx, s.dao, userId, s.config.VerifiedEmailRequiredForPaidGames)
+}
+
+// loadUserAuthData builds the authentication data of a user from its current roles.
+// The permission to join paid games is withheld from users without a verified email, if
+// so configured.
+func loadUserAuthData(ctx context.Context, dao *domain.DAO, userId int64, verifiedEmailRequiredForPaidGames bool) (*types.UserAuthData, error) {
+	// Required repositories and providers.
+	userRepo := dao.UserRepo()
+	userRoleRepo := dao.UserRoleRepo()
+
+	// Retrieve the roles of the user and the permissions they grant.
+	userRoles, err := userRoleRepo.FindByUserId(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	roles := make([]types.Role, len(userRoles))
+	for i, userRole := range userRoles {
+		roles[i] = types.Role(userRole.Role)
+	}
+	permissions := types.PermissionsOf(roles)
+
+	// Withhold the permissions that require a verified email.
+	if verifiedEmailRequiredForPaidGames {
+		user, err := userRepo.FindById(ctx, userId)
+		if err != nil {
+			return nil, err
+		}
+		if user.EmailVerifiedAt == nil {
+			permissions = slices.DeleteFunc(permissions, func(permission types.Permission) bool {
+				return permission == types.PermissionJoinPaidGames
+			})
+		}
+	}
+	return &types.UserAuthData{
+		UserId: userId,
+		Roles: roles,
+		Permissions: permissions,
+	}, nil
+}
+
+// issueTokens generates a refresh token and an access token for a session, and
+// registers the refresh token (its digest).
+// The session contains the family, the parent token (nil for the first token of the
+// family) and the device metadata of the refresh token to register; the remaining
+// fields are filled by this method.
+func (s *AuthService) issueTokens(
+		ctx context.Context,
+		userAuthData types.UserAuthData,
+		session entities.RefreshToken,
+		) (string, string, error) {
+	// Required repositories and providers.
+	refreshTokenRepo := s.dao.RefreshTokenRepo()
+	authTokenManager := s.authTokenManager
+
+	// Generate refresh and access tokens, which identify the session.
+	userAuthData.SessionId = session.FamilyId
+	refreshToken, err := authTokenManager.NewRefreshToken(userAuthData)
+	if err != nil {
+		return "", "", err
+	}
+	accessToken, err := authTokenManager.NewAccessToken(userAuthData)
+	if err != nil {
+		return "", "", err
+	}
+
+	// Register refresh token, along with the identifier of the access token.
+	createdAt, expiredAt, err := authTokenManager.IssuedAtAndExpiresAt(refreshToken)
+	if err != nil {
+		return "", "", err
+	}
+	accessTokenId, err := authTokenManager.TokenId(accessToken)
+	if err != nil {
+		return "", "", err
+	}
+	_, accessTokenExpiresAt, err := authTokenManager.IssuedAtAndExpiresAt(accessToken)
+	if err != nil {
+		return "", "", err
+	}
+	rt := session
+	rt.TokenHash = hashToken(refreshToken)
+	rt.UserId = userAuthData.UserId
+	rt.AccessTokenId = accessTokenId
+	rt.AccessTokenExpiresAt = accessTokenExpiresAt
+	rt.LastUsedAt = createdAt
+	rt.CreatedAt = createdAt
+	rt.ExpiresAt = expiredAt
+	if _, err := refreshTokenRepo.Create(ctx, rt); err !=  nil {
+		return "", "", err
+	}
+	return refreshToken, accessToken, nil
+}
+
+// revokeTokenFamily ends the session of a token family: the access tokens issued along
+// with the refresh tokens of the family are revoked, and the refresh tokens are deleted.
+func (s *AuthService) revokeTokenFamily(ctx context.Context, familyId string) error {
+	// Required repositories and providers.
+	refreshTokenRepo := s.dao.RefreshTokenRepo()
+
+	// Revoke the access tokens and delete the refresh tokens of the family.
+	refreshTokens, err := refreshTokenRepo.FindByFamilyId(ctx, familyId)
+	if err != nil {
+		return err
+	}
+	if err := s.revokeAccessTokens(ctx, refreshTokens); err != nil {
+		return err
+	}
+	return refreshTokenRepo.DeleteByFamilyId(ctx, familyId)
+}
+
+// revokeAccessTokens revokes the unexpired access tokens issued along with the refresh
+// tokens.
+func (s *AuthService) revokeAccessTokens(ctx context.Context, refreshTokens []entities.RefreshToken) error {
+	// Required repositories and providers.
+	revokedTokenRepo := s.dao.RevokedTokenRepo()
+
+	now := time.Now()
+	for _, refreshToken := range refreshTokens {
+		if refreshToken.AccessTokenId == "" || !refreshToken.AccessTokenExpiresAt.After(now) {
+			continue
+		}
+		revokedToken := entities.RevokedToken{
+			TokenId: refreshToken.AccessTokenId,
+			UserId: refreshToken.UserId,
+			ExpiresAt: refreshToken.AccessTokenExpiresAt,
+			RevokedAt: now,
+		}
+		if err := revokedTokenRepo.Create(ctx, revokedToken); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// revokeReusedTokenFamily revokes the family of a reused refresh token, and returns the
+// authentication error to report.
+func (s *AuthService) revokeReusedTokenFamily(ctx context.Context, reusedToken *entities.RefreshToken) error {
+	if err := s.revokeTokenFamily(ctx, reusedToken.FamilyId); err != nil {
+		return err
+	}
+	return fmt.Errorf("unauthenticated user: refresh token reuse detected, the session has been revoked")
+}
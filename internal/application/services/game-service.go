@@ -0,0 +1,753 @@
+package services
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gabriel-98/bingo-backend/internal/application/dto"
+	"github.com/gabriel-98/bingo-backend/internal/application/types"
+	"github.com/gabriel-98/bingo-backend/internal/domain"
+	"github.com/gabriel-98/bingo-backend/internal/domain/entities"
+	domports "github.com/gabriel-98/bingo-backend/internal/domain/ports"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxCardsPerPurchase is the maximum number of cards bought at once.
+const maxCardsPerPurchase = 100
+
+// A GameServiceConfig contains the settings of the GameService.
+type GameServiceConfig struct {
+	// Percentage of the prize of the games kept by the house.
+	HouseCutPercent int
+}
+
+type GameService struct {
+	dao *domain.DAO
+	ledger *Ledger
+	config GameServiceConfig
+}
+
+func NewGameService(dao *domain.DAO, ledger *Ledger, config GameServiceConfig) *GameService {
+	return &GameService{
+		dao: dao,
+		ledger: ledger,
+		config: config,
+	}
+}
+
+// CreateGame creates a game hosted by the user. Its cards are not on sale until the host
+// opens the buying.
+func (s *GameService) CreateGame(ctx context.Context, userAuthData types.UserAuthData, createGameRequest dto.CreateGameRequest) (*dto.GameResponse, error) {
+	// Required repositories and providers.
+	gameRepo := s.dao.GameRepo()
+
+	// Validate the request and create the game.
+	game, err := newGame(ctx, s.dao, userAuthData, createGameRequest, s.config.HouseCutPercent)
+	if err != nil {
+		return nil, err
+	}
+	game, err = gameRepo.Create(ctx, *game)
+	if err != nil {
+		return nil, err
+	}
+	return gameResponse(game), nil
+}
+
+func (s *GameService) GetGame(ctx context.Context, gameId int64) (*dto.GameResponse, error) {
+	game, err := s.dao.GameRepo().FindById(ctx, gameId)
+	if err != nil {
+		return nil, err
+	}
+	return gameResponse(game), nil
+}
+
+// OpenBuying puts the cards of a scheduled game on sale.
+func (s *GameService) OpenBuying(ctx context.Context, userAuthData types.UserAuthData, gameId int64) (*dto.GameResponse, error) {
+	return s.changeStatus(ctx, userAuthData, gameId, types.GameScheduled, types.GameBuying)
+}
+
+// StartGame starts a game, which closes the sale of its cards.
+func (s *GameService) StartGame(ctx context.Context, userAuthData types.UserAuthData, gameId int64) (*dto.GameResponse, error) {
+	return s.changeStatus(ctx, userAuthData, gameId, types.GameBuying, types.GamePlaying)
+}
+
+// CancelGame cancels a game that has not finished, so that its card purchases can be
+// refunded (see WalletService.RefundTransaction). It is allowed to the host of the game
+// and to the users who manage games. The game is locked meanwhile, so that the
+// cancellation waits for the purchases and draws in progress.
+func (s *GameService) CancelGame(ctx context.Context, userAuthData types.UserAuthData, gameId int64) (*dto.GameResponse, error) {
+	// Required repositories and providers.
+	gameRepo := s.dao.GameRepo()
+	transactionManager := s.dao.TransactionManager()
+
+	var game *entities.Game
+	err := transactionManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		game, err = gameRepo.LockById(ctx, gameId)
+		if err != nil {
+			return err
+		}
+		runsGame := game.HostId == userAuthData.UserId && userAuthData.HasPermission(types.PermissionRunGames)
+		if !runsGame && !userAuthData.HasPermission(types.PermissionManageGames) {
+			return types.ErrNotGameHost
+		}
+		switch types.GameStatus(game.Status) {
+			case types.GameScheduled, types.GameBuying, types.GamePlaying:
+			default:
+				return types.ErrInvalidGameStatus
+		}
+		game.Status = string(types.GameCancelled)
+		return gameRepo.Update(ctx, *game)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return gameResponse(game), nil
+}
+
+// changeStatus moves a game hosted by the user from one status to the next. The game is
+// locked meanwhile, so that the change waits for the purchases in progress.
+func (s *GameService) changeStatus(ctx context.Context, userAuthData types.UserAuthData, gameId int64, from types.GameStatus, to types.GameStatus) (*dto.GameResponse, error) {
+	// Required repositories and providers.
+	gameRepo := s.dao.GameRepo()
+	transactionManager := s.dao.TransactionManager()
+
+	var game *entities.Game
+	err := transactionManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		game, err = gameRepo.LockById(ctx, gameId)
+		if err != nil {
+			return err
+		}
+		if game.HostId != userAuthData.UserId {
+			return types.ErrNotGameHost
+		}
+		if game.Status != string(from) {
+			return types.ErrInvalidGameStatus
+		}
+		game.Status = string(to)
+		if to == types.GamePlaying {
+			now := time.Now()
+			game.StartedAt = &now
+		}
+		return gameRepo.Update(ctx, *game)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return gameResponse(game), nil
+}
+
+// PurchaseCards buys cards of a game that is selling them. The cards are different from
+// every other card of the game, and are created atomically with the debit of the price
+// from the user's wallet. Retries with the same idempotency key return the cards of the
+// first purchase, even if the sale has closed since then.
+func (s *GameService) PurchaseCards(ctx context.Context, userAuthData types.UserAuthData, gameId int64, purchaseCardsRequest dto.PurchaseCardsRequest) (*dto.CardPurchaseResponse, error) {
+	// Required repositories and providers.
+	gameRepo := s.dao.GameRepo()
+	gameCardRepo := s.dao.GameCardRepo()
+	ledgerTransactionRepo := s.dao.LedgerTransactionRepo()
+	transactionManager := s.dao.TransactionManager()
+	ledger := s.ledger
+
+	// Validate the request.
+	quantity := purchaseCardsRequest.Quantity
+	if quantity < 1 || quantity > maxCardsPerPurchase {
+		return nil, fmt.Errorf("invalid quantity: it must be between 1 and %d", maxCardsPerPurchase)
+	}
+	idempotencyKey := purchaseCardsRequest.IdempotencyKey
+	if idempotencyKey == "" {
+		randomId, err := newRandomId()
+		if err != nil {
+			return nil, err
+		}
+		idempotencyKey = randomId
+	}
+	idempotencyKey = fmt.Sprintf("purchase:game:%d:user:%d:%s", gameId, userAuthData.UserId, idempotencyKey)
+
+	// The game is locked until the end of the purchase, so that the cards of concurrent
+	// purchases are compared against each other and counted against the limits, and so
+	// that the sale cannot close meanwhile.
+	cardPurchaseResponse := &dto.CardPurchaseResponse{ GameId: gameId }
+	err := transactionManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		game, err := gameRepo.LockById(ctx, gameId)
+		if err != nil {
+			return err
+		}
+		paid := game.CardPrice > 0 || game.BundlePrice > 0
+		if paid && !userAuthData.HasPermission(types.PermissionJoinPaidGames) {
+			return types.ErrPaidGameNotAllowed
+		}
+
+		// Return the cards of a purchase with the same idempotency key.
+		if paid {
+			ledgerTransaction, err := ledgerTransactionRepo.FindByIdempotencyKey(ctx, idempotencyKey)
+			if err == nil {
+				gameCards, err := gameCardRepo.FindByTransactionId(ctx, ledgerTransaction.Id)
+				if err != nil {
+					return err
+				}
+				if len(gameCards) != quantity {
+					return types.ErrIdempotencyKeyReused
+				}
+				cardPurchaseResponse.TransactionId = &ledgerTransaction.Id
+				cardPurchaseResponse.Price = cardsPrice(game, quantity)
+				cardPurchaseResponse.Replayed = true
+				return fillCardPurchaseResponse(cardPurchaseResponse, gameCards)
+			}
+			if !errors.Is(err, domports.ErrNotFound) {
+				return err
+			}
+		}
+
+		// Validate the sale is open and the limits.
+		if game.Status != string(types.GameBuying) {
+			return types.ErrPurchasesClosed
+		}
+		if game.MaxCardsPerPlayer > 0 {
+			playerCards, err := gameCardRepo.CountByGameIdAndUserId(ctx, gameId, userAuthData.UserId)
+			if err != nil {
+				return err
+			}
+			if playerCards + quantity > game.MaxCardsPerPlayer {
+				return types.ErrCardLimitExceeded
+			}
+		}
+		if game.MaxCards > 0 {
+			gameCardsCount, err := gameCardRepo.CountByGameId(ctx, gameId)
+			if err != nil {
+				return err
+			}
+			if gameCardsCount + quantity > game.MaxCards {
+				return types.ErrCardLimitExceeded
+			}
+		}
+
+		// Debit the price from the user's wallet.
+		price := cardsPrice(game, quantity)
+		var transactionId *int64
+		if price > 0 {
+			wallet, err := ledger.UserAccount(ctx, userAuthData.UserId)
+			if err != nil {
+				return err
+			}
+			house, err := ledger.SystemAccount(ctx, types.LedgerHouseAccount)
+			if err != nil {
+				return err
+			}
+			ledgerTransaction, _, err := ledger.Post(ctx, LedgerPosting{
+				Type: types.LedgerCardPurchase,
+				IdempotencyKey: idempotencyKey,
+				Reference: gameReference(gameId),
+				Description: strconv.Itoa(quantity) + " cards",
+				CreatedBy: &userAuthData.UserId,
+				Entries: []LedgerPostingEntry{
+					{ AccountId: wallet.Id, Amount: -price },
+					{ AccountId: house.Id, Amount: price },
+				},
+			})
+			if err != nil {
+				return err
+			}
+			transactionId = &ledgerTransaction.Id
+		}
+
+		// Generate cards different from the cards already sold, and from each other.
+		soldNumbers, err := gameCardRepo.FindNumbersByGameId(ctx, gameId)
+		if err != nil {
+			return err
+		}
+		used := make(map[string]bool, len(soldNumbers) + quantity)
+		for _, numbers := range soldNumbers {
+			used[numbers] = true
+		}
+		gameCards := make([]entities.GameCard, 0, quantity)
+		for len(gameCards) < quantity {
+			numbers := types.NewRandomCard().String()
+			if used[numbers] {
+				continue
+			}
+			used[numbers] = true
+			gameCards = append(gameCards, entities.GameCard{
+				GameId: gameId,
+				UserId: userAuthData.UserId,
+				Numbers: numbers,
+				TransactionId: transactionId,
+			})
+		}
+		gameCards, err = gameCardRepo.CreateBatch(ctx, gameCards)
+		if err != nil {
+			return err
+		}
+		cardPurchaseResponse.TransactionId = transactionId
+		cardPurchaseResponse.Price = price
+		return fillCardPurchaseResponse(cardPurchaseResponse, gameCards)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cardPurchaseResponse, nil
+}
+
+// DrawNumber draws a random number of a game being played. The cards of the game are
+// marked automatically, and if some of them complete the pattern, the game finishes: the
+// prize is split among them and paid to their owners' wallets, and the progressive
+// jackpot of the game is settled, in the same transaction. The payouts have idempotency
+// keys derived from the game, so that they are recorded at most once.
+func (s *GameService) DrawNumber(ctx context.Context, userAuthData types.UserAuthData, gameId int64) (*dto.DrawResponse, error) {
+	// Required repositories and providers.
+	gameRepo := s.dao.GameRepo()
+	gameCardRepo := s.dao.GameCardRepo()
+	gameWinnerRepo := s.dao.GameWinnerRepo()
+	transactionManager := s.dao.TransactionManager()
+
+	drawResponse := &dto.DrawResponse{ Winners: []dto.GameWinnerResponse{} }
+	err := transactionManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		// Validate the game is being played by the user.
+		game, err := gameRepo.LockById(ctx, gameId)
+		if err != nil {
+			return err
+		}
+		if game.HostId != userAuthData.UserId {
+			return types.ErrNotGameHost
+		}
+		if game.Status != string(types.GamePlaying) {
+			return types.ErrInvalidGameStatus
+		}
+
+		// Draw the number.
+		drawnNumbers, err := types.ParseDrawnNumbers(game.DrawnNumbers)
+		if err != nil {
+			return err
+		}
+		number, ok := drawnNumbers.DrawRandom()
+		if !ok {
+			return types.ErrInvalidGameStatus
+		}
+		game.DrawnNumbers = drawnNumbers.String()
+
+		// Find the cards that complete the pattern with this number.
+		gameCards, err := gameCardRepo.FindByGameId(ctx, gameId)
+		if err != nil {
+			return err
+		}
+		validator := types.GamePattern(game.Pattern).Validator()
+		winningCards := []entities.GameCard{}
+		for _, gameCard := range gameCards {
+			card, err := types.ParseCard(gameCard.Numbers)
+			if err != nil {
+				return err
+			}
+			card.MarkDrawn(drawnNumbers)
+			if validator.Validate(drawnNumbers, card) {
+				winningCards = append(winningCards, gameCard)
+			}
+		}
+
+		// Finish the game if there are winners, or if every number has been drawn.
+		if len(winningCards) > 0 || drawnNumbers.Len() == types.MaxBallNumber {
+			sales, err := s.gameSales(ctx, gameId)
+			if err != nil {
+				return err
+			}
+			gameWinners, err := s.payOut(ctx, game, winningCards, drawnNumbers.Len(), sales)
+			if err != nil {
+				return err
+			}
+			if game.JackpotId != nil && game.Pattern == string(types.PatternFullCard) {
+				err := s.settleJackpot(ctx, game, winningCards, gameWinners, drawnNumbers, sales)
+				if err != nil {
+					return err
+				}
+			}
+			if err := gameWinnerRepo.CreateBatch(ctx, gameWinners); err != nil {
+				return err
+			}
+			now := time.Now()
+			game.Status = string(types.GameFinished)
+			game.FinishedAt = &now
+			for _, gameWinner := range gameWinners {
+				drawResponse.Winners = append(drawResponse.Winners, gameWinnerResponse(gameWinner))
+			}
+		}
+		if err := gameRepo.Update(ctx, *game); err != nil {
+			return err
+		}
+		drawResponse.Number = number
+		drawResponse.DrawnNumbers = drawnNumbers.Numbers()
+		drawResponse.Status = game.Status
+		if game.JackpotId != nil {
+			jackpot, err := s.dao.JackpotRepo().FindById(ctx, *game.JackpotId)
+			if err != nil {
+				return err
+			}
+			drawResponse.Jackpot, err = jackpotResponse(ctx, s.ledger, jackpot)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return drawResponse, nil
+}
+
+// gameSales returns the card sales of a game (purchases minus refunds), which the house
+// received.
+func (s *GameService) gameSales(ctx context.Context, gameId int64) (int64, error) {
+	house, err := s.ledger.SystemAccount(ctx, types.LedgerHouseAccount)
+	if err != nil {
+		return 0, err
+	}
+	return s.dao.LedgerTransactionRepo().SumEntries(ctx, house.Id, gameReference(gameId), []string{
+		string(types.LedgerCardPurchase),
+		string(types.LedgerRefund),
+	})
+}
+
+// payOut computes the prize of a finished game from its card sales, splits it among the
+// winning cards (ordered by id, so that the remainder of the split is deterministic) and
+// pays it to their owners. It must run inside a transaction.
+func (s *GameService) payOut(ctx context.Context, game *entities.Game, winningCards []entities.GameCard, ballCount int, sales int64) ([]entities.GameWinner, error) {
+	// Required repositories and providers.
+	ledger := s.ledger
+
+	if len(winningCards) == 0 {
+		return nil, nil
+	}
+	house, err := ledger.SystemAccount(ctx, types.LedgerHouseAccount)
+	if err != nil {
+		return nil, err
+	}
+	prize := gamePrize(game, sales)
+
+	// Split the prize and pay it. The winning cards are sorted in place.
+	slices.SortFunc(winningCards, func(a entities.GameCard, b entities.GameCard) int {
+		return cmp.Compare(a.Id, b.Id)
+	})
+	shares := splitPrize(prize, len(winningCards))
+	gameWinners := make([]entities.GameWinner, len(winningCards))
+	posting := LedgerPosting{
+		Type: types.LedgerPrizePayout,
+		IdempotencyKey: "payout:" + gameReference(game.Id),
+		Reference: gameReference(game.Id),
+		Description: "Prize of " + game.Name,
+		Entries: []LedgerPostingEntry{{ AccountId: house.Id, Amount: -prize }},
+	}
+	for i, winningCard := range winningCards {
+		gameWinners[i] = entities.GameWinner{
+			GameId: game.Id,
+			CardId: winningCard.Id,
+			UserId: winningCard.UserId,
+			Prize: shares[i],
+			BallCount: ballCount,
+		}
+		if shares[i] == 0 {
+			continue
+		}
+		wallet, err := ledger.UserAccount(ctx, winningCard.UserId)
+		if err != nil {
+			return nil, err
+		}
+		posting.Entries = append(posting.Entries, LedgerPostingEntry{ AccountId: wallet.Id, Amount: shares[i] })
+	}
+	if prize > 0 {
+		if _, _, err := ledger.Post(ctx, posting); err != nil {
+			return nil, err
+		}
+	}
+	return gameWinners, nil
+}
+
+// settleJackpot moves the contribution of a finished game to its progressive jackpot, and
+// pays the jackpot to the winning cards that completed the full card within the ball
+// limit of the jackpot. If nobody won it, the ball limit grows by one, up to the number
+// of balls. Jackpots only apply to full card games. The shares of the
+// jackpot are set in the game winners, which must be in the same order as the winning
+// cards. It must run inside a transaction.
+func (s *GameService) settleJackpot(ctx context.Context, game *entities.Game, winningCards []entities.GameCard, gameWinners []entities.GameWinner, drawnNumbers *types.DrawnNumbers, sales int64) error {
+	// Required repositories and providers.
+	jackpotRepo := s.dao.JackpotRepo()
+	ledgerAccountRepo := s.dao.LedgerAccountRepo()
+	ledger := s.ledger
+
+	// Lock the jackpot, shared by concurrent games, and move the contribution from the
+	// house to the jackpot.
+	jackpot, err := jackpotRepo.LockById(ctx, *game.JackpotId)
+	if err != nil {
+		return err
+	}
+	house, err := ledger.SystemAccount(ctx, types.LedgerHouseAccount)
+	if err != nil {
+		return err
+	}
+	jackpotAccount, err := ledger.SystemAccount(ctx, jackpotAccountCode(jackpot.Id))
+	if err != nil {
+		return err
+	}
+	contribution := max(sales, 0) * int64(jackpot.ContributionPercent) / 100
+	if contribution > 0 {
+		_, _, err := ledger.Post(ctx, LedgerPosting{
+			Type: types.LedgerJackpotContribution,
+			IdempotencyKey: "jackpot:contribution:" + gameReference(game.Id),
+			Reference: gameReference(game.Id),
+			Description: "Contribution of " + game.Name,
+			Entries: []LedgerPostingEntry{
+				{ AccountId: house.Id, Amount: -contribution },
+				{ AccountId: jackpotAccount.Id, Amount: contribution },
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// Find the winning cards that completed the full card within the ball limit.
+	jackpotWinners := []int{}
+	if drawnNumbers.Len() <= jackpot.BallLimit {
+		validator := types.NewFullCardValidator()
+		for i, winningCard := range winningCards {
+			card, err := types.ParseCard(winningCard.Numbers)
+			if err != nil {
+				return err
+			}
+			card.MarkDrawn(drawnNumbers)
+			if validator.Validate(drawnNumbers, card) {
+				jackpotWinners = append(jackpotWinners, i)
+			}
+		}
+	}
+	if len(jackpotWinners) == 0 {
+		jackpot.BallLimit = min(jackpot.BallLimit + 1, types.MaxBallNumber)
+		return jackpotRepo.Update(ctx, *jackpot)
+	}
+
+	// Pay the whole jackpot to the winners, and restart it.
+	value, err := ledgerAccountRepo.Balance(ctx, jackpotAccount.Id)
+	if err != nil {
+		return err
+	}
+	if value > 0 {
+		shares := splitPrize(value, len(jackpotWinners))
+		posting := LedgerPosting{
+			Type: types.LedgerJackpotPayout,
+			IdempotencyKey: "jackpot:payout:" + gameReference(game.Id),
+			Reference: gameReference(game.Id),
+			Description: "Jackpot " + jackpot.Name,
+			Entries: []LedgerPostingEntry{{ AccountId: jackpotAccount.Id, Amount: -value }},
+		}
+		for k, i := range jackpotWinners {
+			gameWinners[i].JackpotPrize = shares[k]
+			if shares[k] == 0 {
+				continue
+			}
+			wallet, err := ledger.UserAccount(ctx, winningCards[i].UserId)
+			if err != nil {
+				return err
+			}
+			posting.Entries = append(posting.Entries, LedgerPostingEntry{ AccountId: wallet.Id, Amount: shares[k] })
+		}
+		if _, _, err := ledger.Post(ctx, posting); err != nil {
+			return err
+		}
+	}
+	now := time.Now()
+	jackpot.BallLimit = jackpot.InitialBallLimit
+	jackpot.LastWonAt = &now
+	return jackpotRepo.Update(ctx, *jackpot)
+}
+
+// GetWinners returns the winners of a game.
+func (s *GameService) GetWinners(ctx context.Context, gameId int64) ([]dto.GameWinnerResponse, error) {
+	// Required repositories and providers.
+	gameRepo := s.dao.GameRepo()
+	gameWinnerRepo := s.dao.GameWinnerRepo()
+
+	if _, err := gameRepo.FindById(ctx, gameId); err != nil {
+		return nil, err
+	}
+	gameWinners, err := gameWinnerRepo.FindByGameId(ctx, gameId)
+	if err != nil {
+		return nil, err
+	}
+	gameWinnerResponses := make([]dto.GameWinnerResponse, 0, len(gameWinners))
+	for _, gameWinner := range gameWinners {
+		gameWinnerResponses = append(gameWinnerResponses, gameWinnerResponse(gameWinner))
+	}
+	return gameWinnerResponses, nil
+}
+
+// ListCards returns the cards of the game bought by the user.
+func (s *GameService) ListCards(ctx context.Context, userAuthData types.UserAuthData, gameId int64) ([]dto.GameCardResponse, error) {
+	// Required repositories and providers.
+	gameRepo := s.dao.GameRepo()
+	gameCardRepo := s.dao.GameCardRepo()
+
+	if _, err := gameRepo.FindById(ctx, gameId); err != nil {
+		return nil, err
+	}
+	gameCards, err := gameCardRepo.FindByGameIdAndUserId(ctx, gameId, userAuthData.UserId)
+	if err != nil {
+		return nil, err
+	}
+	cardPurchaseResponse := &dto.CardPurchaseResponse{}
+	if err := fillCardPurchaseResponse(cardPurchaseResponse, gameCards); err != nil {
+		return nil, err
+	}
+	return cardPurchaseResponse.Cards, nil
+}
+
+// newGame validates the settings of a game hosted by the user, and returns the game to
+// create, in the scheduled status. It is not stored.
+func newGame(ctx context.Context, dao *domain.DAO, userAuthData types.UserAuthData, createGameRequest dto.CreateGameRequest, houseCutPercent int) (*entities.Game, error) {
+	name := strings.TrimSpace(createGameRequest.Name)
+	if name == "" {
+		return nil, fmt.Errorf("invalid game: name is empty")
+	}
+	if createGameRequest.CardPrice < 0 || createGameRequest.BundlePrice < 0 {
+		return nil, fmt.Errorf("invalid game: negative price")
+	}
+	if createGameRequest.BundleSize == 1 || createGameRequest.BundleSize < 0 {
+		return nil, fmt.Errorf("invalid game: a bundle must have at least 2 cards")
+	}
+	if createGameRequest.MaxCardsPerPlayer < 0 || createGameRequest.MaxCards < 0 {
+		return nil, fmt.Errorf("invalid game: negative card limit")
+	}
+	pattern := types.PatternFullCard
+	if createGameRequest.Pattern != "" {
+		var err error
+		if pattern, err = types.ParseGamePattern(createGameRequest.Pattern); err != nil {
+			return nil, err
+		}
+	}
+	prizeType := types.PrizeFixed
+	if createGameRequest.PrizeType != "" {
+		var err error
+		if prizeType, err = types.ParsePrizeType(createGameRequest.PrizeType); err != nil {
+			return nil, err
+		}
+	}
+	if createGameRequest.PrizeAmount < 0 || createGameRequest.PrizePercent < 0 || createGameRequest.PrizePercent > 100 {
+		return nil, fmt.Errorf("invalid game: the prize amount must not be negative, and the percent must be between 0 and 100")
+	}
+
+	// Only the host of a jackpot can attach it to games, and only to full card games,
+	// since the jackpot is won by completing the full card.
+	if createGameRequest.JackpotId != nil {
+		if pattern != types.PatternFullCard {
+			return nil, fmt.Errorf("invalid game: a jackpot can only be attached to %s games", types.PatternFullCard)
+		}
+		jackpot, err := dao.JackpotRepo().FindById(ctx, *createGameRequest.JackpotId)
+		if err != nil {
+			return nil, err
+		}
+		if jackpot.HostId != userAuthData.UserId {
+			return nil, types.ErrNotJackpotHost
+		}
+	}
+
+	return &entities.Game{
+		Name: name,
+		HostId: userAuthData.UserId,
+		Status: string(types.GameScheduled),
+		CardPrice: createGameRequest.CardPrice,
+		BundleSize: createGameRequest.BundleSize,
+		BundlePrice: createGameRequest.BundlePrice,
+		MaxCardsPerPlayer: createGameRequest.MaxCardsPerPlayer,
+		MaxCards: createGameRequest.MaxCards,
+		Pattern: string(pattern),
+		PrizeType: string(prizeType),
+		PrizeAmount: createGameRequest.PrizeAmount,
+		PrizePercent: createGameRequest.PrizePercent,
+		HouseCutPercent: houseCutPercent,
+		JackpotId: createGameRequest.JackpotId,
+	}, nil
+}
+
+// cardsPrice returns the price of quantity cards of the game: every complete bundle
+// costs the bundle price, and the remaining cards cost the card price each.
+func cardsPrice(game *entities.Game, quantity int) int64 {
+	if game.BundleSize <= 0 {
+		return int64(quantity) * game.CardPrice
+	}
+	bundles := int64(quantity / game.BundleSize)
+	remainingCards := int64(quantity % game.BundleSize)
+	return bundles * game.BundlePrice + remainingCards * game.CardPrice
+}
+
+// gameReference returns the reference of the ledger transactions of a game.
+func gameReference(gameId int64) string {
+	return "game:" + strconv.FormatInt(gameId, 10)
+}
+
+// gameIdFromReference returns the id of the game of a ledger reference created by
+// gameReference.
+func gameIdFromReference(reference string) (int64, error) {
+	gameId, found := strings.CutPrefix(reference, "game:")
+	if !found {
+		return 0, fmt.Errorf("invalid game reference: %q", reference)
+	}
+	return strconv.ParseInt(gameId, 10, 64)
+}
+
+func fillCardPurchaseResponse(cardPurchaseResponse *dto.CardPurchaseResponse, gameCards []entities.GameCard) error {
+	cardPurchaseResponse.Cards = make([]dto.GameCardResponse, 0, len(gameCards))
+	for _, gameCard := range gameCards {
+		card, err := types.ParseCard(gameCard.Numbers)
+		if err != nil {
+			return err
+		}
+		cardPurchaseResponse.Cards = append(cardPurchaseResponse.Cards, dto.GameCardResponse{
+			Id: gameCard.Id,
+			Numbers: card.Cells(),
+		})
+	}
+	return nil
+}
+
+func gameResponse(game *entities.Game) *dto.GameResponse {
+	drawnNumbers := []int{}
+	if parsedNumbers, err := types.ParseDrawnNumbers(game.DrawnNumbers); err == nil {
+		drawnNumbers = parsedNumbers.Numbers()
+	}
+	return &dto.GameResponse{
+		Id: game.Id,
+		Name: game.Name,
+		HostId: game.HostId,
+		Status: game.Status,
+		CardPrice: game.CardPrice,
+		BundleSize: game.BundleSize,
+		BundlePrice: game.BundlePrice,
+		MaxCardsPerPlayer: game.MaxCardsPerPlayer,
+		MaxCards: game.MaxCards,
+		Pattern: game.Pattern,
+		PrizeType: game.PrizeType,
+		PrizeAmount: game.PrizeAmount,
+		PrizePercent: game.PrizePercent,
+		HouseCutPercent: game.HouseCutPercent,
+		DrawnNumbers: drawnNumbers,
+		JackpotId: game.JackpotId,
+		TemplateId: game.TemplateId,
+		ScheduledAt: game.ScheduledAt,
+		BuyingOpensAt: game.BuyingOpensAt,
+		CreatedAt: game.CreatedAt,
+		StartedAt: game.StartedAt,
+		FinishedAt: game.FinishedAt,
+	}
+}
+
+func gameWinnerResponse(gameWinner entities.GameWinner) dto.GameWinnerResponse {
+	return dto.GameWinnerResponse{
+		CardId: gameWinner.CardId,
+		UserId: gameWinner.UserId,
+		Prize: gameWinner.Prize,
+		BallCount: gameWinner.BallCount,
+		JackpotPrize: gameWinner.JackpotPrize,
+	}
+}
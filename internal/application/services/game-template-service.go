@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"github.com/gabriel-98/bingo-backend/internal/application/dto"
+	"github.com/gabriel-98/bingo-backend/internal/application/types"
+	"github.com/gabriel-98/bingo-backend/internal/domain"
+	"github.com/gabriel-98/bingo-backend/internal/domain/entities"
+	"strings"
+	"time"
+)
+
+// maxGamesPerOccurrence is the maximum number of games scheduled each time the recurrence
+// of a template matches.
+const maxGamesPerOccurrence = 50
+
+type GameTemplateService struct {
+	dao *domain.DAO
+}
+
+func NewGameTemplateService(dao *domain.DAO) *GameTemplateService {
+	return &GameTemplateService{
+		dao: dao,
+	}
+}
+
+// CreateTemplate creates a template of recurring games hosted by the user. The games are
+// created ahead of time by the game scheduler job.
+func (s *GameTemplateService) CreateTemplate(ctx context.Context, userAuthData types.UserAuthData, createGameTemplateRequest dto.CreateGameTemplateRequest) (*dto.GameTemplateResponse, error) {
+	// Required repositories and providers.
+	gameTemplateRepo := s.dao.GameTemplateRepo()
+
+	// Validate the schedule.
+	if createGameTemplateRequest.Variant != types.Variant75Ball {
+		return nil, fmt.Errorf("unsupported variant: %q", createGameTemplateRequest.Variant)
+	}
+	recurrence, err := types.ParseRecurrence(createGameTemplateRequest.Recurrence)
+	if err != nil {
+		return nil, err
+	}
+	gamesPerOccurrence := createGameTemplateRequest.GamesPerOccurrence
+	if gamesPerOccurrence < 1 || gamesPerOccurrence > maxGamesPerOccurrence {
+		return nil, fmt.Errorf("invalid game template: games per occurrence must be between 1 and %d", maxGamesPerOccurrence)
+	}
+	gameInterval, err := time.ParseDuration(createGameTemplateRequest.GameInterval)
+	if err != nil || gameInterval < time.Minute {
+		return nil, fmt.Errorf("invalid game template: the game interval must be a duration of at least 1m")
+	}
+	buyingLeadTime, err := time.ParseDuration(createGameTemplateRequest.BuyingLeadTime)
+	if err != nil || buyingLeadTime < 0 {
+		return nil, fmt.Errorf("invalid game template: the buying lead time must be a non-negative duration")
+	}
+
+	// Validate the settings of the games, once for each pattern.
+	if len(createGameTemplateRequest.Patterns) == 0 {
+		return nil, fmt.Errorf("invalid game template: no patterns")
+	}
+	for _, pattern := range createGameTemplateRequest.Patterns {
+		_, err := newGame(ctx, s.dao, userAuthData, dto.CreateGameRequest{
+			Name: createGameTemplateRequest.Name,
+			CardPrice: createGameTemplateRequest.CardPrice,
+			BundleSize: createGameTemplateRequest.BundleSize,
+			BundlePrice: createGameTemplateRequest.BundlePrice,
+			MaxCardsPerPlayer: createGameTemplateRequest.MaxCardsPerPlayer,
+			MaxCards: createGameTemplateRequest.MaxCards,
+			Pattern: pattern,
+			PrizeType: createGameTemplateRequest.PrizeType,
+			PrizeAmount: createGameTemplateRequest.PrizeAmount,
+			PrizePercent: createGameTemplateRequest.PrizePercent,
+			JackpotId: createGameTemplateRequest.JackpotId,
+		}, 0)
+		if err != nil {
+			return nil, err
+		}
+	}
+	prizeType := types.PrizeFixed
+	if createGameTemplateRequest.PrizeType != "" {
+		prizeType = types.PrizeType(createGameTemplateRequest.PrizeType)
+	}
+
+	// Create the template. Its games are scheduled from now on.
+	gameTemplate, err := gameTemplateRepo.Create(ctx, entities.GameTemplate{
+		Name: strings.TrimSpace(createGameTemplateRequest.Name),
+		HostId: userAuthData.UserId,
+		Variant: createGameTemplateRequest.Variant,
+		Patterns: strings.Join(createGameTemplateRequest.Patterns, ","),
+		CardPrice: createGameTemplateRequest.CardPrice,
+		BundleSize: createGameTemplateRequest.BundleSize,
+		BundlePrice: createGameTemplateRequest.BundlePrice,
+		MaxCardsPerPlayer: createGameTemplateRequest.MaxCardsPerPlayer,
+		MaxCards: createGameTemplateRequest.MaxCards,
+		PrizeType: string(prizeType),
+		PrizeAmount: createGameTemplateRequest.PrizeAmount,
+		PrizePercent: createGameTemplateRequest.PrizePercent,
+		JackpotId: createGameTemplateRequest.JackpotId,
+		Recurrence: recurrence.String(),
+		GamesPerOccurrence: gamesPerOccurrence,
+		GameInterval: gameInterval,
+		BuyingLeadTime: buyingLeadTime,
+		Enabled: true,
+		ScheduledUntil: time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return gameTemplateResponse(gameTemplate), nil
+}
+
+// ListTemplates returns the game templates hosted by the user.
+func (s *GameTemplateService) ListTemplates(ctx context.Context, userAuthData types.UserAuthData) ([]dto.GameTemplateResponse, error) {
+	gameTemplates, err := s.dao.GameTemplateRepo().FindByHostId(ctx, userAuthData.UserId)
+	if err != nil {
+		return nil, err
+	}
+	gameTemplateResponses := make([]dto.GameTemplateResponse, 0, len(gameTemplates))
+	for _, gameTemplate := range gameTemplates {
+		gameTemplateResponses = append(gameTemplateResponses, *gameTemplateResponse(&gameTemplate))
+	}
+	return gameTemplateResponses, nil
+}
+
+// DisableTemplate stops scheduling new games of a template hosted by the user. The games
+// already scheduled are kept.
+func (s *GameTemplateService) DisableTemplate(ctx context.Context, userAuthData types.UserAuthData, gameTemplateId int64) error {
+	// Required repositories and providers.
+	gameTemplateRepo := s.dao.GameTemplateRepo()
+
+	gameTemplate, err := gameTemplateRepo.FindById(ctx, gameTemplateId)
+	if err != nil {
+		return err
+	}
+	if gameTemplate.HostId != userAuthData.UserId {
+		return types.ErrNotGameTemplateHost
+	}
+	return gameTemplateRepo.Disable(ctx, gameTemplateId)
+}
+
+func gameTemplateResponse(gameTemplate *entities.GameTemplate) *dto.GameTemplateResponse {
+	return &dto.GameTemplateResponse{
+		Id: gameTemplate.Id,
+		Name: gameTemplate.Name,
+		Variant: gameTemplate.Variant,
+		Patterns: strings.Split(gameTemplate.Patterns, ","),
+		CardPrice: gameTemplate.CardPrice,
+		BundleSize: gameTemplate.BundleSize,
+		BundlePrice: gameTemplate.BundlePrice,
+		MaxCardsPerPlayer: gameTemplate.MaxCardsPerPlayer,
+		MaxCards: gameTemplate.MaxCards,
+		PrizeType: gameTemplate.PrizeType,
+		PrizeAmount: gameTemplate.PrizeAmount,
+		PrizePercent: gameTemplate.PrizePercent,
+		JackpotId: gameTemplate.JackpotId,
+		Recurrence: gameTemplate.Recurrence,
+		GamesPerOccurrence: gameTemplate.GamesPerOccurrence,
+		GameInterval: gameTemplate.GameInterval.String(),
+		BuyingLeadTime: gameTemplate.BuyingLeadTime.String(),
+		Enabled: gameTemplate.Enabled,
+		ScheduledUntil: gameTemplate.ScheduledUntil,
+	}
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"github.com/gabriel-98/bingo-backend/internal/application/dto"
+	"github.com/gabriel-98/bingo-backend/internal/application/types"
+	"github.com/gabriel-98/bingo-backend/internal/domain"
+	"github.com/gabriel-98/bingo-backend/internal/domain/entities"
+	"strconv"
+	"strings"
+)
+
+// jackpotHistoryLimit is the number of movements returned in the history of a jackpot.
+const jackpotHistoryLimit = 100
+
+type JackpotService struct {
+	dao *domain.DAO
+	ledger *Ledger
+}
+
+func NewJackpotService(dao *domain.DAO, ledger *Ledger) *JackpotService {
+	return &JackpotService{
+		dao: dao,
+		ledger: ledger,
+	}
+}
+
+// CreateJackpot creates a progressive jackpot hosted by the user, who can then attach it
+// to their games.
+func (s *JackpotService) CreateJackpot(ctx context.Context, userAuthData types.UserAuthData, createJackpotRequest dto.CreateJackpotRequest) (*dto.JackpotResponse, error) {
+	// Required repositories and providers.
+	jackpotRepo := s.dao.JackpotRepo()
+
+	// Validate the request. A full card needs at least 24 balls.
+	name := strings.TrimSpace(createJackpotRequest.Name)
+	if name == "" {
+		return nil, fmt.Errorf("invalid jackpot: name is empty")
+	}
+	if createJackpotRequest.ContributionPercent < 1 || createJackpotRequest.ContributionPercent > 100 {
+		return nil, fmt.Errorf("invalid jackpot: the contribution percent must be between 1 and 100")
+	}
+	if createJackpotRequest.BallLimit < 24 || createJackpotRequest.BallLimit > types.MaxBallNumber {
+		return nil, fmt.Errorf("invalid jackpot: the ball limit must be between 24 and %d", types.MaxBallNumber)
+	}
+
+	// Create the jackpot.
+	jackpot, err := jackpotRepo.Create(ctx, entities.Jackpot{
+		Name: name,
+		HostId: userAuthData.UserId,
+		ContributionPercent: createJackpotRequest.ContributionPercent,
+		InitialBallLimit: createJackpotRequest.BallLimit,
+		BallLimit: createJackpotRequest.BallLimit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return jackpotResponse(ctx, s.ledger, jackpot)
+}
+
+// GetJackpot returns a jackpot and its current value.
+func (s *JackpotService) GetJackpot(ctx context.Context, jackpotId int64) (*dto.JackpotResponse, error) {
+	jackpot, err := s.dao.JackpotRepo().FindById(ctx, jackpotId)
+	if err != nil {
+		return nil, err
+	}
+	return jackpotResponse(ctx, s.ledger, jackpot)
+}
+
+// GetJackpotHistory returns the last movements of a jackpot (contributions and payouts),
+// newest first.
+func (s *JackpotService) GetJackpotHistory(ctx context.Context, jackpotId int64) ([]dto.JackpotHistoryEntryResponse, error) {
+	// Required repositories and providers.
+	jackpotRepo := s.dao.JackpotRepo()
+	ledgerTransactionRepo := s.dao.LedgerTransactionRepo()
+	ledger := s.ledger
+
+	if _, err := jackpotRepo.FindById(ctx, jackpotId); err != nil {
+		return nil, err
+	}
+	jackpotAccount, err := ledger.SystemAccount(ctx, jackpotAccountCode(jackpotId))
+	if err != nil {
+		return nil, err
+	}
+	entries, err := ledgerTransactionRepo.FindEntriesByAccountId(ctx, jackpotAccount.Id, jackpotHistoryLimit)
+	if err != nil {
+		return nil, err
+	}
+	jackpotHistory := make([]dto.JackpotHistoryEntryResponse, 0, len(entries))
+	for _, entry := range entries {
+		jackpotHistory = append(jackpotHistory, dto.JackpotHistoryEntryResponse{
+			TransactionId: entry.TransactionId,
+			Type: entry.Transaction.Type,
+			Amount: entry.Amount,
+			Reference: entry.Transaction.Reference,
+			CreatedAt: entry.CreatedAt,
+		})
+	}
+	return jackpotHistory, nil
+}
+
+// jackpotAccountCode returns the code of the ledger account of a jackpot.
+func jackpotAccountCode(jackpotId int64) string {
+	return types.LedgerJackpotAccountPrefix + strconv.FormatInt(jackpotId, 10)
+}
+
+func jackpotResponse(ctx context.Context, ledger *Ledger, jackpot *entities.Jackpot) (*dto.JackpotResponse, error) {
+	jackpotAccount, err := ledger.SystemAccount(ctx, jackpotAccountCode(jackpot.Id))
+	if err != nil {
+		return nil, err
+	}
+	value, err := ledger.dao.LedgerAccountRepo().Balance(ctx, jackpotAccount.Id)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.JackpotResponse{
+		Id: jackpot.Id,
+		Name: jackpot.Name,
+		Value: value,
+		ContributionPercent: jackpot.ContributionPercent,
+		BallLimit: jackpot.BallLimit,
+		LastWonAt: jackpot.LastWonAt,
+	}, nil
+}
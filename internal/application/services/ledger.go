@@ -0,0 +1,174 @@
+package services
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gabriel-98/bingo-backend/internal/application/types"
+	"github.com/gabriel-98/bingo-backend/internal/domain"
+	"github.com/gabriel-98/bingo-backend/internal/domain/entities"
+	domports "github.com/gabriel-98/bingo-backend/internal/domain/ports"
+	"slices"
+	"strconv"
+)
+
+// A LedgerPosting is a transaction to record in the ledger. The amounts of its entries
+// must add up to zero.
+type LedgerPosting struct {
+	Type types.LedgerTransactionType
+	IdempotencyKey string
+	Reference string
+	Description string
+	CreatedBy *int64
+	Entries []LedgerPostingEntry
+}
+
+// A LedgerPostingEntry credits (positive amount) or debits (negative amount) an account.
+type LedgerPostingEntry struct {
+	AccountId int64
+	Amount int64
+}
+
+// A Ledger records the movements of money between the users' wallets and the system
+// accounts in the double-entry ledger. It is shared by the services that move money
+// (e.g., card purchases and prize payouts).
+type Ledger struct {
+	dao *domain.DAO
+}
+
+func NewLedger(dao *domain.DAO) *Ledger {
+	return &Ledger{
+		dao: dao,
+	}
+}
+
+// UserAccount returns the wallet of a user, creating it on first use.
+func (l *Ledger) UserAccount(ctx context.Context, userId int64) (*entities.LedgerAccount, error) {
+	return l.dao.LedgerAccountRepo().FindOrCreate(ctx, entities.LedgerAccount{
+		Code: "wallet:" + strconv.FormatInt(userId, 10),
+		UserId: &userId,
+	})
+}
+
+// SystemAccount returns the system account with the code, creating it on first use.
+// System accounts can have a negative balance.
+func (l *Ledger) SystemAccount(ctx context.Context, code string) (*entities.LedgerAccount, error) {
+	return l.dao.LedgerAccountRepo().FindOrCreate(ctx, entities.LedgerAccount{
+		Code: code,
+		AllowNegative: true,
+	})
+}
+
+// Post records a transaction, unless a transaction with the same idempotency key was
+// already recorded, in which case that transaction is returned and nothing is recorded
+// (replayed is true). types.ErrIdempotencyKeyReused is returned if the recorded
+// transaction is of another type or reference, or has other entries.
+// The accounts of the entries are locked until the end of the transaction, so that
+// concurrent postings cannot make a wallet go negative: types.ErrInsufficientFunds is
+// returned if a debit exceeds the balance. Post must run inside a transaction (see
+// domports.TransactionManager), which may record other changes atomically with it.
+func (l *Ledger) Post(ctx context.Context, posting LedgerPosting) (ledgerTransaction *entities.LedgerTransaction, replayed bool, err error) {
+	// Required repositories and providers.
+	ledgerAccountRepo := l.dao.LedgerAccountRepo()
+	ledgerTransactionRepo := l.dao.LedgerTransactionRepo()
+
+	// Validate the entries balance, and compute the change of each account.
+	if posting.IdempotencyKey == "" {
+		return nil, false, fmt.Errorf("missing idempotency key")
+	}
+	changes := map[int64]int64{}
+	accountIds := []int64{}
+	var total int64
+	for _, entry := range posting.Entries {
+		if _, found := changes[entry.AccountId]; !found {
+			accountIds = append(accountIds, entry.AccountId)
+		}
+		changes[entry.AccountId] += entry.Amount
+		total += entry.Amount
+	}
+	if len(posting.Entries) < 2 || total != 0 {
+		return nil, false, fmt.Errorf("unbalanced ledger transaction")
+	}
+	slices.Sort(accountIds)
+
+	// Lock the accounts. Postings with the same idempotency key lock the same accounts,
+	// so a retry waits for the original posting and then finds it.
+	accounts, err := ledgerAccountRepo.LockByIds(ctx, accountIds)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(accounts) != len(accountIds) {
+		return nil, false, fmt.Errorf("unknown ledger account")
+	}
+	existingTransaction, err := ledgerTransactionRepo.FindByIdempotencyKey(ctx, posting.IdempotencyKey)
+	if err == nil {
+		if existingTransaction.Type != string(posting.Type) || existingTransaction.Reference != posting.Reference {
+			return nil, false, types.ErrIdempotencyKeyReused
+		}
+		existingEntries, err := ledgerTransactionRepo.FindEntries(ctx, existingTransaction.Id)
+		if err != nil {
+			return nil, false, err
+		}
+		if !sameEntries(posting.Entries, existingEntries) {
+			return nil, false, types.ErrIdempotencyKeyReused
+		}
+		return existingTransaction, true, nil
+	}
+	if !errors.Is(err, domports.ErrNotFound) {
+		return nil, false, err
+	}
+
+	// Validate the balances of the debited wallets.
+	for _, account := range accounts {
+		if changes[account.Id] >= 0 || account.AllowNegative {
+			continue
+		}
+		balance, err := ledgerAccountRepo.Balance(ctx, account.Id)
+		if err != nil {
+			return nil, false, err
+		}
+		if balance + changes[account.Id] < 0 {
+			return nil, false, types.ErrInsufficientFunds
+		}
+	}
+
+	// Record the transaction and its entries.
+	entries := make([]entities.LedgerEntry, len(posting.Entries))
+	for i, entry := range posting.Entries {
+		entries[i] = entities.LedgerEntry{ AccountId: entry.AccountId, Amount: entry.Amount }
+	}
+	ledgerTransaction, err = ledgerTransactionRepo.Create(ctx, entities.LedgerTransaction{
+		Type: string(posting.Type),
+		IdempotencyKey: posting.IdempotencyKey,
+		Reference: posting.Reference,
+		Description: posting.Description,
+		CreatedBy: posting.CreatedBy,
+	}, entries)
+	if err != nil {
+		return nil, false, err
+	}
+	return ledgerTransaction, false, nil
+}
+
+// sameEntries reports whether the entries of a posting are the recorded entries, in any
+// order.
+func sameEntries(postingEntries []LedgerPostingEntry, entries []entities.LedgerEntry) bool {
+	if len(postingEntries) != len(entries) {
+		return false
+	}
+	recordedEntries := make([]LedgerPostingEntry, len(entries))
+	for i, entry := range entries {
+		recordedEntries[i] = LedgerPostingEntry{ AccountId: entry.AccountId, Amount: entry.Amount }
+	}
+	compare := func(a LedgerPostingEntry, b LedgerPostingEntry) int {
+		if a.AccountId != b.AccountId {
+			return cmp.Compare(a.AccountId, b.AccountId)
+		}
+		return cmp.Compare(a.Amount, b.Amount)
+	}
+	postingEntries = slices.Clone(postingEntries)
+	slices.SortFunc(postingEntries, compare)
+	slices.SortFunc(recordedEntries, compare)
+	return slices.Equal(postingEntries, recordedEntries)
+}
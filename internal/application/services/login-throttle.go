@@ -0,0 +1,178 @@
+package services
+
+import (
+	"context"
+	"github.com/gabriel-98/bingo-backend/internal/application/types"
+	"github.com/gabriel-98/bingo-backend/internal/domain"
+	"github.com/gabriel-98/bingo-backend/internal/domain/entities"
+	"time"
+)
+
+// A LoginThrottlePolicy configures the brute-force protection of the login.
+type LoginThrottlePolicy struct {
+	// Consecutive failures allowed before the backoff starts.
+	FreeAttempts int
+	// Delay after the first failure beyond the free attempts. It is doubled on each
+	// further failure, up to MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay time.Duration
+	// Consecutive failures of a username that lock the account for LockoutDuration.
+	// Zero disables the lockout.
+	LockoutThreshold int
+	LockoutDuration time.Duration
+	// Failures older than FailureWindow are forgotten.
+	FailureWindow time.Duration
+}
+
+// A LoginThrottle counts the failed logins per username and per IP address, and
+// rejects the logins attempted before the backoff delay has elapsed or while the
+// account is locked.
+type LoginThrottle struct {
+	dao *domain.DAO
+	policy LoginThrottlePolicy
+}
+
+func NewLoginThrottle(dao *domain.DAO, policy LoginThrottlePolicy) *LoginThrottle {
+	return &LoginThrottle{
+		dao: dao,
+		policy: policy,
+	}
+}
+
+// Reserve returns a *types.LoginThrottledError if a login for the username from the IP
+// address must be rejected at the given time. Otherwise, the attempt is counted as a
+// failure before the credentials are checked, so that concurrent attempts cannot all
+// pass the check before any failure is recorded. The attempt must then be completed
+// with RecordFailure or Release.
+func (t *LoginThrottle) Reserve(ctx context.Context, username string, ipAddress string, now time.Time) error {
+	// Required repositories and providers.
+	loginAttemptRepo := t.dao.LoginAttemptRepo()
+
+	// Reject the attempt if a key is locked or must wait.
+	keys := loginThrottleKeys(username, ipAddress)
+	failures := make([]int, len(keys))
+	var retryAfter time.Duration
+	for i, key := range keys {
+		loginAttempt, err := loginAttemptRepo.Find(ctx, key)
+		if err != nil {
+			return err
+		}
+		failures[i] = loginAttempt.Failures
+
+		// A locked account is reported as such, regardless of the backoff.
+		if loginAttempt.LockedUntil != nil && now.Before(*loginAttempt.LockedUntil) {
+			return &types.LoginThrottledError{ Locked: true, RetryAfter: loginAttempt.LockedUntil.Sub(now) }
+		}
+		if wait := t.backoff(loginAttempt, now); wait > retryAfter {
+			retryAfter = wait
+		}
+	}
+	if retryAfter > 0 {
+		return &types.LoginThrottledError{ RetryAfter: retryAfter }
+	}
+
+	// Count the attempt. The counter is incremented atomically, so if other attempts
+	// were counted since it was read, this one is only allowed while it is still within
+	// the free attempts; otherwise it is rejected and uncounted.
+	for i, key := range keys {
+		loginAttempt, err := loginAttemptRepo.RecordFailure(ctx, key, now, t.windowStart(now))
+		if err != nil {
+			return err
+		}
+		if loginAttempt.Failures == 1 || loginAttempt.Failures == failures[i] + 1 ||
+			loginAttempt.Failures <= t.policy.FreeAttempts {
+			continue
+		}
+		for _, reservedKey := range keys[:i + 1] {
+			if err := loginAttemptRepo.Release(ctx, reservedKey); err != nil {
+				return err
+			}
+		}
+		return &types.LoginThrottledError{ RetryAfter: max(t.backoff(loginAttempt, now), time.Second) }
+	}
+	return nil
+}
+
+// RecordFailure completes a reserved attempt that failed. The failure was already
+// counted by Reserve, so it only locks the account if the username reached the lockout
+// threshold.
+func (t *LoginThrottle) RecordFailure(ctx context.Context, username string, ipAddress string, now time.Time) error {
+	// Required repositories and providers.
+	loginAttemptRepo := t.dao.LoginAttemptRepo()
+
+	// Only accounts are locked. An IP address is limited by the backoff alone, so that
+	// many users behind the same address are not locked out together.
+	if t.policy.LockoutThreshold <= 0 {
+		return nil
+	}
+	key := usernameThrottleKey(username)
+	loginAttempt, err := loginAttemptRepo.Find(ctx, key)
+	if err != nil {
+		return err
+	}
+	if loginAttempt.Failures >= t.policy.LockoutThreshold {
+		return loginAttemptRepo.Lock(ctx, key, now.Add(t.policy.LockoutDuration))
+	}
+	return nil
+}
+
+// Release completes a reserved attempt that did not fail (e.g., valid credentials), so
+// it is no longer counted as a failure.
+func (t *LoginThrottle) Release(ctx context.Context, username string, ipAddress string) error {
+	// Required repositories and providers.
+	loginAttemptRepo := t.dao.LoginAttemptRepo()
+
+	for _, key := range loginThrottleKeys(username, ipAddress) {
+		if err := loginAttemptRepo.Release(ctx, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RecordSuccess resets the failure counter of the username. The counter of the IP
+// address is kept, otherwise an attacker could reset it by logging in to an account of
+// their own between guesses.
+func (t *LoginThrottle) RecordSuccess(ctx context.Context, username string) error {
+	return t.dao.LoginAttemptRepo().Reset(ctx, usernameThrottleKey(username))
+}
+
+// backoff returns how long the key must wait before its next login attempt.
+func (t *LoginThrottle) backoff(loginAttempt *entities.LoginAttempt, now time.Time) time.Duration {
+	excess := loginAttempt.Failures - t.policy.FreeAttempts
+	if excess <= 0 || t.policy.BaseDelay <= 0 {
+		return 0
+	}
+	if loginAttempt.LastFailureAt.Before(t.windowStart(now)) {
+		return 0
+	}
+	delay := t.policy.BaseDelay
+	for i := 1; i < excess && i < 32 && (t.policy.MaxDelay <= 0 || delay < t.policy.MaxDelay); i++ {
+		delay *= 2
+	}
+	if t.policy.MaxDelay > 0 && delay > t.policy.MaxDelay {
+		delay = t.policy.MaxDelay
+	}
+	return max(loginAttempt.LastFailureAt.Add(delay).Sub(now), 0)
+}
+
+// windowStart returns the time before which failures are forgotten. A zero window keeps
+// them until a successful login.
+func (t *LoginThrottle) windowStart(now time.Time) time.Time {
+	if t.policy.FailureWindow <= 0 {
+		return time.Time{}
+	}
+	return now.Add(-t.policy.FailureWindow)
+}
+
+func usernameThrottleKey(username string) string {
+	return "username:" + username
+}
+
+func loginThrottleKeys(username string, ipAddress string) []string {
+	keys := []string{ usernameThrottleKey(username) }
+	if ipAddress != "" {
+		keys = append(keys, "ip:" + ipAddress)
+	}
+	return keys
+}
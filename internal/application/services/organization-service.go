@@ -0,0 +1,177 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gabriel-98/bingo-backend/internal/application/dto"
+	"github.com/gabriel-98/bingo-backend/internal/application/types"
+	"github.com/gabriel-98/bingo-backend/internal/domain"
+	"github.com/gabriel-98/bingo-backend/internal/domain/entities"
+	domports "github.com/gabriel-98/bingo-backend/internal/domain/ports"
+	"strings"
+)
+
+type OrganizationService struct {
+	dao *domain.DAO
+}
+
+func NewOrganizationService(dao *domain.DAO) *OrganizationService {
+	return &OrganizationService{
+		dao: dao,
+	}
+}
+
+// CreateOrganization creates an organization, whose first owner is the user.
+func (s *OrganizationService) CreateOrganization(ctx context.Context, userAuthData types.UserAuthData, createOrganizationRequest dto.CreateOrganizationRequest) (*dto.OrganizationResponse, error) {
+	// Required repositories and providers.
+	organizationRepo := s.dao.OrganizationRepo()
+	organizationMemberRepo := s.dao.OrganizationMemberRepo()
+
+	// Validate the name is valid and not used by another organization.
+	name := strings.TrimSpace(createOrganizationRequest.Name)
+	if name == "" {
+		return nil, fmt.Errorf("invalid organization name: name is empty")
+	}
+	_, err := organizationRepo.FindByName(ctx, name)
+	if err == nil {
+		return nil, types.ErrOrganizationNameTaken
+	}
+	if !errors.Is(err, domports.ErrNotFound) {
+		return nil, err
+	}
+
+	// Create the organization and make the user its owner.
+	organization, err := organizationRepo.Create(ctx, entities.Organization{ Name: name })
+	if err != nil {
+		return nil, err
+	}
+	organizationMember := entities.OrganizationMember{
+		OrganizationId: organization.Id,
+		UserId: userAuthData.UserId,
+		Role: string(types.OrganizationOwner),
+	}
+	if _, err := organizationMemberRepo.Save(ctx, organizationMember); err != nil {
+		return nil, err
+	}
+	return &dto.OrganizationResponse{ Id: organization.Id, Name: organization.Name, Role: organizationMember.Role }, nil
+}
+
+// ListOrganizations returns the organizations the user is a member of, with its role in
+// each of them.
+func (s *OrganizationService) ListOrganizations(ctx context.Context, userAuthData types.UserAuthData) ([]dto.OrganizationResponse, error) {
+	// Required repositories and providers.
+	organizationRepo := s.dao.OrganizationRepo()
+	organizationMemberRepo := s.dao.OrganizationMemberRepo()
+
+	organizationMembers, err := organizationMemberRepo.FindByUserId(ctx, userAuthData.UserId)
+	if err != nil {
+		return nil, err
+	}
+	organizations := make([]dto.OrganizationResponse, 0, len(organizationMembers))
+	for _, organizationMember := range organizationMembers {
+		organization, err := organizationRepo.FindById(ctx, organizationMember.OrganizationId)
+		if err != nil {
+			return nil, err
+		}
+		organizations = append(organizations, dto.OrganizationResponse{
+			Id: organization.Id,
+			Name: organization.Name,
+			Role: organizationMember.Role,
+		})
+	}
+	return organizations, nil
+}
+
+// SetMember adds a user to an organization with the role, or changes its role if it is
+// already a member. Only owners can manage the members.
+func (s *OrganizationService) SetMember(
+		ctx context.Context,
+		userAuthData types.UserAuthData,
+		organizationId int64,
+		userId int64,
+		organizationMemberRequest dto.OrganizationMemberRequest,
+		) (*dto.OrganizationMemberResponse, error) {
+	// Required repositories and providers.
+	userRepo := s.dao.UserRepo()
+	organizationMemberRepo := s.dao.OrganizationMemberRepo()
+
+	// Validate the user is an owner, and the new member and its role exist.
+	if err := requireOrganizationOwner(ctx, s.dao, organizationId, userAuthData.UserId); err != nil {
+		return nil, err
+	}
+	role, err := types.ParseOrganizationRole(organizationMemberRequest.Role)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := userRepo.FindById(ctx, userId); err != nil {
+		return nil, err
+	}
+	if role != types.OrganizationOwner {
+		if err := s.requireAnotherOwner(ctx, organizationId, userId); err != nil {
+			return nil, err
+		}
+	}
+
+	// Save the membership.
+	organizationMember, err := organizationMemberRepo.Save(ctx, entities.OrganizationMember{
+		OrganizationId: organizationId,
+		UserId: userId,
+		Role: string(role),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &dto.OrganizationMemberResponse{
+		OrganizationId: organizationMember.OrganizationId,
+		UserId: organizationMember.UserId,
+		Role: organizationMember.Role,
+	}, nil
+}
+
+// RemoveMember removes a user from an organization. Only owners can manage the members.
+// The API keys created by the user for the organization stop working, since they act on
+// behalf of an owner.
+func (s *OrganizationService) RemoveMember(ctx context.Context, userAuthData types.UserAuthData, organizationId int64, userId int64) error {
+	// Required repositories and providers.
+	organizationMemberRepo := s.dao.OrganizationMemberRepo()
+
+	if err := requireOrganizationOwner(ctx, s.dao, organizationId, userAuthData.UserId); err != nil {
+		return err
+	}
+	if err := s.requireAnotherOwner(ctx, organizationId, userId); err != nil {
+		return err
+	}
+	return organizationMemberRepo.Delete(ctx, organizationId, userId)
+}
+
+// requireAnotherOwner returns types.ErrLastOrganizationOwner if the user is the only
+// owner of the organization, which therefore cannot lose it.
+func (s *OrganizationService) requireAnotherOwner(ctx context.Context, organizationId int64, userId int64) error {
+	organizationMembers, err := s.dao.OrganizationMemberRepo().FindByOrganizationId(ctx, organizationId)
+	if err != nil {
+		return err
+	}
+	for _, organizationMember := range organizationMembers {
+		if organizationMember.UserId != userId && organizationMember.Role == string(types.OrganizationOwner) {
+			return nil
+		}
+	}
+	return types.ErrLastOrganizationOwner
+}
+
+// requireOrganizationOwner returns types.ErrNotOrganizationOwner unless the user is an
+// owner of the organization.
+func requireOrganizationOwner(ctx context.Context, dao *domain.DAO, organizationId int64, userId int64) error {
+	organizationMember, err := dao.OrganizationMemberRepo().Find(ctx, organizationId, userId)
+	if errors.Is(err, domports.ErrNotFound) {
+		return types.ErrNotOrganizationOwner
+	}
+	if err != nil {
+		return err
+	}
+	if organizationMember.Role != string(types.OrganizationOwner) {
+		return types.ErrNotOrganizationOwner
+	}
+	return nil
+}
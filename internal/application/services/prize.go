@@ -0,0 +1,38 @@
+package services
+
+import (
+	"github.com/gabriel-98/bingo-backend/internal/application/types"
+	"github.com/gabriel-98/bingo-backend/internal/domain/entities"
+)
+
+// gamePrize returns the prize of a game with the given card sales. Fixed and percentage
+// prizes are reduced by the house cut; guaranteed prizes are the percentage of the sales
+// minus the house cut, but never less than the guaranteed amount.
+func gamePrize(game *entities.Game, sales int64) int64 {
+	var prize int64
+	switch types.PrizeType(game.PrizeType) {
+		case types.PrizePercentage, types.PrizeGuaranteed:
+			prize = max(sales, 0) * int64(game.PrizePercent) / 100
+		default:
+			prize = game.PrizeAmount
+	}
+	prize -= prize * int64(game.HouseCutPercent) / 100
+	if types.PrizeType(game.PrizeType) == types.PrizeGuaranteed {
+		prize = max(prize, game.PrizeAmount)
+	}
+	return prize
+}
+
+// splitPrize splits a prize evenly among n winners. The remainder of the division is
+// given, one credit each, to the first winners, so that the shares add up to the prize
+// and differ by one credit at most.
+func splitPrize(prize int64, n int) []int64 {
+	shares := make([]int64, n)
+	for i := range shares {
+		shares[i] = prize / int64(n)
+		if int64(i) < prize % int64(n) {
+			shares[i]++
+		}
+	}
+	return shares
+}
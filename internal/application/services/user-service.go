@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"github.com/gabriel-98/bingo-backend/internal/application/dto"
+	"github.com/gabriel-98/bingo-backend/internal/application/types"
+	"github.com/gabriel-98/bingo-backend/internal/domain"
+	"github.com/gabriel-98/bingo-backend/internal/domain/entities"
+	domports "github.com/gabriel-98/bingo-backend/internal/domain/ports"
+	"slices"
+)
+
+type UserService struct {
+	dao *domain.DAO
+}
+
+func NewUserService(dao *domain.DAO) *UserService {
+	return &UserService{
+		dao: dao,
+	}
+}
+
+func (s *UserService) GetRoles(ctx context.Context, userId int64) (*dto.UserRolesResponse, error) {
+	// Required repositories and providers.
+	userRepo := s.dao.UserRepo()
+	userRoleRepo := s.dao.UserRoleRepo()
+
+	// Validate the user exists and retrieve its roles.
+	if _, err := userRepo.FindById(ctx, userId); err != nil {
+		return nil, err
+	}
+	userRoles, err := userRoleRepo.FindByUserId(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	return userRolesResponse(userId, userRoles), nil
+}
+
+// SetRoles replaces the roles of a user. The changes take effect on the next refresh
+// of the user's tokens.
+func (s *UserService) SetRoles(ctx context.Context, userId int64, userRolesRequest dto.UserRolesRequest) (*dto.UserRolesResponse, error) {
+	// Required repositories and providers.
+	userRepo := s.dao.UserRepo()
+	userRoleRepo := s.dao.UserRoleRepo()
+
+	// Validate the roles, ignoring duplicates.
+	roles := []string{}
+	for _, roleName := range userRolesRequest.Roles {
+		role, err := types.ParseRole(roleName)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(roles, string(role)) {
+			roles = append(roles, string(role))
+		}
+	}
+
+	// Validate the user exists and replace its roles.
+	if _, err := userRepo.FindById(ctx, userId); err != nil {
+		return nil, err
+	}
+	userRoles, err := userRoleRepo.Replace(ctx, userId, roles)
+	if err != nil {
+		return nil, err
+	}
+	return userRolesResponse(userId, userRoles), nil
+}
+
+// BootstrapAdmins grants the admin role to the users with the given usernames, keeping
+// their other roles. It is used on startup to create the first admins. The usernames
+// that do not belong to any user are returned.
+func (s *UserService) BootstrapAdmins(ctx context.Context, usernames []string) ([]string, error) {
+	// Required repositories and providers.
+	userRepo := s.dao.UserRepo()
+	userRoleRepo := s.dao.UserRoleRepo()
+
+	missingUsernames := []string{}
+	for _, username := range usernames {
+		user, err := userRepo.FindByUsername(ctx, username)
+		if errors.Is(err, domports.ErrNotFound) {
+			missingUsernames = append(missingUsernames, username)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := userRoleRepo.Add(ctx, user.Id, string(types.RoleAdmin)); err != nil {
+			return nil, err
+		}
+	}
+	return missingUsernames, nil
+}
+
+func userRolesResponse(userId int64, userRoles []entities.UserRole) *dto.UserRolesResponse {
+	roles := make([]string, len(userRoles))
+	for i, userRole := range userRoles {
+		roles[i] = userRole.Role
+	}
+	return &dto.UserRolesResponse{ UserId: userId, Roles: roles }
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"github.com/gabriel-98/bingo-backend/internal/application/types"
+	"net/mail"
+	"strings"
+	"unicode"
+)
+
+// newRandomId returns a random 128-bit identifier encoded in hexadecimal.
+func newRandomId() (string, error) {
+	idBytes := make([]byte, 16)
+	if _, err := rand.Read(idBytes); err != nil {
+		return "", fmt.Errorf("failed to generate random id: %w", err)
+	}
+	return hex.EncodeToString(idBytes), nil
+}
+
+
+// newRandomToken returns a random 256-bit token encoded in unpadded base64url, so that it
+// can be sent in URLs.
+func newRandomToken() (string, error) {
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return "", fmt.Errorf("failed to generate random token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(tokenBytes), nil
+}
+
+// hashToken returns the SHA-256 digest of a token encoded in hexadecimal. Tokens are
+// stored and looked up by this digest, never in plaintext.
+func hashToken(token string) string {
+	digest := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(digest[:])
+}
+
+// normalizeRecoveryCode returns the canonical form of a recovery code, which is the one
+// hashed, so that the codes are accepted regardless of case, spaces and dashes.
+func normalizeRecoveryCode(recoveryCode string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || unicode.IsSpace(r) {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, recoveryCode)
+}
+
+// normalizeEmail validates an email address and returns it in lower case. Only bare
+// addresses (e.g., "jdoe@example.com") are accepted.
+func normalizeEmail(email string) (string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email {
+		return "", fmt.Errorf("invalid email: %q", email)
+	}
+	return email, nil
+}
+
+// deviceName returns the friendly name of a session's device: the name chosen by the
+// user, or otherwise a description of the browser and operating system guessed from
+// the user agent (e.g., "Chrome on Windows").
+func deviceName(chosenName string, userAgent string) string {
+	if name := strings.TrimSpace(chosenName); name != "" {
+		return name
+	}
+
+	// The order matters: e.g., Edge user agents also mention Chrome and Safari.
+	browsers := []struct{ token, name string }{
+		{ "Edg/", "Edge" },
+		{ "OPR/", "Opera" },
+		{ "Firefox/", "Firefox" },
+		{ "Chrome/", "Chrome" },
+		{ "Safari/", "Safari" },
+	}
+	systems := []struct{ token, name string }{
+		{ "Android", "Android" },
+		{ "iPhone", "iOS" },
+		{ "iPad", "iPadOS" },
+		{ "Windows", "Windows" },
+		{ "Mac OS X", "macOS" },
+		{ "Linux", "Linux" },
+	}
+	browser, system := "", ""
+	for _, candidate := range browsers {
+		if strings.Contains(userAgent, candidate.token) {
+			browser = candidate.name
+			break
+		}
+	}
+	for _, candidate := range systems {
+		if strings.Contains(userAgent, candidate.token) {
+			system = candidate.name
+			break
+		}
+	}
+	switch {
+		case browser != "" && system != "":
+			return browser + " on " + system
+		case browser != "":
+			return browser
+		case system != "":
+			return system
+		default:
+			return "Unknown device"
+	}
+}
+
+// pkceCodeChallenge returns the S256 code challenge of a PKCE code verifier (RFC 7636).
+func pkceCodeChallenge(codeVerifier string) string {
+	digest := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(digest[:])
+}
+
+// oidcUsername returns a username derived from the profile of an external identity: its
+// preferred username, name or the local part of its email, keeping only letters,
+// digits, dots, dashes and underscores.
+func oidcUsername(identity *types.OidcIdentity) string {
+	candidates := []string{ identity.PreferredUsername, identity.Name, strings.Split(identity.Email, "@")[0] }
+	for _, candidate := range candidates {
+		username := strings.Map(func(r rune) rune {
+			switch {
+				case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '.' || r == '-' || r == '_':
+					return r
+				case unicode.IsSpace(r):
+					return '_'
+				default:
+					return -1
+			}
+		}, strings.TrimSpace(candidate))
+		if runes := []rune(username); len(runes) > 24 {
+			username = string(runes[:24])
+		}
+		if username != "" {
+			return username
+		}
+	}
+	return "player"
+}
@@ -0,0 +1,217 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gabriel-98/bingo-backend/internal/application/dto"
+	"github.com/gabriel-98/bingo-backend/internal/application/types"
+	"github.com/gabriel-98/bingo-backend/internal/domain"
+	"github.com/gabriel-98/bingo-backend/internal/domain/entities"
+	domports "github.com/gabriel-98/bingo-backend/internal/domain/ports"
+	"strconv"
+	"strings"
+)
+
+// walletEntriesLimit is the number of movements returned along with the balance.
+const walletEntriesLimit = 50
+
+type WalletService struct {
+	dao *domain.DAO
+	ledger *Ledger
+}
+
+func NewWalletService(dao *domain.DAO, ledger *Ledger) *WalletService {
+	return &WalletService{
+		dao: dao,
+		ledger: ledger,
+	}
+}
+
+// GetWallet returns the balance of the user's wallet and its last movements.
+func (s *WalletService) GetWallet(ctx context.Context, userAuthData types.UserAuthData) (*dto.WalletResponse, error) {
+	// Required repositories and providers.
+	ledgerAccountRepo := s.dao.LedgerAccountRepo()
+	ledgerTransactionRepo := s.dao.LedgerTransactionRepo()
+
+	// Users without a wallet have not received or spent anything yet.
+	walletResponse := &dto.WalletResponse{ Entries: []dto.WalletEntryResponse{} }
+	account, err := ledgerAccountRepo.FindByUserId(ctx, userAuthData.UserId)
+	if errors.Is(err, domports.ErrNotFound) {
+		return walletResponse, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Retrieve the balance and the last movements.
+	walletResponse.Balance, err = ledgerAccountRepo.Balance(ctx, account.Id)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := ledgerTransactionRepo.FindEntriesByAccountId(ctx, account.Id, walletEntriesLimit)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		walletResponse.Entries = append(walletResponse.Entries, dto.WalletEntryResponse{
+			TransactionId: entry.TransactionId,
+			Type: entry.Transaction.Type,
+			Amount: entry.Amount,
+			Reference: entry.Transaction.Reference,
+			Description: entry.Transaction.Description,
+			CreatedAt: entry.CreatedAt,
+		})
+	}
+	return walletResponse, nil
+}
+
+// AdjustBalance credits or debits the wallet of a user by an administrator (e.g., a
+// deposit or a correction). The adjustment is recorded once per idempotency key. Debits
+// cannot leave the wallet with a negative balance.
+func (s *WalletService) AdjustBalance(ctx context.Context, userAuthData types.UserAuthData, userId int64, walletAdjustmentRequest dto.WalletAdjustmentRequest) (*dto.LedgerTransactionResponse, error) {
+	// Required repositories and providers.
+	userRepo := s.dao.UserRepo()
+	transactionManager := s.dao.TransactionManager()
+	ledger := s.ledger
+
+	// Validate the request and the user.
+	if walletAdjustmentRequest.Amount == 0 {
+		return nil, fmt.Errorf("invalid adjustment: amount is zero")
+	}
+	reason := strings.TrimSpace(walletAdjustmentRequest.Reason)
+	if reason == "" {
+		return nil, fmt.Errorf("invalid adjustment: reason is empty")
+	}
+	if walletAdjustmentRequest.IdempotencyKey == "" {
+		return nil, fmt.Errorf("invalid adjustment: missing idempotency key")
+	}
+	if _, err := userRepo.FindById(ctx, userId); err != nil {
+		return nil, err
+	}
+
+	// Record the adjustment against the adjustments account.
+	var ledgerTransaction *entities.LedgerTransaction
+	var replayed bool
+	err := transactionManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		wallet, err := ledger.UserAccount(ctx, userId)
+		if err != nil {
+			return err
+		}
+		adjustments, err := ledger.SystemAccount(ctx, types.LedgerAdjustmentsAccount)
+		if err != nil {
+			return err
+		}
+		ledgerTransaction, replayed, err = ledger.Post(ctx, LedgerPosting{
+			Type: types.LedgerAdjustment,
+			IdempotencyKey: "adjustment:" + walletAdjustmentRequest.IdempotencyKey,
+			Reference: "user:" + strconv.FormatInt(userId, 10),
+			Description: reason,
+			CreatedBy: &userAuthData.UserId,
+			Entries: []LedgerPostingEntry{
+				{ AccountId: wallet.Id, Amount: walletAdjustmentRequest.Amount },
+				{ AccountId: adjustments.Id, Amount: -walletAdjustmentRequest.Amount },
+			},
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ledgerTransactionResponse(ledgerTransaction, replayed), nil
+}
+
+// RefundTransaction reverses a card purchase: every entry is recorded again with the
+// opposite amount, and the purchased cards are deleted. A purchase is refunded at most
+// once, and only while its game has not started, or after it is cancelled.
+func (s *WalletService) RefundTransaction(ctx context.Context, userAuthData types.UserAuthData, transactionId int64) (*dto.LedgerTransactionResponse, error) {
+	// Required repositories and providers.
+	ledgerTransactionRepo := s.dao.LedgerTransactionRepo()
+	gameRepo := s.dao.GameRepo()
+	gameCardRepo := s.dao.GameCardRepo()
+	transactionManager := s.dao.TransactionManager()
+
+	// Validate the transaction is a card purchase.
+	originalTransaction, err := ledgerTransactionRepo.FindById(ctx, transactionId)
+	if err != nil {
+		return nil, err
+	}
+	if originalTransaction.Type != string(types.LedgerCardPurchase) {
+		return nil, types.ErrNotRefundable
+	}
+	gameId, err := gameIdFromReference(originalTransaction.Reference)
+	if err != nil {
+		return nil, err
+	}
+
+	var ledgerTransaction *entities.LedgerTransaction
+	var replayed bool
+	err = transactionManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		// Lock the game, so that it does not start while the purchase is refunded, and
+		// validate the cards are not in play. A refund already recorded is replayed.
+		game, err := gameRepo.LockById(ctx, gameId)
+		if err != nil {
+			return err
+		}
+		switch types.GameStatus(game.Status) {
+			case types.GameScheduled, types.GameBuying, types.GameCancelled:
+			default:
+				_, err := ledgerTransactionRepo.FindByIdempotencyKey(ctx, refundIdempotencyKey(originalTransaction.Id))
+				if errors.Is(err, domports.ErrNotFound) {
+					return types.ErrInvalidGameStatus
+				}
+				if err != nil {
+					return err
+				}
+		}
+
+		// Record the reversal, and delete the purchased cards.
+		ledgerTransaction, replayed, err = s.refund(ctx, originalTransaction, &userAuthData.UserId)
+		if err != nil {
+			return err
+		}
+		if replayed {
+			return nil
+		}
+		return gameCardRepo.DeleteByTransactionId(ctx, originalTransaction.Id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ledgerTransactionResponse(ledgerTransaction, replayed), nil
+}
+
+// refund records the reversal of a transaction. Its idempotency key is derived from the
+// transaction, so that it is reversed at most once. It must run inside a transaction.
+func (s *WalletService) refund(ctx context.Context, originalTransaction *entities.LedgerTransaction, createdBy *int64) (*entities.LedgerTransaction, bool, error) {
+	entries, err := s.dao.LedgerTransactionRepo().FindEntries(ctx, originalTransaction.Id)
+	if err != nil {
+		return nil, false, err
+	}
+	posting := LedgerPosting{
+		Type: types.LedgerRefund,
+		IdempotencyKey: refundIdempotencyKey(originalTransaction.Id),
+		Reference: originalTransaction.Reference,
+		Description: "Refund of transaction " + strconv.FormatInt(originalTransaction.Id, 10),
+		CreatedBy: createdBy,
+	}
+	for _, entry := range entries {
+		posting.Entries = append(posting.Entries, LedgerPostingEntry{ AccountId: entry.AccountId, Amount: -entry.Amount })
+	}
+	return s.ledger.Post(ctx, posting)
+}
+
+func refundIdempotencyKey(transactionId int64) string {
+	return "refund:" + strconv.FormatInt(transactionId, 10)
+}
+
+func ledgerTransactionResponse(ledgerTransaction *entities.LedgerTransaction, replayed bool) *dto.LedgerTransactionResponse {
+	return &dto.LedgerTransactionResponse{
+		Id: ledgerTransaction.Id,
+		Type: ledgerTransaction.Type,
+		Reference: ledgerTransaction.Reference,
+		Description: ledgerTransaction.Description,
+		CreatedAt: ledgerTransaction.CreatedAt,
+		Replayed: replayed,
+	}
+}
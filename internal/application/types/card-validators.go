@@ -82,6 +82,20 @@ func (v *ColCardValidator) Validate(drawnNumbers *DrawnNumbers, card *Card) bool
 	return false
 }
 
+// LineCardValidator provides the validation that all cells in any row, column or
+// diagonal are marked and their numbers have already been drawn.
+type LineCardValidator struct {}
+
+func NewLineCardValidator() *LineCardValidator {
+	return &LineCardValidator{}
+}
+
+func (v *LineCardValidator) Validate(drawnNumbers *DrawnNumbers, card *Card) bool {
+	return NewRowCardValidator().Validate(drawnNumbers, card) ||
+		NewColCardValidator().Validate(drawnNumbers, card) ||
+		NewDiagonalCardValidator().Validate(drawnNumbers, card)
+}
+
 // DiagonalCardValidator provides the validation that all cells in either of the two
 // diagonals are marked and their numbers have already been drawn.
 type DiagonalCardValidator struct {}
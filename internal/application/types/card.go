@@ -1,5 +1,12 @@
 package types
 
+import (
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+)
+
 // A Card represents a card bingo, and has the following restrictions: 
 // - Consist of 5 rows x 5 columns (25 cells).
 // - All numbers in the card are different.
@@ -12,16 +19,18 @@ type Card struct {
 	counter int
 }
 
-// NewRandomCard creates a new Card.
+// NewRandomCard creates a new Card, whose numbers are chosen at random.
 func NewRandomCard() *Card {
 	var cells [5][5]int
 	var marked [5][5]bool
-	for i:=0; i<5; i++ {
-		for j:=0; j<5; j++ {
-			cells[i][j] = 0
-			marked[i][j] = false
+	for j:=0; j<5; j++ {
+		// Choose 5 different numbers of the range of the column.
+		numbers := rand.Perm(15)
+		for i:=0; i<5; i++ {
+			cells[i][j] = 15*j + numbers[i] + 1
 		}
 	}
+	cells[2][2] = 0
 	return &Card{
 		cells: cells,
 		marked: marked,
@@ -29,6 +38,65 @@ func NewRandomCard() *Card {
 	}
 }
 
+// NewCard creates a Card with the given cells, returning a non-nil error if they do not
+// meet the restrictions of a card. The central cell must be 0.
+func NewCard(cells [5][5]int) (*Card, error) {
+	seen := map[int]bool{}
+	for i:=0; i<5; i++ {
+		for j:=0; j<5; j++ {
+			value := cells[i][j]
+			if i == 2 && j == 2 {
+				if value != 0 {
+					return nil, fmt.Errorf("invalid card: the central cell is not empty")
+				}
+				continue
+			}
+			if value < 15*j+1 || value > 15*(j+1) || seen[value] {
+				return nil, fmt.Errorf("invalid card: invalid number %d in column %d", value, j+1)
+			}
+			seen[value] = true
+		}
+	}
+	return &Card{
+		cells: cells,
+	}, nil
+}
+
+// ParseCard converts the string representation of a card (see Card.String) to a Card,
+// returning a non-nil error if it is not a valid card.
+func ParseCard(s string) (*Card, error) {
+	values := strings.Split(s, ",")
+	if len(values) != 25 {
+		return nil, fmt.Errorf("invalid card: %d cells", len(values))
+	}
+	var cells [5][5]int
+	for k, value := range values {
+		number, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid card: %w", err)
+		}
+		cells[k/5][k%5] = number
+	}
+	return NewCard(cells)
+}
+
+// String returns the numbers of the card, row by row, separated by commas (the central
+// cell is 0). Two cards have the same numbers if and only if their strings are equal.
+func (card *Card) String() string {
+	values := make([]string, 0, 25)
+	for i:=0; i<5; i++ {
+		for j:=0; j<5; j++ {
+			values = append(values, strconv.Itoa(card.cells[i][j]))
+		}
+	}
+	return strings.Join(values, ",")
+}
+
+// Cells returns the numbers of the card (the central cell is 0).
+func (card *Card) Cells() [5][5]int {
+	return card.cells
+}
+
 func (card *Card) isValidCell(r int, c int) bool {
 	return (1 <= r && r <= 5) && (1 <= c && c <= 5) && !(r == 3 && c == 3)
 }
@@ -59,4 +127,15 @@ func (card *Card) Unmark(r int, c int) {
 
 func (card *Card) IsMarked(r int, c int) bool {
 	return card.marked[r-1][c-1]
-}
\ No newline at end of file
+}
+
+// MarkDrawn marks every cell whose number has been drawn.
+func (card *Card) MarkDrawn(drawnNumbers *DrawnNumbers) {
+	for r:=1; r<=5; r++ {
+		for c:=1; c<=5; c++ {
+			if drawnNumbers.Contains(card.Value(r, c)) {
+				card.Mark(r, c)
+			}
+		}
+	}
+}
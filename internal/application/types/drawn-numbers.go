@@ -1,13 +1,99 @@
 package types
 
+import (
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+)
+
+// MaxBallNumber is the greatest number of a ball. Balls are numbered from 1.
+const MaxBallNumber = 75
+
+// DrawnNumbers contains the numbers drawn in a game, in the order they were drawn.
 type DrawnNumbers struct {
-	
+	numbers []int
+	drawn [MaxBallNumber+1]bool
 }
 
 func NewEmptyDrawnNumbers() *DrawnNumbers {
 	return &DrawnNumbers{}
 }
 
+// ParseDrawnNumbers converts the string representation of the drawn numbers (see
+// DrawnNumbers.String) to DrawnNumbers, returning a non-nil error if a number is invalid
+// or repeated.
+func ParseDrawnNumbers(s string) (*DrawnNumbers, error) {
+	dn := NewEmptyDrawnNumbers()
+	if s == "" {
+		return dn, nil
+	}
+	for _, value := range strings.Split(s, ",") {
+		number, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid drawn numbers: %w", err)
+		}
+		if err := dn.Add(number); err != nil {
+			return nil, err
+		}
+	}
+	return dn, nil
+}
+
+// Add adds a drawn number, returning a non-nil error if it is out of range or was
+// already drawn.
+func (dn *DrawnNumbers) Add(number int) error {
+	if number < 1 || number > MaxBallNumber {
+		return fmt.Errorf("invalid drawn number: %d", number)
+	}
+	if dn.drawn[number] {
+		return fmt.Errorf("number already drawn: %d", number)
+	}
+	dn.drawn[number] = true
+	dn.numbers = append(dn.numbers, number)
+	return nil
+}
+
+// DrawRandom draws a random number among those not drawn yet, and adds it. It returns
+// false if every number has been drawn.
+func (dn *DrawnNumbers) DrawRandom() (int, bool) {
+	remaining := MaxBallNumber - len(dn.numbers)
+	if remaining == 0 {
+		return 0, false
+	}
+	k := rand.IntN(remaining)
+	for number := 1; number <= MaxBallNumber; number++ {
+		if dn.drawn[number] {
+			continue
+		}
+		if k == 0 {
+			dn.Add(number)
+			return number, true
+		}
+		k--
+	}
+	return 0, false
+}
+
 func (dn *DrawnNumbers) Contains(number int) bool {
-	return true
-}
\ No newline at end of file
+	return 1 <= number && number <= MaxBallNumber && dn.drawn[number]
+}
+
+// Len returns the number of drawn numbers.
+func (dn *DrawnNumbers) Len() int {
+	return len(dn.numbers)
+}
+
+// Numbers returns the drawn numbers, in the order they were drawn.
+func (dn *DrawnNumbers) Numbers() []int {
+	return append([]int{}, dn.numbers...)
+}
+
+// String returns the drawn numbers, in the order they were drawn, separated by commas.
+func (dn *DrawnNumbers) String() string {
+	values := make([]string, len(dn.numbers))
+	for i, number := range dn.numbers {
+		values[i] = strconv.Itoa(number)
+	}
+	return strings.Join(values, ",")
+}
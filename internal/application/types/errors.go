@@ -0,0 +1,192 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidCredentials is returned when a login fails, either because the user does
+	// not exist or because the password is wrong. Both cases return the same error so that
+	// valid usernames cannot be discovered.
+	ErrInvalidCredentials = errors.New("invalid username or password")
+
+	// ErrReauthenticationRequired is returned when a sensitive change of an account
+	// without a password is requested from a session that did not start recently.
+	ErrReauthenticationRequired = errors.New("a recent login is required")
+
+	// ErrPasswordChangedConcurrently is returned when the password of a user is replaced
+	// while another change of the same password is in progress.
+	ErrPasswordChangedConcurrently = errors.New("the password was changed concurrently")
+
+	// ErrInvalidToken is returned when an authentication token is malformed, has an
+	// invalid signature, or does not contain the required claims.
+	ErrInvalidToken = errors.New("invalid token")
+
+	// ErrTokenExpired is returned when an authentication token has expired.
+	ErrTokenExpired = errors.New("expired token")
+
+	// ErrTokenRevoked is returned when an access token has been revoked before its
+	// expiration (e.g., on logout).
+	ErrTokenRevoked = errors.New("revoked token")
+
+	// ErrSessionNotFound is returned when a session does not exist or does not belong to
+	// the user.
+	ErrSessionNotFound = errors.New("session not found")
+
+	// ErrAccountLocked is returned when an account is temporarily locked after too many
+	// failed logins.
+	ErrAccountLocked = errors.New("account locked")
+
+	// ErrTooManyLoginAttempts is returned when a login is attempted before the backoff
+	// delay of the previous failed logins has elapsed.
+	ErrTooManyLoginAttempts = errors.New("too many login attempts")
+
+	// ErrInvalidMfaCode is returned when a TOTP or recovery code is wrong or was already
+	// used.
+	ErrInvalidMfaCode = errors.New("invalid two-factor authentication code")
+
+	// ErrMfaAlreadyEnabled is returned when enrolling a user whose two-factor
+	// authentication is already enabled.
+	ErrMfaAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+
+	// ErrMfaNotEnrolled is returned when confirming or using the two-factor
+	// authentication of a user that has not enrolled it.
+	ErrMfaNotEnrolled = errors.New("two-factor authentication is not enrolled")
+
+	// ErrUsernameTaken is returned when a username is already used by another user.
+	ErrUsernameTaken = errors.New("username already taken")
+
+	// ErrUsernameChangeTooSoon is returned when a user changes their username before the
+	// cooldown of the previous change has passed.
+	ErrUsernameChangeTooSoon = errors.New("username changed too recently")
+
+	// ErrEmailTaken is returned when an email is already used by another user.
+	ErrEmailTaken = errors.New("email already taken")
+
+	// ErrNoEmail is returned when an action requires an email and the user has none.
+	ErrNoEmail = errors.New("the account has no email")
+
+	// ErrEmailAlreadyVerified is returned when verifying an email that is already
+	// verified.
+	ErrEmailAlreadyVerified = errors.New("email already verified")
+
+	// ErrVerificationEmailTooSoon is returned when a new verification email is requested
+	// before the resend cooldown of the previous one has passed.
+	ErrVerificationEmailTooSoon = errors.New("verification email sent too recently")
+
+	// ErrUnknownOidcProvider is returned when an OpenID Connect provider is not
+	// configured.
+	ErrUnknownOidcProvider = errors.New("unknown OpenID Connect provider")
+
+	// ErrInvalidOidcState is returned when the state of an OpenID Connect callback does
+	// not match a pending login (e.g., it expired or was already used).
+	ErrInvalidOidcState = errors.New("invalid or expired OpenID Connect login state")
+
+	// ErrGuestLoginDisabled is returned when guest accounts are disabled.
+	ErrGuestLoginDisabled = errors.New("guest login is disabled")
+
+	// ErrNotGuest is returned when upgrading an account that is not a guest account.
+	ErrNotGuest = errors.New("the account is not a guest account")
+
+	// ErrApiKeyNotAllowed is returned when a request authenticated with an API key tries
+	// an action reserved to users' sessions (e.g., managing the account).
+	ErrApiKeyNotAllowed = errors.New("not allowed with an API key")
+
+	// ErrNotOrganizationOwner is returned when a user who is not an owner of an
+	// organization tries to manage it.
+	ErrNotOrganizationOwner = errors.New("not an owner of the organization")
+
+	// ErrOrganizationNameTaken is returned when the name of an organization is used by
+	// another organization.
+	ErrOrganizationNameTaken = errors.New("organization name already taken")
+
+	// ErrLastOrganizationOwner is returned when removing or demoting the last owner of
+	// an organization.
+	ErrLastOrganizationOwner = errors.New("an organization must keep at least one owner")
+
+	// ErrInsufficientFunds is returned when a wallet does not have enough balance for a
+	// debit.
+	ErrInsufficientFunds = errors.New("insufficient funds")
+
+	// ErrIdempotencyKeyReused is returned when an idempotency key is reused for a
+	// different operation.
+	ErrIdempotencyKeyReused = errors.New("idempotency key already used for a different operation")
+
+	// ErrNotRefundable is returned when refunding a ledger transaction that is not a
+	// card purchase.
+	ErrNotRefundable = errors.New("only card purchases can be refunded")
+
+	// ErrNotGameHost is returned when a user who is not the host of a game tries to run
+	// it.
+	ErrNotGameHost = errors.New("not the host of the game")
+
+	// ErrInvalidGameStatus is returned when an action is not allowed in the current
+	// status of a game (e.g., starting a finished game).
+	ErrInvalidGameStatus = errors.New("not allowed in the current status of the game")
+
+	// ErrPurchasesClosed is returned when buying cards of a game that is not selling
+	// them.
+	ErrPurchasesClosed = errors.New("the game is not selling cards")
+
+	// ErrCardLimitExceeded is returned when a purchase exceeds the cards limit of the
+	// player or of the game.
+	ErrCardLimitExceeded = errors.New("card limit exceeded")
+
+	// ErrPaidGameNotAllowed is returned when a user without the permission to join paid
+	// games buys cards of a paid game.
+	ErrPaidGameNotAllowed = errors.New("not allowed to join paid games")
+
+	// ErrNotGameTemplateHost is returned when a user who is not the host of a game
+	// template tries to change it.
+	ErrNotGameTemplateHost = errors.New("not the host of the game template")
+
+	// ErrNotJackpotHost is returned when a host uses a jackpot created by another host.
+	ErrNotJackpotHost = errors.New("not the host of the jackpot")
+
+	// ErrOidcLoginFailed is returned when an OpenID Connect provider denies a login or
+	// returns an invalid ID token.
+	ErrOidcLoginFailed = errors.New("OpenID Connect login failed")
+)
+
+// A PasswordPolicyViolation describes a single password policy rule that a password
+// does not satisfy.
+type PasswordPolicyViolation struct {
+	Rule string `json:"rule" example:"min_length"`
+	Message string `json:"message" example:"password must be at least 8 characters long"`
+}
+
+// A PasswordPolicyError is returned when a password does not satisfy the password
+// policy. It contains every violated rule, not just the first one.
+type PasswordPolicyError struct {
+	Violations []PasswordPolicyViolation
+}
+
+func (e *PasswordPolicyError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, violation := range e.Violations {
+		messages[i] = violation.Message
+	}
+	return fmt.Sprintf("invalid password: %s", strings.Join(messages, "; "))
+}
+
+// A LoginThrottledError is returned when a login is rejected by the brute-force
+// protection. It wraps ErrAccountLocked or ErrTooManyLoginAttempts, and tells when the
+// login can be retried.
+type LoginThrottledError struct {
+	Locked bool
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string {
+	return fmt.Sprintf("%s: retry after %s", e.Unwrap(), e.RetryAfter)
+}
+
+func (e *LoginThrottledError) Unwrap() error {
+	if e.Locked {
+		return ErrAccountLocked
+	}
+	return ErrTooManyLoginAttempts
+}
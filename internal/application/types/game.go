@@ -0,0 +1,101 @@
+package types
+
+import (
+	"fmt"
+)
+
+// A GameStatus is the stage of a game. Games go through the statuses in this order, and
+// can be cancelled before they finish.
+type GameStatus string
+
+const (
+	// GameScheduled games are created, but their cards are not on sale yet.
+	GameScheduled GameStatus = "scheduled"
+
+	// GameBuying games sell their cards.
+	GameBuying GameStatus = "buying"
+
+	// GamePlaying games are being played. Their cards are no longer on sale.
+	GamePlaying GameStatus = "playing"
+
+	GameFinished GameStatus = "finished"
+
+	// GameCancelled games were cancelled by their host or an admin before they finished.
+	// Their card purchases can be refunded.
+	GameCancelled GameStatus = "cancelled"
+)
+
+// Variant75Ball is the only supported variant of bingo: 75 balls and 5x5 cards.
+const Variant75Ball = "75-ball"
+
+// A GamePattern is the pattern a card must complete to win a game.
+type GamePattern string
+
+const (
+	PatternRow GamePattern = "row"
+	PatternColumn GamePattern = "column"
+	PatternDiagonal GamePattern = "diagonal"
+
+	// PatternLine is any row, column or diagonal.
+	PatternLine GamePattern = "line"
+
+	PatternCorners GamePattern = "corners"
+
+	// PatternFullCard is every cell of the card (a full house, or blackout).
+	PatternFullCard GamePattern = "full_card"
+)
+
+// ParseGamePattern converts a string to a GamePattern, returning a non-nil error if the
+// pattern does not exist.
+func ParseGamePattern(pattern string) (GamePattern, error) {
+	switch GamePattern(pattern) {
+		case PatternRow, PatternColumn, PatternDiagonal, PatternLine, PatternCorners, PatternFullCard:
+			return GamePattern(pattern), nil
+		default:
+			return "", fmt.Errorf("unknown game pattern: %q", pattern)
+	}
+}
+
+// Validator returns the validator of the cards that complete the pattern.
+func (pattern GamePattern) Validator() CardValidator {
+	switch pattern {
+		case PatternRow:
+			return NewRowCardValidator()
+		case PatternColumn:
+			return NewColCardValidator()
+		case PatternDiagonal:
+			return NewDiagonalCardValidator()
+		case PatternLine:
+			return NewLineCardValidator()
+		case PatternCorners:
+			return NewCornerCardValidator()
+		default:
+			return NewFullCardValidator()
+	}
+}
+
+// A PrizeType tells how the prize of a game is computed.
+type PrizeType string
+
+const (
+	// PrizeFixed is a fixed amount.
+	PrizeFixed PrizeType = "fixed"
+
+	// PrizePercentage is a percentage of the card sales.
+	PrizePercentage PrizeType = "percentage"
+
+	// PrizeGuaranteed is a percentage of the card sales, but not less than a fixed
+	// amount.
+	PrizeGuaranteed PrizeType = "guaranteed"
+)
+
+// ParsePrizeType converts a string to a PrizeType, returning a non-nil error if the type
+// does not exist.
+func ParsePrizeType(prizeType string) (PrizeType, error) {
+	switch PrizeType(prizeType) {
+		case PrizeFixed, PrizePercentage, PrizeGuaranteed:
+			return PrizeType(prizeType), nil
+		default:
+			return "", fmt.Errorf("unknown prize type: %q", prizeType)
+	}
+}
@@ -0,0 +1,15 @@
+package types
+
+// A JSONWebKey is the public part of a token signing key, as defined by RFC 7517.
+// Only the members used by RSA, EC and OKP (Ed25519) keys are included.
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Use string `json:"use,omitempty"`
+	Kid string `json:"kid,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X string `json:"x,omitempty"`
+	Y string `json:"y,omitempty"`
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+}
@@ -0,0 +1,28 @@
+package types
+
+// A LedgerTransactionType tells what a ledger transaction is for.
+type LedgerTransactionType string
+
+const (
+	LedgerCardPurchase LedgerTransactionType = "card_purchase"
+	LedgerPrizePayout LedgerTransactionType = "prize_payout"
+	LedgerRefund LedgerTransactionType = "refund"
+	LedgerAdjustment LedgerTransactionType = "adjustment"
+	LedgerJackpotContribution LedgerTransactionType = "jackpot_contribution"
+	LedgerJackpotPayout LedgerTransactionType = "jackpot_payout"
+)
+
+// Codes of the system ledger accounts, the counterparts of the users' wallets.
+const (
+	// LedgerHouseAccount receives the card sales and pays the prizes, the jackpot
+	// contributions and the refunds.
+	LedgerHouseAccount = "system:house"
+
+	// LedgerAdjustmentsAccount is the counterpart of the manual adjustments of the
+	// administrators.
+	LedgerAdjustmentsAccount = "system:adjustments"
+)
+
+// LedgerJackpotAccountPrefix is the prefix of the codes of the jackpots' accounts,
+// followed by the id of the jackpot.
+const LedgerJackpotAccountPrefix = "jackpot:"
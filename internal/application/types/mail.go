@@ -0,0 +1,8 @@
+package types
+
+// A MailMessage is a plain-text email message.
+type MailMessage struct {
+	To string
+	Subject string
+	Body string
+}
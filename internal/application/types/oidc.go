@@ -0,0 +1,16 @@
+package types
+
+// An OidcIdentity is the identity of a user at an external OpenID Connect provider, as
+// asserted by the ID token of a login. Subject identifies the user at the provider.
+type OidcIdentity struct {
+	Provider string
+	Subject string
+	Email string
+	EmailVerified bool
+	Name string
+	PreferredUsername string
+
+	// Nonce is the nonce claim of the ID token, which must match the one sent in the
+	// authorization request.
+	Nonce string
+}
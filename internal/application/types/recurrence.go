@@ -0,0 +1,153 @@
+package types
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A Recurrence is a cron-like recurrence rule with five fields separated by spaces: the
+// minute (0-59), the hour (0-23), the day of the month (1-31), the month (1-12) and the
+// day of the week (0-6, where 0 is Sunday). Each field is "*", a number, a range ("1-5"),
+// or a list of them ("1,3,5"), optionally followed by a step ("*/15", "8-18/2"; a single
+// value with a step runs to the maximum, so "5/15" is "5-59/15"). As in cron, if both the
+// day of the month and the day of the week are restricted (neither starts with "*", so
+// "*/2" is not a restriction), a day matches if either of them does.
+// E.g., "0 20 * * *" is every day at 20:00.
+type Recurrence struct {
+	expression string
+	minutes uint64
+	hours uint64
+	days uint64
+	months uint64
+	weekdays uint64
+	anyDay bool
+	anyWeekday bool
+}
+
+// ParseRecurrence converts a cron-like expression to a Recurrence, returning a non-nil
+// error if it is invalid.
+func ParseRecurrence(expression string) (*Recurrence, error) {
+	fields := strings.Fields(expression)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid recurrence %q: expected 5 fields", expression)
+	}
+	bounds := [5][2]int{ {0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 6} }
+	var sets [5]uint64
+	for i, field := range fields {
+		set, err := parseRecurrenceField(field, bounds[i][0], bounds[i][1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid recurrence %q: %w", expression, err)
+		}
+		sets[i] = set
+	}
+	return &Recurrence{
+		expression: strings.Join(fields, " "),
+		minutes: sets[0],
+		hours: sets[1],
+		days: sets[2],
+		months: sets[3],
+		weekdays: sets[4],
+		anyDay: strings.HasPrefix(fields[2], "*"),
+		anyWeekday: strings.HasPrefix(fields[4], "*"),
+	}, nil
+}
+
+// parseRecurrenceField returns the set of values (as bits) of a field of a recurrence.
+func parseRecurrenceField(field string, low int, high int) (uint64, error) {
+	var set uint64
+	for _, item := range strings.Split(field, ",") {
+		// Read the step.
+		step := 1
+		rangePart, stepPart, hasStep := strings.Cut(item, "/")
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepPart); err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step %q", stepPart)
+			}
+			item = rangePart
+		}
+
+		// Read the range.
+		first, last := low, high
+		if item != "*" {
+			firstPart, lastPart, isRange := strings.Cut(item, "-")
+			var err error
+			if first, err = strconv.Atoi(firstPart); err != nil {
+				return 0, fmt.Errorf("invalid value %q", firstPart)
+			}
+			last = first
+			if hasStep {
+				last = high
+			}
+			if isRange {
+				if last, err = strconv.Atoi(lastPart); err != nil {
+					return 0, fmt.Errorf("invalid value %q", lastPart)
+				}
+			}
+		}
+		if first < low || last > high || first > last {
+			return 0, fmt.Errorf("%q out of range [%d, %d]", item, low, high)
+		}
+		for value := first; value <= last; value += step {
+			set |= 1 << value
+		}
+	}
+	return set, nil
+}
+
+// Next returns the first time matched by the recurrence strictly after the given time,
+// in its location. It returns the zero time if nothing matches within five years (e.g.,
+// "0 0 31 2 *"). The recurrence follows the wall clock: times skipped by a daylight
+// saving time change are not matched, and times repeated by one are matched once.
+func (r *Recurrence) Next(after time.Time) time.Time {
+	location := after.Location()
+	year, month, day := after.Date()
+	t := time.Date(year, month, day, after.Hour(), after.Minute()+1, 0, 0, location)
+	limit := after.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		year, month, day := t.Date()
+		if r.months & (1 << int(month)) == 0 {
+			t = advance(t, time.Date(year, month+1, 1, 0, 0, 0, 0, location))
+			continue
+		}
+		if !r.matchesDay(t) {
+			t = advance(t, time.Date(year, month, day+1, 0, 0, 0, 0, location))
+			continue
+		}
+		if r.hours & (1 << t.Hour()) == 0 {
+			t = advance(t, time.Date(year, month, day, t.Hour()+1, 0, 0, 0, location))
+			continue
+		}
+		if r.minutes & (1 << t.Minute()) == 0 || !t.After(after) {
+			t = advance(t, time.Date(year, month, day, t.Hour(), t.Minute()+1, 0, 0, location))
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// advance returns next, the following wall clock time to check after t, or t plus a
+// minute if next is not after t: a wall clock time skipped by a daylight saving time
+// change may be normalized to a time before the change.
+func advance(t time.Time, next time.Time) time.Time {
+	if next.After(t) {
+		return next
+	}
+	return t.Add(time.Minute)
+}
+
+func (r *Recurrence) matchesDay(t time.Time) bool {
+	dayMatches := r.days & (1 << t.Day()) != 0
+	weekdayMatches := r.weekdays & (1 << int(t.Weekday())) != 0
+	if r.anyDay || r.anyWeekday {
+		return dayMatches && weekdayMatches
+	}
+	return dayMatches || weekdayMatches
+}
+
+func (r *Recurrence) String() string {
+	return r.expression
+}
@@ -0,0 +1,148 @@
+package types
+
+import (
+	"testing"
+	"time"
+)
+
+// TestParseRecurrence verifies which expressions are accepted, and that a single value
+// with a step runs to the maximum of its field, as in cron.
+func TestParseRecurrence(t *testing.T) {
+	invalidExpressions := []string{
+		"",
+		"0 20 * *",
+		"0 20 * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 7",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+	}
+	for _, expression := range invalidExpressions {
+		if _, err := ParseRecurrence(expression); err == nil {
+			t.Errorf("ParseRecurrence(%q): expected an error", expression)
+		}
+	}
+
+	recurrence, err := ParseRecurrence("5/15 * * * *")
+	if err != nil {
+		t.Fatalf("ParseRecurrence: %v", err)
+	}
+	expectedMinutes := []int{ 5, 20, 35, 50 }
+	var expectedSet uint64
+	for _, minute := range expectedMinutes {
+		expectedSet |= 1 << minute
+	}
+	if recurrence.minutes != expectedSet {
+		t.Errorf("\"5/15\": expected the minutes %v, got the set %b", expectedMinutes, recurrence.minutes)
+	}
+}
+
+// TestRecurrenceNext verifies the times matched by recurrences, including the cron rule
+// for restricted days of the month and of the week, and daylight saving time changes.
+func TestRecurrenceNext(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone database not available: %v", err)
+	}
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone database not available: %v", err)
+	}
+	tests := []struct {
+		name string
+		expression string
+		after time.Time
+		expected []time.Time
+	}{
+		{
+			name: "every day",
+			expression: "0 20 * * *",
+			after: time.Date(2026, 10, 1, 20, 0, 0, 0, time.UTC),
+			expected: []time.Time{
+				time.Date(2026, 10, 2, 20, 0, 0, 0, time.UTC),
+				time.Date(2026, 10, 3, 20, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "single value with a step",
+			expression: "40/15 8 * * *",
+			after: time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC),
+			expected: []time.Time{
+				time.Date(2026, 10, 1, 8, 40, 0, 0, time.UTC),
+				time.Date(2026, 10, 1, 8, 55, 0, 0, time.UTC),
+				time.Date(2026, 10, 2, 8, 40, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "day of the month or day of the week",
+			expression: "0 12 13 * 5",
+			after: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+			expected: []time.Time{
+				time.Date(2026, 10, 2, 12, 0, 0, 0, time.UTC),
+				time.Date(2026, 10, 9, 12, 0, 0, 0, time.UTC),
+				time.Date(2026, 10, 13, 12, 0, 0, 0, time.UTC),
+				time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "stepped wildcard day of the month and day of the week",
+			expression: "0 12 */2 * 1",
+			after: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+			expected: []time.Time{
+				time.Date(2026, 10, 5, 12, 0, 0, 0, time.UTC),
+				time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "time skipped by daylight saving time",
+			expression: "30 2 * * *",
+			after: time.Date(2026, 3, 7, 3, 0, 0, 0, newYork),
+			expected: []time.Time{
+				time.Date(2026, 3, 9, 2, 30, 0, 0, newYork),
+			},
+		},
+		{
+			name: "time repeated by daylight saving time",
+			expression: "30 1 * * *",
+			after: time.Date(2026, 11, 1, 0, 0, 0, 0, newYork),
+			expected: []time.Time{
+				time.Date(2026, 11, 1, 1, 30, 0, 0, newYork),
+				time.Date(2026, 11, 2, 1, 30, 0, 0, newYork),
+			},
+		},
+		{
+			name: "time repeated by daylight saving time, east of UTC",
+			expression: "30 2 * * *",
+			after: time.Date(2026, 10, 25, 0, 0, 0, 0, berlin),
+			expected: []time.Time{
+				time.Date(2026, 10, 25, 2, 30, 0, 0, berlin),
+				time.Date(2026, 10, 26, 2, 30, 0, 0, berlin),
+			},
+		},
+		{
+			name: "impossible date",
+			expression: "0 0 31 2 *",
+			after: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+			expected: []time.Time{ {} },
+		},
+	}
+	for _, test := range tests {
+		recurrence, err := ParseRecurrence(test.expression)
+		if err != nil {
+			t.Fatalf("%s: ParseRecurrence: %v", test.name, err)
+		}
+		after := test.after
+		for _, expected := range test.expected {
+			next := recurrence.Next(after)
+			if !next.Equal(expected) {
+				t.Errorf("%s: Next(%s): expected %s, got %s", test.name, after, expected, next)
+				break
+			}
+			after = next
+		}
+	}
+}
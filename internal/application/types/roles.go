@@ -0,0 +1,103 @@
+package types
+
+import (
+	"fmt"
+	"slices"
+)
+
+// A Role groups the permissions granted to a user. A user can have several roles.
+type Role string
+
+const (
+	// RolePlayer can join games and claim prizes.
+	RolePlayer Role = "player"
+
+	// RoleHost can create and run games, and manage organizations (e.g., bingo halls).
+	RoleHost Role = "host"
+
+	// RoleAdmin can manage users, organizations and wallets, and see reports.
+	RoleAdmin Role = "admin"
+
+	// RoleGuest can join free games only. It is the role of guest accounts.
+	RoleGuest Role = "guest"
+)
+
+// A Permission allows performing an action, and is granted through roles.
+type Permission string
+
+const (
+	PermissionCreateGames Permission = "games:create"
+	PermissionRunGames Permission = "games:run"
+	PermissionManageGames Permission = "games:manage"
+	PermissionJoinGames Permission = "games:join"
+	PermissionJoinPaidGames Permission = "games:join_paid"
+	PermissionClaimPrizes Permission = "games:claim"
+	PermissionManageUsers Permission = "users:manage"
+	PermissionViewReports Permission = "reports:view"
+	PermissionManageOrganizations Permission = "organizations:manage"
+	PermissionManageWallets Permission = "wallets:manage"
+)
+
+// rolePermissions contains the permissions granted by each role.
+var rolePermissions = map[Role][]Permission{
+	RolePlayer: { PermissionJoinGames, PermissionJoinPaidGames, PermissionClaimPrizes },
+	RoleHost: { PermissionCreateGames, PermissionRunGames, PermissionManageOrganizations },
+	RoleAdmin: { PermissionManageUsers, PermissionViewReports, PermissionManageOrganizations, PermissionManageWallets, PermissionManageGames },
+	RoleGuest: { PermissionJoinGames },
+}
+
+// ParseRole converts a string to a Role, returning a non-nil error if the role does not
+// exist.
+func ParseRole(role string) (Role, error) {
+	if _, found := rolePermissions[Role(role)]; !found {
+		return "", fmt.Errorf("unknown role: %q", role)
+	}
+	return Role(role), nil
+}
+
+// ParsePermission converts a string to a Permission, returning a non-nil error if no
+// role grants the permission.
+func ParsePermission(permission string) (Permission, error) {
+	for _, permissions := range rolePermissions {
+		if slices.Contains(permissions, Permission(permission)) {
+			return Permission(permission), nil
+		}
+	}
+	return "", fmt.Errorf("unknown permission: %q", permission)
+}
+
+// PermissionsOf returns the sorted set of permissions granted by the roles.
+func PermissionsOf(roles []Role) []Permission {
+	permissions := []Permission{}
+	for _, role := range roles {
+		for _, permission := range rolePermissions[role] {
+			if !slices.Contains(permissions, permission) {
+				permissions = append(permissions, permission)
+			}
+		}
+	}
+	slices.Sort(permissions)
+	return permissions
+}
+
+// An OrganizationRole is the role of a member of an organization.
+type OrganizationRole string
+
+const (
+	// OrganizationOwner manages the members and the API keys of the organization.
+	OrganizationOwner OrganizationRole = "owner"
+
+	// OrganizationMember belongs to the organization, without managing it.
+	OrganizationMember OrganizationRole = "member"
+)
+
+// ParseOrganizationRole converts a string to an OrganizationRole, returning a non-nil
+// error if the role does not exist.
+func ParseOrganizationRole(role string) (OrganizationRole, error) {
+	switch OrganizationRole(role) {
+		case OrganizationOwner, OrganizationMember:
+			return OrganizationRole(role), nil
+		default:
+			return "", fmt.Errorf("unknown organization role: %q", role)
+	}
+}
@@ -1,5 +1,32 @@
 package types
 
+import (
+	"slices"
+)
+
+// UserAuthData contains the authentication data of a user, which is embedded in the
+// authentication tokens.
 type UserAuthData struct {
 	UserId int64
-}
\ No newline at end of file
+	Roles []Role
+	Permissions []Permission
+
+	// SessionId identifies the session (refresh token family) the token belongs to.
+	SessionId string
+
+	// ApiKeyId identifies the API key the request was authenticated with, and is 0 for
+	// access tokens. Permissions are then restricted to the Scopes of the key.
+	// OrganizationId is set if the key belongs to an organization; the user is the one
+	// who created the key. These fields are never embedded in the tokens.
+	ApiKeyId int64 `json:"-"`
+	OrganizationId *int64 `json:"-"`
+	Scopes []Permission `json:"-"`
+}
+
+// ApiKeyPrefix is the prefix of the API keys, which tells them apart from access tokens.
+const ApiKeyPrefix = "bgk_"
+
+// HasPermission reports whether the user has been granted the permission.
+func (d *UserAuthData) HasPermission(permission Permission) bool {
+	return slices.Contains(d.Permissions, permission)
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"fmt"
 	"gopkg.in/yaml.v3"
 	"os"
 	"time"
@@ -11,6 +12,10 @@ type Config struct {
 	Database DatabaseConfig `yaml:"database"`
 	Auth AuthConfig `yaml:"auth"`
 	PasswordHashing PasswordHashingConfig `yaml:"passwordHashing"`
+	Account AccountConfig `yaml:"account"`
+	Games GamesConfig `yaml:"games"`
+	Mail MailConfig `yaml:"mail"`
+	Jobs JobsConfig `yaml:"jobs"`
 }
 
 func LoadConfig(filepath string) (*Config, error) {
@@ -23,11 +28,18 @@ func LoadConfig(filepath string) (*Config, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := config.Database.validate(); err != nil {
+		return nil, err
+	}
+	if err := config.Jobs.validate(); err != nil {
+		return nil, err
+	}
 	return config, nil
 }
 
 type ServerConfig struct {
 	Port int `yaml:"port"`
+	TrustedProxies []string `yaml:"trustedProxies"`
 }
 
 type DatabaseConfig struct {
@@ -41,22 +53,213 @@ type DatabaseConfig struct {
 	TimeZone string `yaml:"timeZone"`
 }
 
+// validate validates the database settings: the time zone is also the one of the game
+// schedules, so it must be a known IANA time zone.
+func (config DatabaseConfig) validate() error {
+	if _, err := time.LoadLocation(config.TimeZone); err != nil {
+		return fmt.Errorf("invalid database config: unknown timeZone %q: %w", config.TimeZone, err)
+	}
+	return nil
+}
+
 type AuthConfig struct {
 	RefreshToken JwtTokenConfig `yaml:"refreshToken"`
 	AccessToken JwtTokenConfig `yaml:"accessToken"`
+	MfaToken JwtTokenConfig `yaml:"mfaToken"`
+	Issuer string `yaml:"issuer"`
+	ClockSkew time.Duration `yaml:"clockSkew"`
+	BootstrapAdmins []string `yaml:"bootstrapAdmins"`
+	RevocationStore string `yaml:"revocationStore"`
+	LoginThrottle LoginThrottleConfig `yaml:"loginThrottle"`
+	Totp TotpConfig `yaml:"totp"`
+	PasswordReset PasswordResetConfig `yaml:"passwordReset"`
+	EmailVerification EmailVerificationConfig `yaml:"emailVerification"`
+	Oidc OidcConfig `yaml:"oidc"`
+	Guest GuestConfig `yaml:"guest"`
+}
+
+type GuestConfig struct {
+	Enabled bool `yaml:"enabled"`
+	InactivityTimeout time.Duration `yaml:"inactivityTimeout"`
+}
+
+type OidcConfig struct {
+	AuthRequestDuration time.Duration `yaml:"authRequestDuration"`
+	Providers []OidcProviderConfig `yaml:"providers"`
+}
+
+type OidcProviderConfig struct {
+	Name string `yaml:"name"`
 	Issuer string `yaml:"issuer"`
+	ClientId string `yaml:"clientId"`
+	ClientSecret string `yaml:"clientSecret"`
+	RedirectUrl string `yaml:"redirectUrl"`
+	Scopes []string `yaml:"scopes"`
+}
+
+type EmailVerificationConfig struct {
+	TokenDuration time.Duration `yaml:"tokenDuration"`
+	Url string `yaml:"url"`
+	ResendCooldown time.Duration `yaml:"resendCooldown"`
+	RequiredForPaidGames bool `yaml:"requiredForPaidGames"`
+}
+
+type PasswordResetConfig struct {
+	TokenDuration time.Duration `yaml:"tokenDuration"`
+	Url string `yaml:"url"`
+	ResendCooldown time.Duration `yaml:"resendCooldown"`
+}
+
+type TotpConfig struct {
+	Issuer string `yaml:"issuer"`
+	Digits int `yaml:"digits"`
+	Period time.Duration `yaml:"period"`
+	Skew int `yaml:"skew"`
+	RecoveryCodes int `yaml:"recoveryCodes"`
+}
+
+type LoginThrottleConfig struct {
+	Store string `yaml:"store"`
+	FreeAttempts int `yaml:"freeAttempts"`
+	BaseDelay time.Duration `yaml:"baseDelay"`
+	MaxDelay time.Duration `yaml:"maxDelay"`
+	LockoutThreshold int `yaml:"lockoutThreshold"`
+	LockoutDuration time.Duration `yaml:"lockoutDuration"`
+	FailureWindow time.Duration `yaml:"failureWindow"`
 }
 
 type JwtTokenConfig struct {
 	Duration time.Duration `yaml:"duration"`
+	Audience string `yaml:"audience"`
+	SigningMethod string `yaml:"signingMethod"`
 	SigningKey string `yaml:"signingKey"`
+	KeyId string `yaml:"keyId"`
+	PrivateKeyFile string `yaml:"privateKeyFile"`
+	VerificationKeys []JwtVerificationKeyConfig `yaml:"verificationKeys"`
+}
+
+type JwtVerificationKeyConfig struct {
+	KeyId string `yaml:"keyId"`
+	SigningMethod string `yaml:"signingMethod"`
+	PublicKeyFile string `yaml:"publicKeyFile"`
 }
 
 type PasswordHashingConfig struct {
 	Algorithm string `yaml:"algorithm"`
 	Bcrypt BcryptConfig `yaml:"bcrypt"`
+	Argon2id Argon2idConfig `yaml:"argon2id"`
+	Policy PasswordPolicyConfig `yaml:"policy"`
 }
 
 type BcryptConfig struct {
 	Cost int `yaml:"cost"`
-}
\ No newline at end of file
+}
+
+type Argon2idConfig struct {
+	Memory uint32 `yaml:"memory"`
+	Iterations uint32 `yaml:"iterations"`
+	Parallelism uint8 `yaml:"parallelism"`
+	SaltLength uint32 `yaml:"saltLength"`
+	KeyLength uint32 `yaml:"keyLength"`
+}
+
+type PasswordPolicyConfig struct {
+	MinLength int `yaml:"minLength"`
+	RequireUppercase bool `yaml:"requireUppercase"`
+	RequireLowercase bool `yaml:"requireLowercase"`
+	RequireDigit bool `yaml:"requireDigit"`
+	RequireSymbol bool `yaml:"requireSymbol"`
+	DisallowUsername bool `yaml:"disallowUsername"`
+	CommonPasswordsBlocklistSize int `yaml:"commonPasswordsBlocklistSize"`
+}
+
+type AccountConfig struct {
+	UsernameChangeCooldown time.Duration `yaml:"usernameChangeCooldown"`
+	DeletionGracePeriod time.Duration `yaml:"deletionGracePeriod"`
+}
+
+type GamesConfig struct {
+	HouseCutPercent int `yaml:"houseCutPercent"`
+}
+
+type MailConfig struct {
+	Sender string `yaml:"sender"`
+	From string `yaml:"from"`
+	Smtp SmtpConfig `yaml:"smtp"`
+	File MailFileConfig `yaml:"file"`
+}
+
+type SmtpConfig struct {
+	Host string `yaml:"host"`
+	Port int `yaml:"port"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+type MailFileConfig struct {
+	Path string `yaml:"path"`
+}
+
+type JobsConfig struct {
+	RefreshTokenJanitor RefreshTokenJanitorConfig `yaml:"refreshTokenJanitor"`
+	AccountAnonymizer AccountAnonymizerConfig `yaml:"accountAnonymizer"`
+	GuestReaper GuestReaperConfig `yaml:"guestReaper"`
+	GameScheduler GameSchedulerConfig `yaml:"gameScheduler"`
+}
+
+// validate validates the settings of the enabled jobs: every job runs periodically and
+// processes its work in batches, so both the interval and the batch size must be
+// positive.
+func (config JobsConfig) validate() error {
+	jobs := []struct {
+		name string
+		enabled bool
+		interval time.Duration
+		batchSize int
+	}{
+		{ "refreshTokenJanitor", config.RefreshTokenJanitor.Enabled, config.RefreshTokenJanitor.Interval, config.RefreshTokenJanitor.BatchSize },
+		{ "accountAnonymizer", config.AccountAnonymizer.Enabled, config.AccountAnonymizer.Interval, config.AccountAnonymizer.BatchSize },
+		{ "guestReaper", config.GuestReaper.Enabled, config.GuestReaper.Interval, config.GuestReaper.BatchSize },
+		{ "gameScheduler", config.GameScheduler.Enabled, config.GameScheduler.Interval, config.GameScheduler.BatchSize },
+	}
+	for _, job := range jobs {
+		if !job.enabled {
+			continue
+		}
+		if job.interval <= 0 {
+			return fmt.Errorf("invalid jobs.%s config: interval must be positive", job.name)
+		}
+		if job.batchSize <= 0 {
+			return fmt.Errorf("invalid jobs.%s config: batchSize must be positive", job.name)
+		}
+	}
+	if config.GameScheduler.Enabled && config.GameScheduler.ScheduleAhead <= 0 {
+		return fmt.Errorf("invalid jobs.gameScheduler config: scheduleAhead must be positive")
+	}
+	return nil
+}
+
+type RefreshTokenJanitorConfig struct {
+	Enabled bool `yaml:"enabled"`
+	Interval time.Duration `yaml:"interval"`
+	BatchSize int `yaml:"batchSize"`
+}
+
+type AccountAnonymizerConfig struct {
+	Enabled bool `yaml:"enabled"`
+	Interval time.Duration `yaml:"interval"`
+	BatchSize int `yaml:"batchSize"`
+}
+
+type GuestReaperConfig struct {
+	Enabled bool `yaml:"enabled"`
+	Interval time.Duration `yaml:"interval"`
+	BatchSize int `yaml:"batchSize"`
+}
+
+type GameSchedulerConfig struct {
+	Enabled bool `yaml:"enabled"`
+	Interval time.Duration `yaml:"interval"`
+	ScheduleAhead time.Duration `yaml:"scheduleAhead"`
+	BatchSize int `yaml:"batchSize"`
+}
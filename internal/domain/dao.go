@@ -6,16 +6,76 @@ import (
 
 type DAO struct {
 	userRepo domports.UserRepo
+	userRoleRepo domports.UserRoleRepo
 	refreshTokenRepo domports.RefreshTokenRepo
+	revokedTokenRepo domports.RevokedTokenRepo
+	loginAttemptRepo domports.LoginAttemptRepo
+	userTotpRepo domports.UserTotpRepo
+	recoveryCodeRepo domports.RecoveryCodeRepo
+	oneTimeTokenRepo domports.OneTimeTokenRepo
+	userIdentityRepo domports.UserIdentityRepo
+	oidcAuthRequestRepo domports.OidcAuthRequestRepo
+	organizationRepo domports.OrganizationRepo
+	organizationMemberRepo domports.OrganizationMemberRepo
+	apiKeyRepo domports.ApiKeyRepo
+	ledgerAccountRepo domports.LedgerAccountRepo
+	ledgerTransactionRepo domports.LedgerTransactionRepo
+	transactionManager domports.TransactionManager
+	gameRepo domports.GameRepo
+	gameCardRepo domports.GameCardRepo
+	gameWinnerRepo domports.GameWinnerRepo
+	jackpotRepo domports.JackpotRepo
+	gameTemplateRepo domports.GameTemplateRepo
+	lockRepo domports.LockRepo
 }
 
 func NewDAO(
 		userRepo domports.UserRepo,
+		userRoleRepo domports.UserRoleRepo,
 		refreshTokenRepo domports.RefreshTokenRepo,
+		revokedTokenRepo domports.RevokedTokenRepo,
+		loginAttemptRepo domports.LoginAttemptRepo,
+		userTotpRepo domports.UserTotpRepo,
+		recoveryCodeRepo domports.RecoveryCodeRepo,
+		oneTimeTokenRepo domports.OneTimeTokenRepo,
+		userIdentityRepo domports.UserIdentityRepo,
+		oidcAuthRequestRepo domports.OidcAuthRequestRepo,
+		organizationRepo domports.OrganizationRepo,
+		organizationMemberRepo domports.OrganizationMemberRepo,
+		apiKeyRepo domports.ApiKeyRepo,
+		ledgerAccountRepo domports.LedgerAccountRepo,
+		ledgerTransactionRepo domports.LedgerTransactionRepo,
+		transactionManager domports.TransactionManager,
+		gameRepo domports.GameRepo,
+		gameCardRepo domports.GameCardRepo,
+		gameWinnerRepo domports.GameWinnerRepo,
+		jackpotRepo domports.JackpotRepo,
+		gameTemplateRepo domports.GameTemplateRepo,
+		lockRepo domports.LockRepo,
 		) *DAO {
 		return &DAO{
 			userRepo: userRepo,
+			userRoleRepo: userRoleRepo,
 			refreshTokenRepo: refreshTokenRepo,
+			revokedTokenRepo: revokedTokenRepo,
+			loginAttemptRepo: loginAttemptRepo,
+			userTotpRepo: userTotpRepo,
+			recoveryCodeRepo: recoveryCodeRepo,
+			oneTimeTokenRepo: oneTimeTokenRepo,
+			userIdentityRepo: userIdentityRepo,
+			oidcAuthRequestRepo: oidcAuthRequestRepo,
+			organizationRepo: organizationRepo,
+			organizationMemberRepo: organizationMemberRepo,
+			apiKeyRepo: apiKeyRepo,
+			ledgerAccountRepo: ledgerAccountRepo,
+			ledgerTransactionRepo: ledgerTransactionRepo,
+			transactionManager: transactionManager,
+			gameRepo: gameRepo,
+			gameCardRepo: gameCardRepo,
+			gameWinnerRepo: gameWinnerRepo,
+			jackpotRepo: jackpotRepo,
+			gameTemplateRepo: gameTemplateRepo,
+			lockRepo: lockRepo,
 		}
 }
 
@@ -23,6 +83,86 @@ func (dao *DAO) UserRepo() domports.UserRepo {
 	return dao.userRepo
 }
 
+func (dao *DAO) UserRoleRepo() domports.UserRoleRepo {
+	return dao.userRoleRepo
+}
+
 func (dao *DAO) RefreshTokenRepo() domports.RefreshTokenRepo {
 	return dao.refreshTokenRepo
+}
+
+func (dao *DAO) RevokedTokenRepo() domports.RevokedTokenRepo {
+	return dao.revokedTokenRepo
+}
+
+func (dao *DAO) LoginAttemptRepo() domports.LoginAttemptRepo {
+	return dao.loginAttemptRepo
+}
+
+func (dao *DAO) UserTotpRepo() domports.UserTotpRepo {
+	return dao.userTotpRepo
+}
+
+func (dao *DAO) RecoveryCodeRepo() domports.RecoveryCodeRepo {
+	return dao.recoveryCodeRepo
+}
+
+func (dao *DAO) OneTimeTokenRepo() domports.OneTimeTokenRepo {
+	return dao.oneTimeTokenRepo
+}
+
+func (dao *DAO) UserIdentityRepo() domports.UserIdentityRepo {
+	return dao.userIdentityRepo
+}
+
+func (dao *DAO) OidcAuthRequestRepo() domports.OidcAuthRequestRepo {
+	return dao.oidcAuthRequestRepo
+}
+
+func (dao *DAO) OrganizationRepo() domports.OrganizationRepo {
+	return dao.organizationRepo
+}
+
+func (dao *DAO) OrganizationMemberRepo() domports.OrganizationMemberRepo {
+	return dao.organizationMemberRepo
+}
+
+func (dao *DAO) ApiKeyRepo() domports.ApiKeyRepo {
+	return dao.apiKeyRepo
+}
+
+func (dao *DAO) LedgerAccountRepo() domports.LedgerAccountRepo {
+	return dao.ledgerAccountRepo
+}
+
+func (dao *DAO) LedgerTransactionRepo() domports.LedgerTransactionRepo {
+	return dao.ledgerTransactionRepo
+}
+
+func (dao *DAO) TransactionManager() domports.TransactionManager {
+	return dao.transactionManager
+}
+
+func (dao *DAO) GameRepo() domports.GameRepo {
+	return dao.gameRepo
+}
+
+func (dao *DAO) GameCardRepo() domports.GameCardRepo {
+	return dao.gameCardRepo
+}
+
+func (dao *DAO) GameWinnerRepo() domports.GameWinnerRepo {
+	return dao.gameWinnerRepo
+}
+
+func (dao *DAO) JackpotRepo() domports.JackpotRepo {
+	return dao.jackpotRepo
+}
+
+func (dao *DAO) GameTemplateRepo() domports.GameTemplateRepo {
+	return dao.gameTemplateRepo
+}
+
+func (dao *DAO) LockRepo() domports.LockRepo {
+	return dao.lockRepo
 }
\ No newline at end of file
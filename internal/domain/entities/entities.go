@@ -1,20 +1,321 @@
 package entities
 
 import (
+	"gorm.io/gorm"
 	"time"
 )
 
+// A User is an account of the application.
+// Deleted accounts are soft deleted (DeletedAt is set), and anonymized once the grace
+// period has passed (AnonymizedAt is set): their personal data is erased, but the row is
+// kept so that the game history that references it stays intact. A deleted account keeps
+// its username and email, which cannot be taken by another user, until it is anonymized.
+// Guest accounts have GuestExpiresAt set: they are deleted when it passes, and it is
+// postponed while the guest is active. Upgrading the guest to a full account clears it.
 type User struct {
 	Id        int64      `gorm:"type:bigserial;primaryKey"`
 	Username  string     `gorm:"type:text;unique;not null"`
 	Password  string     `gorm:"type:text;not null"`
+	Email     *string    `gorm:"type:text;uniqueIndex"`
+	EmailVerifiedAt *time.Time `gorm:"type:timestamptz"`
 	CreatedAt time.Time  `gorm:"type:timestamptz;autoCreateTime"`
 	UpdatedAt time.Time  `gorm:"type:timestamptz;autoUpdateTime"`
+	UsernameChangedAt *time.Time `gorm:"type:timestamptz"`
+	DeletedAt gorm.DeletedAt `gorm:"type:timestamptz;index"`
+	AnonymizedAt *time.Time `gorm:"type:timestamptz"`
+	GuestExpiresAt *time.Time `gorm:"type:timestamptz;index"`
 }
 
+// A UserRole assigns a role to a user.
+type UserRole struct {
+	UserId int64         `gorm:"type:bigint;primaryKey"`
+	Role string          `gorm:"type:text;primaryKey"`
+	CreatedAt time.Time  `gorm:"type:timestamptz;autoCreateTime"`
+}
+
+// A RefreshToken is a registered refresh token. Only the SHA-256 digest of the token
+// (hex encoded) is stored, so the stored data cannot be used as a token.
+// Refresh tokens are rotated on every use, and the tokens derived from the same login
+// form a family: FamilyId identifies the family, and ParentTokenHash is the digest of
+// the token that was rotated to issue this one.
+// Rotated tokens are kept (RotatedAt is set) to detect their reuse.
+// AccessTokenId and AccessTokenExpiresAt identify the access token issued along with
+// the refresh token, so that it can be revoked when the session ends.
+// The device metadata describes the client of the session: DeviceName and
+// SessionCreatedAt are kept along the family, while UserAgent, IpAddress and LastUsedAt
+// are those of the login or refresh that issued the token.
 type RefreshToken struct {
-	Token string         `gorm:"type:string;primaryKey"`
-	UserId int64         `gorm:"type:bigint;not null"`
+	TokenHash string     `gorm:"type:text;primaryKey"`
+	UserId int64         `gorm:"type:bigint;not null;index"`
+	FamilyId string      `gorm:"type:text;not null;index"`
+	ParentTokenHash *string `gorm:"type:text"`
+	AccessTokenId string `gorm:"type:text;not null;default:''"`
+	AccessTokenExpiresAt time.Time `gorm:"type:timestamptz;not null;default:now()"`
+	DeviceName string    `gorm:"type:text;not null;default:''"`
+	UserAgent string     `gorm:"type:text;not null;default:''"`
+	IpAddress string     `gorm:"type:text;not null;default:''"`
+	SessionCreatedAt time.Time `gorm:"type:timestamptz;not null;default:now()"`
+	LastUsedAt time.Time `gorm:"type:timestamptz;not null;default:now()"`
 	CreatedAt time.Time  `gorm:"type:timestamptz;not null"`
 	ExpiresAt time.Time  `gorm:"type:timestamptz;not null"`
-}
\ No newline at end of file
+	RotatedAt *time.Time `gorm:"type:timestamptz"`
+}
+
+// A RevokedToken is an access token that must no longer be accepted, even though it has
+// not expired yet. It is identified by its JWT ID ('jti'), and can be forgotten once the
+// token expires.
+type RevokedToken struct {
+	TokenId string         `gorm:"type:text;primaryKey"`
+	UserId int64           `gorm:"type:bigint;not null"`
+	ExpiresAt time.Time    `gorm:"type:timestamptz;not null;index"`
+	RevokedAt time.Time    `gorm:"type:timestamptz;not null"`
+}
+
+// A LoginAttempt counts the consecutive failed logins of a key (a username or an IP
+// address). LockedUntil is set while the key is locked out.
+type LoginAttempt struct {
+	Key string             `gorm:"type:text;primaryKey"`
+	Failures int           `gorm:"type:integer;not null"`
+	LastFailureAt time.Time `gorm:"type:timestamptz;not null"`
+	LockedUntil *time.Time `gorm:"type:timestamptz"`
+}
+
+// A UserTotp is the TOTP secret of a user's two-factor authentication. The two-factor
+// authentication is enabled once the enrolment is confirmed (ConfirmedAt is set).
+// LastUsedStep is the time step of the last accepted code, so that a code cannot be
+// used twice.
+type UserTotp struct {
+	UserId int64           `gorm:"type:bigint;primaryKey"`
+	Secret string          `gorm:"type:text;not null"`
+	ConfirmedAt *time.Time `gorm:"type:timestamptz"`
+	LastUsedStep int64     `gorm:"type:bigint;not null;default:0"`
+	CreatedAt time.Time    `gorm:"type:timestamptz;autoCreateTime"`
+}
+
+// A RecoveryCode is a single-use code that replaces the TOTP code when the user has no
+// access to their authenticator. Only the hash of the code is stored.
+type RecoveryCode struct {
+	Id int64               `gorm:"type:bigserial;primaryKey"`
+	UserId int64           `gorm:"type:bigint;not null;index"`
+	CodeHash string        `gorm:"type:text;not null"`
+	UsedAt *time.Time      `gorm:"type:timestamptz"`
+	CreatedAt time.Time    `gorm:"type:timestamptz;autoCreateTime"`
+}
+
+// A OneTimeToken is a single-use token sent to a user (e.g., by email) to prove they
+// control a contact channel, such as the links of the password reset. Only the SHA-256
+// digest of the token (hex encoded) is stored. Purpose prevents using a token for
+// something other than what it was issued for.
+type OneTimeToken struct {
+	TokenHash string       `gorm:"type:text;primaryKey"`
+	UserId int64           `gorm:"type:bigint;not null;index"`
+	Purpose string         `gorm:"type:text;not null"`
+	CreatedAt time.Time    `gorm:"type:timestamptz;autoCreateTime"`
+	ExpiresAt time.Time    `gorm:"type:timestamptz;not null"`
+	UsedAt *time.Time      `gorm:"type:timestamptz"`
+}
+
+// A UserIdentity links a user to their identity at an external OpenID Connect provider,
+// identified by the provider name and the subject (the user id at the provider). Email
+// is the email asserted by the provider on the last login, for information only.
+type UserIdentity struct {
+	Provider string        `gorm:"type:text;primaryKey"`
+	Subject string         `gorm:"type:text;primaryKey"`
+	UserId int64           `gorm:"type:bigint;not null;index"`
+	Email string           `gorm:"type:text;not null;default:''"`
+	CreatedAt time.Time    `gorm:"type:timestamptz;autoCreateTime"`
+	LastLoginAt time.Time  `gorm:"type:timestamptz;not null"`
+}
+
+// An OidcAuthRequest is a pending login with an OpenID Connect provider, between the
+// redirection to the provider and its callback. It is found by the SHA-256 digest of the
+// state parameter (hex encoded), and holds the nonce expected in the ID token and the
+// PKCE code verifier.
+type OidcAuthRequest struct {
+	StateHash string       `gorm:"type:text;primaryKey"`
+	Provider string        `gorm:"type:text;not null"`
+	Nonce string           `gorm:"type:text;not null"`
+	CodeVerifier string    `gorm:"type:text;not null"`
+	CreatedAt time.Time    `gorm:"type:timestamptz;autoCreateTime"`
+	ExpiresAt time.Time    `gorm:"type:timestamptz;not null;index"`
+}
+
+// An Organization groups users that act on behalf of the same partner (e.g., a bingo
+// hall), so that it can own API keys.
+type Organization struct {
+	Id int64               `gorm:"type:bigserial;primaryKey"`
+	Name string            `gorm:"type:text;not null;unique"`
+	CreatedAt time.Time    `gorm:"type:timestamptz;autoCreateTime"`
+}
+
+// An OrganizationMember assigns a user to an organization with a role ("owner" or
+// "member"). Owners manage the members and the API keys of the organization.
+type OrganizationMember struct {
+	OrganizationId int64   `gorm:"type:bigint;primaryKey"`
+	UserId int64           `gorm:"type:bigint;primaryKey;index"`
+	Role string            `gorm:"type:text;not null"`
+	CreatedAt time.Time    `gorm:"type:timestamptz;autoCreateTime"`
+}
+
+// An ApiKey authenticates a machine client on behalf of a user or, if OrganizationId is
+// set, of an organization. Only the SHA-256 digest of the key (hex encoded) is stored;
+// Prefix is the beginning of the key, shown to tell the keys apart. Scopes is the
+// space-separated list of the permissions granted to the key.
+type ApiKey struct {
+	Id int64               `gorm:"type:bigserial;primaryKey"`
+	KeyHash string         `gorm:"type:text;not null;uniqueIndex"`
+	Prefix string          `gorm:"type:text;not null"`
+	Name string            `gorm:"type:text;not null"`
+	UserId *int64          `gorm:"type:bigint;index"`
+	OrganizationId *int64  `gorm:"type:bigint;index"`
+	CreatedBy int64        `gorm:"type:bigint;not null"`
+	Scopes string          `gorm:"type:text;not null"`
+	CreatedAt time.Time    `gorm:"type:timestamptz;autoCreateTime"`
+	ExpiresAt *time.Time   `gorm:"type:timestamptz"`
+	LastUsedAt *time.Time  `gorm:"type:timestamptz"`
+	RevokedAt *time.Time   `gorm:"type:timestamptz"`
+}
+
+// A LedgerAccount is an account of the double-entry ledger: the wallet of a user
+// (UserId is set), or a system account (e.g., the house) identified by its code.
+// Balances are not stored: the balance of an account is the sum of its entries.
+// Only system accounts can have a negative balance.
+type LedgerAccount struct {
+	Id int64               `gorm:"type:bigserial;primaryKey"`
+	Code string            `gorm:"type:text;not null;uniqueIndex"`
+	UserId *int64          `gorm:"type:bigint;uniqueIndex"`
+	AllowNegative bool     `gorm:"type:boolean;not null;default:false"`
+	CreatedAt time.Time    `gorm:"type:timestamptz;autoCreateTime"`
+}
+
+// A LedgerTransaction is an immutable movement of money between ledger accounts, made
+// of entries whose amounts add up to zero. Type tells what it is for (e.g., a card
+// purchase), and IdempotencyKey ensures it is recorded only once even if the operation
+// is retried. Reference identifies the related object (e.g., "game:12").
+type LedgerTransaction struct {
+	Id int64               `gorm:"type:bigserial;primaryKey"`
+	Type string            `gorm:"type:text;not null"`
+	IdempotencyKey string  `gorm:"type:text;not null;uniqueIndex"`
+	Reference string       `gorm:"type:text;not null;default:'';index"`
+	Description string     `gorm:"type:text;not null;default:''"`
+	CreatedBy *int64       `gorm:"type:bigint"`
+	CreatedAt time.Time    `gorm:"type:timestamptz;autoCreateTime"`
+}
+
+// A LedgerEntry credits (positive amount) or debits (negative amount) a ledger account
+// as part of a transaction. Amounts are in credits, the smallest unit of the virtual
+// currency.
+type LedgerEntry struct {
+	Id int64               `gorm:"type:bigserial;primaryKey"`
+	TransactionId int64    `gorm:"type:bigint;not null;index"`
+	AccountId int64        `gorm:"type:bigint;not null;index"`
+	Amount int64           `gorm:"type:bigint;not null"`
+	CreatedAt time.Time    `gorm:"type:timestamptz;autoCreateTime"`
+
+	Transaction *LedgerTransaction `gorm:"foreignKey:TransactionId"`
+}
+
+// A Game is a bingo game. Its cards are sold while its status is "buying", at CardPrice
+// each; if BundleSize is set, every BundleSize cards of a purchase cost BundlePrice
+// instead (e.g., 6 cards for the price of 5). MaxCardsPerPlayer and MaxCards limit the
+// cards of each player and of the whole game (0 means no limit). Prices are in credits.
+// The cards that complete Pattern first win the prize, computed as PrizeType tells from
+// PrizeAmount and PrizePercent, minus HouseCutPercent. DrawnNumbers is the string
+// representation of the numbers drawn so far (see types.DrawnNumbers). If JackpotId is
+// set, the game contributes to the progressive jackpot and can win it. Games created
+// from a template have TemplateId and ScheduledAt set (unique together), and their cards
+// go on sale at BuyingOpensAt.
+type Game struct {
+	Id int64               `gorm:"type:bigserial;primaryKey"`
+	Name string            `gorm:"type:text;not null"`
+	HostId int64           `gorm:"type:bigint;not null;index"`
+	Status string          `gorm:"type:text;not null;index"`
+	CardPrice int64        `gorm:"type:bigint;not null;default:0"`
+	BundleSize int         `gorm:"type:integer;not null;default:0"`
+	BundlePrice int64      `gorm:"type:bigint;not null;default:0"`
+	MaxCardsPerPlayer int  `gorm:"type:integer;not null;default:0"`
+	MaxCards int           `gorm:"type:integer;not null;default:0"`
+	Pattern string         `gorm:"type:text;not null;default:'full_card'"`
+	PrizeType string       `gorm:"type:text;not null;default:'fixed'"`
+	PrizeAmount int64      `gorm:"type:bigint;not null;default:0"`
+	PrizePercent int       `gorm:"type:integer;not null;default:0"`
+	HouseCutPercent int    `gorm:"type:integer;not null;default:0"`
+	DrawnNumbers string    `gorm:"type:text;not null;default:''"`
+	JackpotId *int64       `gorm:"type:bigint;index"`
+	TemplateId *int64      `gorm:"type:bigint;uniqueIndex:idx_games_schedule,priority:1"`
+	ScheduledAt *time.Time `gorm:"type:timestamptz;uniqueIndex:idx_games_schedule,priority:2;index"`
+	BuyingOpensAt *time.Time `gorm:"type:timestamptz;index"`
+	CreatedAt time.Time    `gorm:"type:timestamptz;autoCreateTime"`
+	StartedAt *time.Time   `gorm:"type:timestamptz"`
+	FinishedAt *time.Time  `gorm:"type:timestamptz"`
+}
+
+// A GameCard is a card bought by a user for a game. Numbers is the string representation
+// of the card (see types.Card), unique within the game. TransactionId is the ledger
+// transaction of the purchase, and is not set for free games.
+type GameCard struct {
+	Id int64               `gorm:"type:bigserial;primaryKey"`
+	GameId int64           `gorm:"type:bigint;not null;uniqueIndex:idx_game_cards_numbers,priority:1;index:idx_game_cards_user,priority:1"`
+	UserId int64           `gorm:"type:bigint;not null;index:idx_game_cards_user,priority:2"`
+	Numbers string         `gorm:"type:text;not null;uniqueIndex:idx_game_cards_numbers,priority:2"`
+	TransactionId *int64   `gorm:"type:bigint;index"`
+	CreatedAt time.Time    `gorm:"type:timestamptz;autoCreateTime"`
+}
+
+// A GameWinner is a card that won a game, and the share of the prize paid for it.
+// BallCount is the number of balls drawn when the card completed the pattern.
+// JackpotPrize is the share of the progressive jackpot, if the card won it too.
+type GameWinner struct {
+	GameId int64           `gorm:"type:bigint;primaryKey"`
+	CardId int64           `gorm:"type:bigint;primaryKey"`
+	UserId int64           `gorm:"type:bigint;not null;index"`
+	Prize int64            `gorm:"type:bigint;not null"`
+	BallCount int          `gorm:"type:integer;not null"`
+	JackpotPrize int64     `gorm:"type:bigint;not null;default:0"`
+	CreatedAt time.Time    `gorm:"type:timestamptz;autoCreateTime"`
+}
+
+// A Jackpot is a progressive jackpot, fed by ContributionPercent of the card sales of its
+// full card games. It is won by the cards that complete the full card within BallLimit
+// balls; otherwise BallLimit grows by one after each game (up to 75), until the jackpot
+// is won and it goes back to InitialBallLimit. The value of the jackpot is the balance
+// of its ledger account.
+type Jackpot struct {
+	Id int64               `gorm:"type:bigserial;primaryKey"`
+	Name string            `gorm:"type:text;not null"`
+	HostId int64           `gorm:"type:bigint;not null;index"`
+	ContributionPercent int `gorm:"type:integer;not null"`
+	InitialBallLimit int   `gorm:"type:integer;not null"`
+	BallLimit int          `gorm:"type:integer;not null"`
+	CreatedAt time.Time    `gorm:"type:timestamptz;autoCreateTime"`
+	LastWonAt *time.Time   `gorm:"type:timestamptz"`
+}
+
+// A GameTemplate describes recurring games. Every time Recurrence matches (see
+// types.Recurrence), GamesPerOccurrence games are scheduled GameInterval apart, playing
+// the comma-separated Patterns in turn, with the settings of the template. Their cards go
+// on sale BuyingLeadTime before each game. ScheduledUntil is the time up to which the
+// games have already been created.
+type GameTemplate struct {
+	Id int64               `gorm:"type:bigserial;primaryKey"`
+	Name string            `gorm:"type:text;not null"`
+	HostId int64           `gorm:"type:bigint;not null;index"`
+	Variant string         `gorm:"type:text;not null"`
+	Patterns string        `gorm:"type:text;not null"`
+	CardPrice int64        `gorm:"type:bigint;not null;default:0"`
+	BundleSize int         `gorm:"type:integer;not null;default:0"`
+	BundlePrice int64      `gorm:"type:bigint;not null;default:0"`
+	MaxCardsPerPlayer int  `gorm:"type:integer;not null;default:0"`
+	MaxCards int           `gorm:"type:integer;not null;default:0"`
+	PrizeType string       `gorm:"type:text;not null"`
+	PrizeAmount int64      `gorm:"type:bigint;not null;default:0"`
+	PrizePercent int       `gorm:"type:integer;not null;default:0"`
+	JackpotId *int64       `gorm:"type:bigint"`
+	Recurrence string      `gorm:"type:text;not null"`
+	GamesPerOccurrence int `gorm:"type:integer;not null"`
+	GameInterval time.Duration `gorm:"type:bigint;not null"`
+	BuyingLeadTime time.Duration `gorm:"type:bigint;not null"`
+	Enabled bool           `gorm:"type:boolean;not null;index"`
+	ScheduledUntil time.Time `gorm:"type:timestamptz;not null"`
+	CreatedAt time.Time    `gorm:"type:timestamptz;autoCreateTime"`
+}
@@ -0,0 +1,9 @@
+package ports
+
+import (
+	"errors"
+)
+
+// ErrNotFound is returned by the repositories when the requested record does not exist,
+// so that callers do not depend on the errors of the storage.
+var ErrNotFound = errors.New("record not found")
@@ -3,19 +3,182 @@ package ports
 import (
 	"context"
 	"github.com/gabriel-98/bingo-backend/internal/domain/entities"
+	"time"
 )
 
 type UserRepo interface {
 	Create(ctx context.Context, user entities.User) (*entities.User, error)
 	FindById(ctx context.Context, id int64) (*entities.User, error)
 	FindByUsername(ctx context.Context, username string) (*entities.User, error)
+	FindByEmail(ctx context.Context, email string) (*entities.User, error)
+	ExistsByUsername(ctx context.Context, username string) (bool, error)
+	ExistsByEmail(ctx context.Context, email string) (bool, error)
 	Update(ctx context.Context, id int64, user entities.User) (*entities.User, error)
+	ReplacePassword(ctx context.Context, id int64, oldPassword string, newPassword string) (bool, error)
+	MarkEmailVerified(ctx context.Context, id int64, email string, verifiedAt time.Time) (bool, error)
 	Delete(ctx context.Context, id int64) error
+	FindDeletedBefore(ctx context.Context, before time.Time, limit int) ([]entities.User, error)
+	Anonymize(ctx context.Context, id int64, username string, anonymizedAt time.Time) error
+	FindExpiredGuests(ctx context.Context, now time.Time, limit int) ([]entities.User, error)
+	ExtendGuest(ctx context.Context, id int64, expiresAt time.Time) error
+}
+
+type UserRoleRepo interface {
+	FindByUserId(ctx context.Context, userId int64) ([]entities.UserRole, error)
+	Replace(ctx context.Context, userId int64, roles []string) ([]entities.UserRole, error)
+	Add(ctx context.Context, userId int64, role string) error
 }
 
 type RefreshTokenRepo interface {
 	Create(ctx context.Context, refreshToken entities.RefreshToken) (*entities.RefreshToken, error)
-	FindByToken(ctx context.Context, token string) (*entities.RefreshToken, error)
-	Update(ctx context.Context, token string, refreshToken entities.RefreshToken) (*entities.RefreshToken, error)
-	Delete(ctx context.Context, token string) error
+	FindByToken(ctx context.Context, tokenHash string) (*entities.RefreshToken, error)
+	FindByFamilyId(ctx context.Context, familyId string) ([]entities.RefreshToken, error)
+	FindByUserId(ctx context.Context, userId int64) ([]entities.RefreshToken, error)
+	FindActiveByUserId(ctx context.Context, userId int64, now time.Time) ([]entities.RefreshToken, error)
+	Update(ctx context.Context, tokenHash string, refreshToken entities.RefreshToken) (*entities.RefreshToken, error)
+	MarkRotated(ctx context.Context, tokenHash string, rotatedAt time.Time) (bool, error)
+	Delete(ctx context.Context, tokenHash string) error
+	DeleteByFamilyId(ctx context.Context, familyId string) error
+	DeleteByUserId(ctx context.Context, userId int64) error
+	DeleteExpired(ctx context.Context, before time.Time, limit int) (int64, error)
+}
+
+type RevokedTokenRepo interface {
+	Create(ctx context.Context, revokedToken entities.RevokedToken) error
+	Exists(ctx context.Context, tokenId string, now time.Time) (bool, error)
+}
+
+type LoginAttemptRepo interface {
+	Find(ctx context.Context, key string) (*entities.LoginAttempt, error)
+	RecordFailure(ctx context.Context, key string, at time.Time, resetBefore time.Time) (*entities.LoginAttempt, error)
+	Release(ctx context.Context, key string) error
+	Lock(ctx context.Context, key string, until time.Time) error
+	Reset(ctx context.Context, key string) error
+}
+
+type UserTotpRepo interface {
+	FindByUserId(ctx context.Context, userId int64) (*entities.UserTotp, error)
+	Save(ctx context.Context, userTotp entities.UserTotp) (*entities.UserTotp, error)
+	UseStep(ctx context.Context, userId int64, step int64) (bool, error)
+	Delete(ctx context.Context, userId int64) error
+}
+
+type RecoveryCodeRepo interface {
+	FindUnusedByUserId(ctx context.Context, userId int64) ([]entities.RecoveryCode, error)
+	Replace(ctx context.Context, userId int64, codeHashes []string) ([]entities.RecoveryCode, error)
+	MarkUsed(ctx context.Context, id int64, usedAt time.Time) (bool, error)
+	DeleteByUserId(ctx context.Context, userId int64) error
+}
+
+type OneTimeTokenRepo interface {
+	Create(ctx context.Context, oneTimeToken entities.OneTimeToken) (*entities.OneTimeToken, error)
+	FindByToken(ctx context.Context, tokenHash string, purpose string) (*entities.OneTimeToken, error)
+	FindLatestByUserId(ctx context.Context, userId int64, purpose string) (*entities.OneTimeToken, error)
+	MarkUsed(ctx context.Context, tokenHash string, usedAt time.Time) (bool, error)
+	DeleteByUserId(ctx context.Context, userId int64, purpose string) error
+}
+
+type UserIdentityRepo interface {
+	Find(ctx context.Context, provider string, subject string) (*entities.UserIdentity, error)
+	Create(ctx context.Context, userIdentity entities.UserIdentity) (*entities.UserIdentity, error)
+	UpdateLastLogin(ctx context.Context, provider string, subject string, email string, at time.Time) error
+	DeleteByUserId(ctx context.Context, userId int64) error
+}
+
+type OidcAuthRequestRepo interface {
+	Create(ctx context.Context, oidcAuthRequest entities.OidcAuthRequest) error
+	Take(ctx context.Context, stateHash string) (*entities.OidcAuthRequest, error)
+}
+
+type OrganizationRepo interface {
+	Create(ctx context.Context, organization entities.Organization) (*entities.Organization, error)
+	FindById(ctx context.Context, id int64) (*entities.Organization, error)
+	FindByName(ctx context.Context, name string) (*entities.Organization, error)
+}
+
+type OrganizationMemberRepo interface {
+	Find(ctx context.Context, organizationId int64, userId int64) (*entities.OrganizationMember, error)
+	FindByUserId(ctx context.Context, userId int64) ([]entities.OrganizationMember, error)
+	FindByOrganizationId(ctx context.Context, organizationId int64) ([]entities.OrganizationMember, error)
+	Save(ctx context.Context, organizationMember entities.OrganizationMember) (*entities.OrganizationMember, error)
+	Delete(ctx context.Context, organizationId int64, userId int64) error
+}
+
+type ApiKeyRepo interface {
+	Create(ctx context.Context, apiKey entities.ApiKey) (*entities.ApiKey, error)
+	FindById(ctx context.Context, id int64) (*entities.ApiKey, error)
+	FindByKey(ctx context.Context, keyHash string) (*entities.ApiKey, error)
+	FindByUserId(ctx context.Context, userId int64) ([]entities.ApiKey, error)
+	FindByOrganizationId(ctx context.Context, organizationId int64) ([]entities.ApiKey, error)
+	Revoke(ctx context.Context, id int64, revokedAt time.Time) error
+	TouchLastUsed(ctx context.Context, id int64, usedAt time.Time, precision time.Duration) error
+}
+
+type LedgerAccountRepo interface {
+	FindOrCreate(ctx context.Context, ledgerAccount entities.LedgerAccount) (*entities.LedgerAccount, error)
+	FindByUserId(ctx context.Context, userId int64) (*entities.LedgerAccount, error)
+	LockByIds(ctx context.Context, ids []int64) ([]entities.LedgerAccount, error)
+	Balance(ctx context.Context, id int64) (int64, error)
+}
+
+type LedgerTransactionRepo interface {
+	Create(ctx context.Context, ledgerTransaction entities.LedgerTransaction, entries []entities.LedgerEntry) (*entities.LedgerTransaction, error)
+	FindById(ctx context.Context, id int64) (*entities.LedgerTransaction, error)
+	FindByIdempotencyKey(ctx context.Context, idempotencyKey string) (*entities.LedgerTransaction, error)
+	FindEntries(ctx context.Context, transactionId int64) ([]entities.LedgerEntry, error)
+	FindEntriesByAccountId(ctx context.Context, accountId int64, limit int) ([]entities.LedgerEntry, error)
+	SumEntries(ctx context.Context, accountId int64, reference string, transactionTypes []string) (int64, error)
+}
+
+type GameRepo interface {
+	Create(ctx context.Context, game entities.Game) (*entities.Game, error)
+	CreateScheduled(ctx context.Context, game entities.Game) (created bool, err error)
+	FindById(ctx context.Context, id int64) (*entities.Game, error)
+	FindBuyingDue(ctx context.Context, now time.Time, limit int) ([]entities.Game, error)
+	FindStartDue(ctx context.Context, now time.Time, limit int) ([]entities.Game, error)
+	LockById(ctx context.Context, id int64) (*entities.Game, error)
+	Update(ctx context.Context, game entities.Game) error
+}
+
+type GameCardRepo interface {
+	CreateBatch(ctx context.Context, gameCards []entities.GameCard) ([]entities.GameCard, error)
+	FindByGameId(ctx context.Context, gameId int64) ([]entities.GameCard, error)
+	FindByGameIdAndUserId(ctx context.Context, gameId int64, userId int64) ([]entities.GameCard, error)
+	FindByTransactionId(ctx context.Context, transactionId int64) ([]entities.GameCard, error)
+	FindNumbersByGameId(ctx context.Context, gameId int64) ([]string, error)
+	CountByGameId(ctx context.Context, gameId int64) (int, error)
+	CountByGameIdAndUserId(ctx context.Context, gameId int64, userId int64) (int, error)
+	DeleteByTransactionId(ctx context.Context, transactionId int64) error
+}
+
+type GameWinnerRepo interface {
+	CreateBatch(ctx context.Context, gameWinners []entities.GameWinner) error
+	FindByGameId(ctx context.Context, gameId int64) ([]entities.GameWinner, error)
+}
+
+type GameTemplateRepo interface {
+	Create(ctx context.Context, gameTemplate entities.GameTemplate) (*entities.GameTemplate, error)
+	FindById(ctx context.Context, id int64) (*entities.GameTemplate, error)
+	FindByHostId(ctx context.Context, hostId int64) ([]entities.GameTemplate, error)
+	FindEnabled(ctx context.Context) ([]entities.GameTemplate, error)
+	Disable(ctx context.Context, id int64) error
+	UpdateScheduledUntil(ctx context.Context, id int64, scheduledUntil time.Time) error
+}
+
+type JackpotRepo interface {
+	Create(ctx context.Context, jackpot entities.Jackpot) (*entities.Jackpot, error)
+	FindById(ctx context.Context, id int64) (*entities.Jackpot, error)
+	LockById(ctx context.Context, id int64) (*entities.Jackpot, error)
+	Update(ctx context.Context, jackpot entities.Jackpot) error
+}
+
+// A TransactionManager runs functions in a database transaction. The context passed to
+// the function carries the transaction, so that the repositories called with it take
+// part in the transaction.
+type TransactionManager interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type LockRepo interface {
+	TryLock(ctx context.Context, name string) (release func() error, acquired bool, err error)
 }
\ No newline at end of file
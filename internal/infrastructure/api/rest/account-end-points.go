@@ -0,0 +1,182 @@
+package rest
+
+import (
+	"errors"
+	"github.com/gabriel-98/bingo-backend/internal/application/dto"
+	"github.com/gabriel-98/bingo-backend/internal/application/types"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+func (server *RestServer) ChangePasswordEndPoint(c *gin.Context) {
+	// Read the authenticated user and the request body.
+	userAuthData, _ := UserAuthDataFromContext(c)
+	var changePasswordRequest dto.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&changePasswordRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	changePasswordRequest.ClientInfo = clientInfo(c)
+
+	// Call to the service layer.
+	authService := server.serviceGroup.AuthService()
+	err := authService.ChangePassword(c, *userAuthData, changePasswordRequest)
+	if writeLoginThrottledError(c, err) {
+		return
+	}
+	var passwordPolicyErr *types.PasswordPolicyError
+	if errors.As(err, &passwordPolicyErr) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status": "password not changed",
+			"message": err.Error(),
+			"violations": passwordPolicyErr.Violations,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(accountErrorStatus(err), gin.H{
+			"status": "password not changed",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Write the response body.
+	c.JSON(http.StatusOK, gin.H{"status": "the password has been changed"})
+}
+
+func (server *RestServer) ChangeUsernameEndPoint(c *gin.Context) {
+	// Read the authenticated user and the request body.
+	userAuthData, _ := UserAuthDataFromContext(c)
+	var changeUsernameRequest dto.ChangeUsernameRequest
+	if err := c.ShouldBindJSON(&changeUsernameRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Call to the service layer.
+	authService := server.serviceGroup.AuthService()
+	accountResponse, err := authService.ChangeUsername(c, *userAuthData, changeUsernameRequest)
+	if err != nil {
+		c.JSON(accountErrorStatus(err), gin.H{
+			"status": "username not changed",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Write the response body.
+	c.JSON(http.StatusOK, accountResponse)
+}
+
+// UpgradeGuestEndPoint turns the guest account of the authenticated user into a full
+// account. The response contains the tokens of a new session, which replaces the
+// sessions of the guest.
+func (server *RestServer) UpgradeGuestEndPoint(c *gin.Context) {
+	// Read the authenticated user and the request body.
+	userAuthData, _ := UserAuthDataFromContext(c)
+	var upgradeGuestRequest dto.UpgradeGuestRequest
+	if err := c.ShouldBindJSON(&upgradeGuestRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	upgradeGuestRequest.ClientInfo = clientInfo(c)
+
+	// Call to the service layer.
+	authService := server.serviceGroup.AuthService()
+	loginResponse, err := authService.UpgradeGuest(c, *userAuthData, upgradeGuestRequest)
+	if err != nil {
+		c.JSON(accountErrorStatus(err), gin.H{
+			"status": "account not upgraded",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Write the response body.
+	c.JSON(http.StatusOK, loginResponse)
+}
+
+func (server *RestServer) ChangeEmailEndPoint(c *gin.Context) {
+	// Read the authenticated user and the request body.
+	userAuthData, _ := UserAuthDataFromContext(c)
+	var changeEmailRequest dto.ChangeEmailRequest
+	if err := c.ShouldBindJSON(&changeEmailRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Call to the service layer.
+	authService := server.serviceGroup.AuthService()
+	accountResponse, err := authService.ChangeEmail(c, *userAuthData, changeEmailRequest)
+	if err != nil {
+		c.JSON(accountErrorStatus(err), gin.H{
+			"status": "email not changed",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Write the response body.
+	c.JSON(http.StatusOK, accountResponse)
+}
+
+func (server *RestServer) ResendEmailVerificationEndPoint(c *gin.Context) {
+	// Read the authenticated user.
+	userAuthData, _ := UserAuthDataFromContext(c)
+
+	// Call to the service layer.
+	authService := server.serviceGroup.AuthService()
+	if err := authService.ResendEmailVerification(c, *userAuthData); err != nil {
+		c.JSON(accountErrorStatus(err), gin.H{
+			"status": "verification email not sent",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Write the response body.
+	c.JSON(http.StatusAccepted, gin.H{"status": "a verification link has been sent to your email"})
+}
+
+func (server *RestServer) DeleteAccountEndPoint(c *gin.Context) {
+	// Read the authenticated user and the request body.
+	userAuthData, _ := UserAuthDataFromContext(c)
+	var deleteAccountRequest dto.DeleteAccountRequest
+	if err := c.ShouldBindJSON(&deleteAccountRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Call to the service layer.
+	authService := server.serviceGroup.AuthService()
+	if err := authService.DeleteAccount(c, *userAuthData, deleteAccountRequest); err != nil {
+		c.JSON(accountErrorStatus(err), gin.H{
+			"status": "account not deleted",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Write the response body.
+	c.JSON(http.StatusOK, gin.H{"status": "the account has been deleted"})
+}
+
+// accountErrorStatus returns the HTTP status of an error of the account end points.
+func accountErrorStatus(err error) int {
+	switch {
+		case errors.Is(err, types.ErrInvalidCredentials), errors.Is(err, types.ErrReauthenticationRequired):
+			return http.StatusForbidden
+		case errors.Is(err, types.ErrUsernameTaken), errors.Is(err, types.ErrEmailTaken):
+			return http.StatusConflict
+		case errors.Is(err, types.ErrPasswordChangedConcurrently):
+			return http.StatusConflict
+		case errors.Is(err, types.ErrUsernameChangeTooSoon), errors.Is(err, types.ErrVerificationEmailTooSoon):
+			return http.StatusTooManyRequests
+		case errors.Is(err, types.ErrEmailAlreadyVerified), errors.Is(err, types.ErrNotGuest):
+			return http.StatusConflict
+		default:
+			// Default error for these end points (Error translation is pending)
+			return http.StatusBadRequest
+	}
+}
@@ -0,0 +1,102 @@
+package rest
+
+import (
+	"errors"
+	"github.com/gabriel-98/bingo-backend/internal/application/dto"
+	"github.com/gabriel-98/bingo-backend/internal/application/types"
+	domports "github.com/gabriel-98/bingo-backend/internal/domain/ports"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+// CreateApiKeyEndPoint creates an API key. The key is only returned in this response.
+func (server *RestServer) CreateApiKeyEndPoint(c *gin.Context) {
+	// Read the authenticated user and the request body.
+	userAuthData, _ := UserAuthDataFromContext(c)
+	var createApiKeyRequest dto.CreateApiKeyRequest
+	if err := c.ShouldBindJSON(&createApiKeyRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Call to the service layer.
+	apiKeyService := server.serviceGroup.ApiKeyService()
+	createApiKeyResponse, err := apiKeyService.CreateApiKey(c, *userAuthData, createApiKeyRequest)
+	if err != nil {
+		c.JSON(apiKeyErrorStatus(err), gin.H{
+			"status": "API key not created",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Write the response body.
+	c.JSON(http.StatusCreated, createApiKeyResponse)
+}
+
+// ListApiKeysEndPoint returns the API keys of the user or, with the "organization_id"
+// query parameter, of the organization.
+func (server *RestServer) ListApiKeysEndPoint(c *gin.Context) {
+	// Read the authenticated user and the query parameters.
+	userAuthData, _ := UserAuthDataFromContext(c)
+	var organizationId *int64
+	if value, found := c.GetQuery("organization_id"); found {
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid organization id"})
+			return
+		}
+		organizationId = &id
+	}
+
+	// Call to the service layer.
+	apiKeyService := server.serviceGroup.ApiKeyService()
+	apiKeyResponses, err := apiKeyService.ListApiKeys(c, *userAuthData, organizationId)
+	if err != nil {
+		c.JSON(apiKeyErrorStatus(err), gin.H{
+			"status": "API keys not retrieved",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Write the response body.
+	c.JSON(http.StatusOK, apiKeyResponses)
+}
+
+func (server *RestServer) RevokeApiKeyEndPoint(c *gin.Context) {
+	// Read the authenticated user and the path parameters.
+	userAuthData, _ := UserAuthDataFromContext(c)
+	apiKeyId, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid API key id"})
+		return
+	}
+
+	// Call to the service layer.
+	apiKeyService := server.serviceGroup.ApiKeyService()
+	if err := apiKeyService.RevokeApiKey(c, *userAuthData, apiKeyId); err != nil {
+		c.JSON(apiKeyErrorStatus(err), gin.H{
+			"status": "API key not revoked",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Write the response body.
+	c.JSON(http.StatusOK, gin.H{"status": "the API key has been revoked"})
+}
+
+// apiKeyErrorStatus returns the HTTP status of an error of the API key end points.
+func apiKeyErrorStatus(err error) int {
+	switch {
+		case errors.Is(err, types.ErrNotOrganizationOwner):
+			return http.StatusForbidden
+		case errors.Is(err, domports.ErrNotFound):
+			return http.StatusNotFound
+		default:
+			// Default error for these end points (Error translation is pending)
+			return http.StatusBadRequest
+	}
+}
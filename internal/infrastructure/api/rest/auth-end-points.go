@@ -1,9 +1,13 @@
 package rest
 
 import (
+	"errors"
 	"github.com/gabriel-98/bingo-backend/internal/application/dto"
+	"github.com/gabriel-98/bingo-backend/internal/application/types"
 	"github.com/gin-gonic/gin"
+	"math"
 	"net/http"
+	"strconv"
 )
 
 func (server *RestServer) SignupEndPoint(c *gin.Context) {
@@ -17,6 +21,15 @@ func (server *RestServer) SignupEndPoint(c *gin.Context) {
 	// Call to the service layer.
 	authService := server.serviceGroup.AuthService()
 	signupResponse, err := authService.Signup(c, signupRequest)
+	var passwordPolicyErr *types.PasswordPolicyError
+	if errors.As(err, &passwordPolicyErr) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status": "registration not successful",
+			"message": err.Error(),
+			"violations": passwordPolicyErr.Violations,
+		})
+		return
+	}
 	if err != nil {
 		// Default error for this end point (Error translation is pending)
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -37,10 +50,150 @@ func (server *RestServer) LoginEndPoint(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	loginRequest.ClientInfo = clientInfo(c)
 
 	// Call to the service layer.
 	authService := server.serviceGroup.AuthService()
 	loginResponse, err := authService.Login(c, loginRequest)
+	if writeLoginThrottledError(c, err) {
+		return
+	}
+	if err != nil {
+		// Default error for this end point (Error translation is pending)
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"status": "unathorized",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Write the response body.
+	c.JSON(http.StatusOK, loginResponse)
+}
+
+func (server *RestServer) LoginMfaEndPoint(c *gin.Context) {
+	// Read the request body.
+	var loginMfaRequest dto.LoginMfaRequest
+	if err := c.ShouldBindJSON(&loginMfaRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	loginMfaRequest.ClientInfo = clientInfo(c)
+
+	// Call to the service layer.
+	authService := server.serviceGroup.AuthService()
+	loginResponse, err := authService.LoginMfa(c, loginMfaRequest)
+	if writeLoginThrottledError(c, err) {
+		return
+	}
+	if errors.Is(err, types.ErrInvalidMfaCode) {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"status": "unathorized",
+			"code": "invalid_mfa_code",
+			"message": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		// Default error for this end point (Error translation is pending)
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"status": "unathorized",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Write the response body.
+	c.JSON(http.StatusOK, loginResponse)
+}
+
+// GuestLoginEndPoint creates a guest account and logs in with it.
+func (server *RestServer) GuestLoginEndPoint(c *gin.Context) {
+	// Read the request body (optional).
+	var guestLoginRequest dto.GuestLoginRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&guestLoginRequest); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	guestLoginRequest.ClientInfo = clientInfo(c)
+
+	// Call to the service layer.
+	authService := server.serviceGroup.AuthService()
+	loginResponse, err := authService.LoginGuest(c, guestLoginRequest)
+	if errors.Is(err, types.ErrGuestLoginDisabled) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"status": "authentication not successful",
+			"message": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status": "authentication not successful",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Write the response body.
+	c.JSON(http.StatusOK, loginResponse)
+}
+
+// StartOidcLoginEndPoint starts a login with an external OpenID Connect provider. It
+// returns the URL of the provider where the user must be redirected, or redirects to it
+// if the "redirect" query parameter is set (so that it can be linked from a page).
+func (server *RestServer) StartOidcLoginEndPoint(c *gin.Context) {
+	// Call to the service layer.
+	authService := server.serviceGroup.AuthService()
+	oidcLoginStartResponse, err := authService.StartOidcLogin(c, c.Param("provider"))
+	if errors.Is(err, types.ErrUnknownOidcProvider) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status": "login not started",
+			"message": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{
+			"status": "login not started",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Write the response body.
+	if _, redirect := c.GetQuery("redirect"); redirect {
+		c.Redirect(http.StatusFound, oidcLoginStartResponse.AuthorizationUrl)
+		return
+	}
+	c.JSON(http.StatusOK, oidcLoginStartResponse)
+}
+
+// OidcCallbackEndPoint completes a login with an external OpenID Connect provider, which
+// redirects the user here. The response is the same as the one of the login.
+func (server *RestServer) OidcCallbackEndPoint(c *gin.Context) {
+	// Read the query parameters.
+	oidcCallbackRequest := dto.OidcCallbackRequest{
+		Provider: c.Param("provider"),
+		State: c.Query("state"),
+		Code: c.Query("code"),
+		Error: c.Query("error"),
+		DeviceName: c.Query("device_name"),
+		ClientInfo: clientInfo(c),
+	}
+
+	// Call to the service layer.
+	authService := server.serviceGroup.AuthService()
+	loginResponse, err := authService.CompleteOidcLogin(c, oidcCallbackRequest)
+	if errors.Is(err, types.ErrInvalidOidcState) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status": "authentication not successful",
+			"message": err.Error(),
+		})
+		return
+	}
 	if err != nil {
 		// Default error for this end point (Error translation is pending)
 		c.JSON(http.StatusUnauthorized, gin.H{
@@ -78,6 +231,25 @@ func (server *RestServer) LogoutEndPoint(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"status:": "you have logged out"})
 }
 
+func (server *RestServer) LogoutAllEndPoint(c *gin.Context) {
+	// Read the authenticated user.
+	userAuthData, _ := UserAuthDataFromContext(c)
+
+	// Call to the service layer.
+	authService := server.serviceGroup.AuthService()
+	if err := authService.LogoutAll(c, *userAuthData); err != nil {
+		// Default error for this end point (Error translation is pending)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status": "logout not successful",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Write the response body.
+	c.JSON(http.StatusOK, gin.H{"status": "you have logged out from all sessions"})
+}
+
 func (server *RestServer) RefreshTokenEndPoint(c *gin.Context) {
 	// Read the request body.
 	var refreshTokenRequest dto.RefreshTokenRequest
@@ -85,6 +257,7 @@ func (server *RestServer) RefreshTokenEndPoint(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	refreshTokenRequest.ClientInfo = clientInfo(c)
 
 	// Call to the service layer.
 	authService := server.serviceGroup.AuthService()
@@ -100,4 +273,285 @@ func (server *RestServer) RefreshTokenEndPoint(c *gin.Context) {
 
 	// Write the response body.
 	c.JSON(http.StatusOK, refreshTokenResponse)
+}
+
+func (server *RestServer) VerifyEmailEndPoint(c *gin.Context) {
+	// Read the request body.
+	var verifyEmailRequest dto.VerifyEmailRequest
+	if err := c.ShouldBindJSON(&verifyEmailRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Call to the service layer.
+	authService := server.serviceGroup.AuthService()
+	if err := authService.VerifyEmail(c, verifyEmailRequest); err != nil {
+		// Default error for this end point (Error translation is pending)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status": "email not verified",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Write the response body.
+	c.JSON(http.StatusOK, gin.H{"status": "the email has been verified"})
+}
+
+func (server *RestServer) RequestPasswordResetEndPoint(c *gin.Context) {
+	// Read the request body.
+	var passwordResetRequest dto.PasswordResetRequest
+	if err := c.ShouldBindJSON(&passwordResetRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Call to the service layer.
+	authService := server.serviceGroup.AuthService()
+	if err := authService.RequestPasswordReset(c, passwordResetRequest); err != nil {
+		// Default error for this end point (Error translation is pending)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status": "password reset not requested",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Write the response body. It does not reveal whether the email belongs to a user.
+	c.JSON(http.StatusAccepted, gin.H{
+		"status": "if the email belongs to an account, a password reset link has been sent to it",
+	})
+}
+
+func (server *RestServer) ConfirmPasswordResetEndPoint(c *gin.Context) {
+	// Read the request body.
+	var passwordResetConfirmRequest dto.PasswordResetConfirmRequest
+	if err := c.ShouldBindJSON(&passwordResetConfirmRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Call to the service layer.
+	authService := server.serviceGroup.AuthService()
+	err := authService.ConfirmPasswordReset(c, passwordResetConfirmRequest)
+	var passwordPolicyErr *types.PasswordPolicyError
+	if errors.As(err, &passwordPolicyErr) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status": "password not reset",
+			"message": err.Error(),
+			"violations": passwordPolicyErr.Violations,
+		})
+		return
+	}
+	if errors.Is(err, types.ErrPasswordChangedConcurrently) {
+		c.JSON(http.StatusConflict, gin.H{
+			"status": "password not reset",
+			"message": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		// Default error for this end point (Error translation is pending)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status": "password not reset",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Write the response body.
+	c.JSON(http.StatusOK, gin.H{"status": "the password has been reset"})
+}
+
+func (server *RestServer) ListSessionsEndPoint(c *gin.Context) {
+	// Read the authenticated user.
+	userAuthData, _ := UserAuthDataFromContext(c)
+
+	// Call to the service layer.
+	authService := server.serviceGroup.AuthService()
+	sessions, err := authService.ListSessions(c, *userAuthData)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status": "sessions not retrieved",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Write the response body.
+	c.JSON(http.StatusOK, gin.H{"sessions": sessions})
+}
+
+func (server *RestServer) RevokeSessionEndPoint(c *gin.Context) {
+	// Read the authenticated user and the path parameters.
+	userAuthData, _ := UserAuthDataFromContext(c)
+	sessionId := c.Param("id")
+
+	// Call to the service layer.
+	authService := server.serviceGroup.AuthService()
+	err := authService.RevokeSession(c, *userAuthData, sessionId)
+	if errors.Is(err, types.ErrSessionNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status": "session not revoked",
+			"message": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status": "session not revoked",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Write the response body.
+	c.JSON(http.StatusOK, gin.H{"status": "the session has been revoked"})
+}
+
+func (server *RestServer) RevokeOtherSessionsEndPoint(c *gin.Context) {
+	// Read the authenticated user.
+	userAuthData, _ := UserAuthDataFromContext(c)
+
+	// Call to the service layer.
+	authService := server.serviceGroup.AuthService()
+	if err := authService.RevokeOtherSessions(c, *userAuthData); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status": "sessions not revoked",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Write the response body.
+	c.JSON(http.StatusOK, gin.H{"status": "all other sessions have been revoked"})
+}
+
+func (server *RestServer) JWKSEndPoint(c *gin.Context) {
+	// Call to the service layer.
+	authService := server.serviceGroup.AuthService()
+	jwksResponse, err := authService.JWKS(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status": "keys not retrieved",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Write the response body. Verifiers may cache the keys for a short time.
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, jwksResponse)
+}
+
+func (server *RestServer) EnrollTotpEndPoint(c *gin.Context) {
+	// Read the authenticated user.
+	userAuthData, _ := UserAuthDataFromContext(c)
+
+	// Call to the service layer.
+	authService := server.serviceGroup.AuthService()
+	totpEnrollmentResponse, err := authService.EnrollTotp(c, *userAuthData)
+	if err != nil {
+		c.JSON(mfaErrorStatus(err), gin.H{
+			"status": "two-factor authentication not enrolled",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Write the response body.
+	c.JSON(http.StatusOK, totpEnrollmentResponse)
+}
+
+func (server *RestServer) ConfirmTotpEndPoint(c *gin.Context) {
+	// Read the authenticated user and the request body.
+	userAuthData, _ := UserAuthDataFromContext(c)
+	var totpConfirmRequest dto.TotpConfirmRequest
+	if err := c.ShouldBindJSON(&totpConfirmRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Call to the service layer.
+	authService := server.serviceGroup.AuthService()
+	recoveryCodesResponse, err := authService.ConfirmTotp(c, *userAuthData, totpConfirmRequest)
+	if err != nil {
+		c.JSON(mfaErrorStatus(err), gin.H{
+			"status": "two-factor authentication not enabled",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Write the response body.
+	c.JSON(http.StatusOK, recoveryCodesResponse)
+}
+
+func (server *RestServer) DisableTotpEndPoint(c *gin.Context) {
+	// Read the authenticated user and the request body.
+	userAuthData, _ := UserAuthDataFromContext(c)
+	var totpDisableRequest dto.TotpDisableRequest
+	if err := c.ShouldBindJSON(&totpDisableRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Call to the service layer.
+	authService := server.serviceGroup.AuthService()
+	if err := authService.DisableTotp(c, *userAuthData, totpDisableRequest); err != nil {
+		c.JSON(mfaErrorStatus(err), gin.H{
+			"status": "two-factor authentication not disabled",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Write the response body.
+	c.JSON(http.StatusOK, gin.H{"status": "two-factor authentication has been disabled"})
+}
+
+// writeLoginThrottledError writes the response of a login rejected by the brute-force
+// protection, and reports whether err was such a rejection. Locked accounts get their
+// own status and code.
+func writeLoginThrottledError(c *gin.Context, err error) bool {
+	var loginThrottledErr *types.LoginThrottledError
+	if !errors.As(err, &loginThrottledErr) {
+		return false
+	}
+	status, code := http.StatusTooManyRequests, "too_many_attempts"
+	if loginThrottledErr.Locked {
+		status, code = http.StatusLocked, "account_locked"
+	}
+	retryAfter := int64(math.Ceil(loginThrottledErr.RetryAfter.Seconds()))
+	c.Header("Retry-After", strconv.FormatInt(retryAfter, 10))
+	c.JSON(status, gin.H{
+		"status": "unathorized",
+		"code": code,
+		"message": err.Error(),
+		"retry_after": retryAfter,
+	})
+	return true
+}
+
+// mfaErrorStatus returns the HTTP status of an error of the two-factor authentication
+// end points.
+func mfaErrorStatus(err error) int {
+	switch {
+		case errors.Is(err, types.ErrInvalidMfaCode):
+			return http.StatusUnauthorized
+		case errors.Is(err, types.ErrMfaAlreadyEnabled):
+			return http.StatusConflict
+		case errors.Is(err, types.ErrMfaNotEnrolled):
+			return http.StatusNotFound
+		default:
+			return http.StatusInternalServerError
+	}
+}
+
+// clientInfo returns the description of the client that sent the request.
+func clientInfo(c *gin.Context) dto.ClientInfo {
+	return dto.ClientInfo{
+		UserAgent: c.Request.UserAgent(),
+		IpAddress: c.ClientIP(),
+	}
 }
\ No newline at end of file
@@ -0,0 +1,141 @@
+package rest
+
+import (
+	"errors"
+	"github.com/gabriel-98/bingo-backend/internal/application/types"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
+)
+
+// userAuthDataKey is the key under which the AuthenticationMiddleware stores the
+// authentication data of the user in the request context.
+const userAuthDataKey = "UserAuthData"
+
+// AuthenticationMiddleware authenticates the request using the access token of the
+// 'Authorization: Bearer <token>' header, and stores the authentication data of the
+// user in the request context (see UserAuthDataFromContext).
+// API keys are accepted too, in the 'X-Api-Key' header or as bearer tokens (they are
+// told apart by their prefix).
+// Requests with a missing, expired, malformed or revoked token are aborted with a 401
+// response.
+func (server *RestServer) AuthenticationMiddleware(c *gin.Context) {
+	// Read the API key or the access token.
+	apiKey := strings.TrimSpace(c.GetHeader("X-Api-Key"))
+	accessToken := ""
+	if apiKey == "" {
+		scheme, token, found := strings.Cut(c.GetHeader("Authorization"), " ")
+		token = strings.TrimSpace(token)
+		if !found || !strings.EqualFold(scheme, "Bearer") || token == "" {
+			abortUnauthorized(c, "missing_token", "a bearer access token is required")
+			return
+		}
+		if strings.HasPrefix(token, types.ApiKeyPrefix) {
+			apiKey = token
+		} else {
+			accessToken = token
+		}
+	}
+
+	// Call to the service layer.
+	var userAuthData *types.UserAuthData
+	var err error
+	if apiKey != "" {
+		userAuthData, err = server.serviceGroup.ApiKeyService().AuthenticateApiKey(c, apiKey)
+	} else {
+		userAuthData, err = server.serviceGroup.AuthService().Authenticate(c, accessToken)
+	}
+	switch {
+		case errors.Is(err, types.ErrTokenExpired):
+			abortUnauthorized(c, "expired_token", "the token has expired")
+			return
+		case errors.Is(err, types.ErrInvalidToken):
+			abortUnauthorized(c, "invalid_token", "the token is invalid")
+			return
+		case errors.Is(err, types.ErrTokenRevoked):
+			abortUnauthorized(c, "revoked_token", "the token has been revoked")
+			return
+		case err != nil:
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"status": "authentication not successful",
+				"message": "the request could not be authenticated",
+			})
+			return
+	}
+
+	// Set UserAuthData in the context.
+	c.Set(userAuthDataKey, userAuthData)
+
+	c.Next()
+}
+
+// UserAuthDataFromContext returns the authentication data stored in the request context
+// by the AuthenticationMiddleware. The second value is false if the request has not
+// been authenticated.
+func UserAuthDataFromContext(c *gin.Context) (*types.UserAuthData, bool) {
+	value, exists := c.Get(userAuthDataKey)
+	if !exists {
+		return nil, false
+	}
+	userAuthData, ok := value.(*types.UserAuthData)
+	return userAuthData, ok
+}
+
+// abortUnauthorized aborts the request with the 401 response shared by every
+// authentication failure. The message must be fixed for each code: the errors of the
+// service layer are not returned, since they may describe why a token was rejected.
+func abortUnauthorized(c *gin.Context, code string, message string) {
+	c.Header("WWW-Authenticate", `Bearer realm="bingo"`)
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+		"status": "unauthorized",
+		"code": code,
+		"message": message,
+	})
+}
+
+// RequireSession aborts the request with a 403 response if it was authenticated with an
+// API key. It protects the end points that manage the account and its credentials,
+// which are reserved to users' sessions. It must run after the
+// AuthenticationMiddleware.
+func (server *RestServer) RequireSession(c *gin.Context) {
+	userAuthData, ok := UserAuthDataFromContext(c)
+	if !ok {
+		abortUnauthorized(c, "missing_token", "a bearer access token is required")
+		return
+	}
+	if userAuthData.ApiKeyId != 0 {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"status": "forbidden",
+			"code": "api_key_not_allowed",
+			"message": types.ErrApiKeyNotAllowed.Error(),
+		})
+		return
+	}
+
+	c.Next()
+}
+
+// RequirePermissions returns a middleware that aborts the request with a 403 response
+// unless the authenticated user has been granted all the permissions. It must run after
+// the AuthenticationMiddleware.
+func (server *RestServer) RequirePermissions(permissions ...types.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userAuthData, ok := UserAuthDataFromContext(c)
+		if !ok {
+			abortUnauthorized(c, "missing_token", "a bearer access token is required")
+			return
+		}
+		for _, permission := range permissions {
+			if !userAuthData.HasPermission(permission) {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+					"status": "forbidden",
+					"code": "insufficient_permissions",
+					"message": "missing permission: " + string(permission),
+				})
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,259 @@
+package rest
+
+import (
+	"errors"
+	"github.com/gabriel-98/bingo-backend/internal/application/dto"
+	"github.com/gabriel-98/bingo-backend/internal/application/types"
+	domports "github.com/gabriel-98/bingo-backend/internal/domain/ports"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+func (server *RestServer) CreateGameEndPoint(c *gin.Context) {
+	// Read the authenticated user and the request body.
+	userAuthData, _ := UserAuthDataFromContext(c)
+	var createGameRequest dto.CreateGameRequest
+	if err := c.ShouldBindJSON(&createGameRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Call to the service layer.
+	gameService := server.serviceGroup.GameService()
+	gameResponse, err := gameService.CreateGame(c, *userAuthData, createGameRequest)
+	if err != nil {
+		c.JSON(gameErrorStatus(err), gin.H{
+			"status": "game not created",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Write the response body.
+	c.JSON(http.StatusCreated, gameResponse)
+}
+
+func (server *RestServer) GetGameEndPoint(c *gin.Context) {
+	// Read the path parameters.
+	gameId, ok := gameIdParam(c)
+	if !ok {
+		return
+	}
+
+	// Call to the service layer.
+	gameService := server.serviceGroup.GameService()
+	gameResponse, err := gameService.GetGame(c, gameId)
+	if err != nil {
+		c.JSON(gameErrorStatus(err), gin.H{
+			"status": "game not retrieved",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Write the response body.
+	c.JSON(http.StatusOK, gameResponse)
+}
+
+func (server *RestServer) OpenBuyingEndPoint(c *gin.Context) {
+	// Read the authenticated user and the path parameters.
+	userAuthData, _ := UserAuthDataFromContext(c)
+	gameId, ok := gameIdParam(c)
+	if !ok {
+		return
+	}
+
+	// Call to the service layer.
+	gameService := server.serviceGroup.GameService()
+	gameResponse, err := gameService.OpenBuying(c, *userAuthData, gameId)
+	if err != nil {
+		c.JSON(gameErrorStatus(err), gin.H{
+			"status": "buying not opened",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Write the response body.
+	c.JSON(http.StatusOK, gameResponse)
+}
+
+func (server *RestServer) StartGameEndPoint(c *gin.Context) {
+	// Read the authenticated user and the path parameters.
+	userAuthData, _ := UserAuthDataFromContext(c)
+	gameId, ok := gameIdParam(c)
+	if !ok {
+		return
+	}
+
+	// Call to the service layer.
+	gameService := server.serviceGroup.GameService()
+	gameResponse, err := gameService.StartGame(c, *userAuthData, gameId)
+	if err != nil {
+		c.JSON(gameErrorStatus(err), gin.H{
+			"status": "game not started",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Write the response body.
+	c.JSON(http.StatusOK, gameResponse)
+}
+
+// CancelGameEndPoint cancels a game, which allows refunding its card purchases.
+func (server *RestServer) CancelGameEndPoint(c *gin.Context) {
+	// Read the authenticated user and the path parameters.
+	userAuthData, _ := UserAuthDataFromContext(c)
+	gameId, ok := gameIdParam(c)
+	if !ok {
+		return
+	}
+
+	// Call to the service layer.
+	gameService := server.serviceGroup.GameService()
+	gameResponse, err := gameService.CancelGame(c, *userAuthData, gameId)
+	if err != nil {
+		c.JSON(gameErrorStatus(err), gin.H{
+			"status": "game not cancelled",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Write the response body.
+	c.JSON(http.StatusOK, gameResponse)
+}
+
+func (server *RestServer) DrawNumberEndPoint(c *gin.Context) {
+	// Read the authenticated user and the path parameters.
+	userAuthData, _ := UserAuthDataFromContext(c)
+	gameId, ok := gameIdParam(c)
+	if !ok {
+		return
+	}
+
+	// Call to the service layer.
+	gameService := server.serviceGroup.GameService()
+	drawResponse, err := gameService.DrawNumber(c, *userAuthData, gameId)
+	if err != nil {
+		c.JSON(gameErrorStatus(err), gin.H{
+			"status": "number not drawn",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Write the response body.
+	c.JSON(http.StatusCreated, drawResponse)
+}
+
+func (server *RestServer) GetWinnersEndPoint(c *gin.Context) {
+	// Read the path parameters.
+	gameId, ok := gameIdParam(c)
+	if !ok {
+		return
+	}
+
+	// Call to the service layer.
+	gameService := server.serviceGroup.GameService()
+	gameWinnerResponses, err := gameService.GetWinners(c, gameId)
+	if err != nil {
+		c.JSON(gameErrorStatus(err), gin.H{
+			"status": "winners not retrieved",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Write the response body.
+	c.JSON(http.StatusOK, gameWinnerResponses)
+}
+
+func (server *RestServer) PurchaseCardsEndPoint(c *gin.Context) {
+	// Read the authenticated user, the path parameters, the idempotency key and the
+	// request body.
+	userAuthData, _ := UserAuthDataFromContext(c)
+	gameId, ok := gameIdParam(c)
+	if !ok {
+		return
+	}
+	var purchaseCardsRequest dto.PurchaseCardsRequest
+	if err := c.ShouldBindJSON(&purchaseCardsRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	purchaseCardsRequest.IdempotencyKey = c.GetHeader("Idempotency-Key")
+
+	// Call to the service layer.
+	gameService := server.serviceGroup.GameService()
+	cardPurchaseResponse, err := gameService.PurchaseCards(c, *userAuthData, gameId, purchaseCardsRequest)
+	if err != nil {
+		c.JSON(gameErrorStatus(err), gin.H{
+			"status": "cards not purchased",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Write the response body.
+	status := http.StatusCreated
+	if cardPurchaseResponse.Replayed {
+		status = http.StatusOK
+	}
+	c.JSON(status, cardPurchaseResponse)
+}
+
+func (server *RestServer) ListCardsEndPoint(c *gin.Context) {
+	// Read the authenticated user and the path parameters.
+	userAuthData, _ := UserAuthDataFromContext(c)
+	gameId, ok := gameIdParam(c)
+	if !ok {
+		return
+	}
+
+	// Call to the service layer.
+	gameService := server.serviceGroup.GameService()
+	gameCardResponses, err := gameService.ListCards(c, *userAuthData, gameId)
+	if err != nil {
+		c.JSON(gameErrorStatus(err), gin.H{
+			"status": "cards not retrieved",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Write the response body.
+	c.JSON(http.StatusOK, gameCardResponses)
+}
+
+// gameIdParam reads the game id of the path. If it is invalid, a 400 response is written
+// and ok is false.
+func gameIdParam(c *gin.Context) (gameId int64, ok bool) {
+	gameId, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid game id"})
+		return 0, false
+	}
+	return gameId, true
+}
+
+// gameErrorStatus returns the HTTP status of an error of the game end points.
+func gameErrorStatus(err error) int {
+	switch {
+		case errors.Is(err, types.ErrNotGameHost), errors.Is(err, types.ErrNotJackpotHost),
+			errors.Is(err, types.ErrPaidGameNotAllowed):
+			return http.StatusForbidden
+		case errors.Is(err, types.ErrInvalidGameStatus), errors.Is(err, types.ErrPurchasesClosed),
+			errors.Is(err, types.ErrCardLimitExceeded), errors.Is(err, types.ErrIdempotencyKeyReused):
+			return http.StatusConflict
+		case errors.Is(err, types.ErrInsufficientFunds):
+			return http.StatusPaymentRequired
+		case errors.Is(err, domports.ErrNotFound):
+			return http.StatusNotFound
+		default:
+			// Default error for these end points (Error translation is pending)
+			return http.StatusBadRequest
+	}
+}
@@ -0,0 +1,85 @@
+package rest
+
+import (
+	"errors"
+	"github.com/gabriel-98/bingo-backend/internal/application/dto"
+	"github.com/gabriel-98/bingo-backend/internal/application/types"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+func (server *RestServer) CreateGameTemplateEndPoint(c *gin.Context) {
+	// Read the authenticated user and the request body.
+	userAuthData, _ := UserAuthDataFromContext(c)
+	var createGameTemplateRequest dto.CreateGameTemplateRequest
+	if err := c.ShouldBindJSON(&createGameTemplateRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Call to the service layer.
+	gameTemplateService := server.serviceGroup.GameTemplateService()
+	gameTemplateResponse, err := gameTemplateService.CreateTemplate(c, *userAuthData, createGameTemplateRequest)
+	if err != nil {
+		c.JSON(gameTemplateErrorStatus(err), gin.H{
+			"status": "game template not created",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Write the response body.
+	c.JSON(http.StatusCreated, gameTemplateResponse)
+}
+
+func (server *RestServer) ListGameTemplatesEndPoint(c *gin.Context) {
+	// Read the authenticated user.
+	userAuthData, _ := UserAuthDataFromContext(c)
+
+	// Call to the service layer.
+	gameTemplateService := server.serviceGroup.GameTemplateService()
+	gameTemplateResponses, err := gameTemplateService.ListTemplates(c, *userAuthData)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status": "game templates not retrieved",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Write the response body.
+	c.JSON(http.StatusOK, gameTemplateResponses)
+}
+
+func (server *RestServer) DisableGameTemplateEndPoint(c *gin.Context) {
+	// Read the authenticated user and the path parameters.
+	userAuthData, _ := UserAuthDataFromContext(c)
+	gameTemplateId, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid game template id"})
+		return
+	}
+
+	// Call to the service layer.
+	gameTemplateService := server.serviceGroup.GameTemplateService()
+	if err := gameTemplateService.DisableTemplate(c, *userAuthData, gameTemplateId); err != nil {
+		c.JSON(gameTemplateErrorStatus(err), gin.H{
+			"status": "game template not disabled",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Write the response body.
+	c.JSON(http.StatusOK, gin.H{"status": "the game template has been disabled"})
+}
+
+// gameTemplateErrorStatus returns the HTTP status of an error of the game template end
+// points.
+func gameTemplateErrorStatus(err error) int {
+	if errors.Is(err, types.ErrNotGameTemplateHost) {
+		return http.StatusForbidden
+	}
+	return gameErrorStatus(err)
+}
@@ -0,0 +1,87 @@
+package rest
+
+import (
+	"github.com/gabriel-98/bingo-backend/internal/application/dto"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+func (server *RestServer) CreateJackpotEndPoint(c *gin.Context) {
+	// Read the authenticated user and the request body.
+	userAuthData, _ := UserAuthDataFromContext(c)
+	var createJackpotRequest dto.CreateJackpotRequest
+	if err := c.ShouldBindJSON(&createJackpotRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Call to the service layer.
+	jackpotService := server.serviceGroup.JackpotService()
+	jackpotResponse, err := jackpotService.CreateJackpot(c, *userAuthData, createJackpotRequest)
+	if err != nil {
+		c.JSON(gameErrorStatus(err), gin.H{
+			"status": "jackpot not created",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Write the response body.
+	c.JSON(http.StatusCreated, jackpotResponse)
+}
+
+func (server *RestServer) GetJackpotEndPoint(c *gin.Context) {
+	// Read the path parameters.
+	jackpotId, ok := jackpotIdParam(c)
+	if !ok {
+		return
+	}
+
+	// Call to the service layer.
+	jackpotService := server.serviceGroup.JackpotService()
+	jackpotResponse, err := jackpotService.GetJackpot(c, jackpotId)
+	if err != nil {
+		c.JSON(gameErrorStatus(err), gin.H{
+			"status": "jackpot not retrieved",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Write the response body.
+	c.JSON(http.StatusOK, jackpotResponse)
+}
+
+func (server *RestServer) GetJackpotHistoryEndPoint(c *gin.Context) {
+	// Read the path parameters.
+	jackpotId, ok := jackpotIdParam(c)
+	if !ok {
+		return
+	}
+
+	// Call to the service layer.
+	jackpotService := server.serviceGroup.JackpotService()
+	jackpotHistory, err := jackpotService.GetJackpotHistory(c, jackpotId)
+	if err != nil {
+		c.JSON(gameErrorStatus(err), gin.H{
+			"status": "jackpot history not retrieved",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Write the response body.
+	c.JSON(http.StatusOK, jackpotHistory)
+}
+
+// jackpotIdParam reads the jackpot id of the path. If it is invalid, a 400 response is
+// written and ok is false.
+func jackpotIdParam(c *gin.Context) (jackpotId int64, ok bool) {
+	jackpotId, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid jackpot id"})
+		return 0, false
+	}
+	return jackpotId, true
+}
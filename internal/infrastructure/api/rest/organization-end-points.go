@@ -0,0 +1,136 @@
+package rest
+
+import (
+	"errors"
+	"github.com/gabriel-98/bingo-backend/internal/application/dto"
+	"github.com/gabriel-98/bingo-backend/internal/application/types"
+	domports "github.com/gabriel-98/bingo-backend/internal/domain/ports"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+func (server *RestServer) CreateOrganizationEndPoint(c *gin.Context) {
+	// Read the authenticated user and the request body.
+	userAuthData, _ := UserAuthDataFromContext(c)
+	var createOrganizationRequest dto.CreateOrganizationRequest
+	if err := c.ShouldBindJSON(&createOrganizationRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Call to the service layer.
+	organizationService := server.serviceGroup.OrganizationService()
+	organizationResponse, err := organizationService.CreateOrganization(c, *userAuthData, createOrganizationRequest)
+	if err != nil {
+		c.JSON(organizationErrorStatus(err), gin.H{
+			"status": "organization not created",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Write the response body.
+	c.JSON(http.StatusCreated, organizationResponse)
+}
+
+func (server *RestServer) ListOrganizationsEndPoint(c *gin.Context) {
+	// Read the authenticated user.
+	userAuthData, _ := UserAuthDataFromContext(c)
+
+	// Call to the service layer.
+	organizationService := server.serviceGroup.OrganizationService()
+	organizationResponses, err := organizationService.ListOrganizations(c, *userAuthData)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status": "organizations not retrieved",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Write the response body.
+	c.JSON(http.StatusOK, organizationResponses)
+}
+
+func (server *RestServer) SetOrganizationMemberEndPoint(c *gin.Context) {
+	// Read the authenticated user, the path parameters and the request body.
+	userAuthData, _ := UserAuthDataFromContext(c)
+	organizationId, userId, ok := organizationMemberParams(c)
+	if !ok {
+		return
+	}
+	var organizationMemberRequest dto.OrganizationMemberRequest
+	if err := c.ShouldBindJSON(&organizationMemberRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Call to the service layer.
+	organizationService := server.serviceGroup.OrganizationService()
+	organizationMemberResponse, err := organizationService.SetMember(c, *userAuthData, organizationId, userId, organizationMemberRequest)
+	if err != nil {
+		c.JSON(organizationErrorStatus(err), gin.H{
+			"status": "member not saved",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Write the response body.
+	c.JSON(http.StatusOK, organizationMemberResponse)
+}
+
+func (server *RestServer) RemoveOrganizationMemberEndPoint(c *gin.Context) {
+	// Read the authenticated user and the path parameters.
+	userAuthData, _ := UserAuthDataFromContext(c)
+	organizationId, userId, ok := organizationMemberParams(c)
+	if !ok {
+		return
+	}
+
+	// Call to the service layer.
+	organizationService := server.serviceGroup.OrganizationService()
+	if err := organizationService.RemoveMember(c, *userAuthData, organizationId, userId); err != nil {
+		c.JSON(organizationErrorStatus(err), gin.H{
+			"status": "member not removed",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Write the response body.
+	c.JSON(http.StatusOK, gin.H{"status": "the member has been removed"})
+}
+
+// organizationMemberParams reads the organization and user ids of the path. If they are
+// invalid, a 400 response is written and ok is false.
+func organizationMemberParams(c *gin.Context) (organizationId int64, userId int64, ok bool) {
+	organizationId, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid organization id"})
+		return 0, 0, false
+	}
+	userId, err = strconv.ParseInt(c.Param("userId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return 0, 0, false
+	}
+	return organizationId, userId, true
+}
+
+// organizationErrorStatus returns the HTTP status of an error of the organization end
+// points.
+func organizationErrorStatus(err error) int {
+	switch {
+		case errors.Is(err, types.ErrNotOrganizationOwner):
+			return http.StatusForbidden
+		case errors.Is(err, types.ErrOrganizationNameTaken), errors.Is(err, types.ErrLastOrganizationOwner):
+			return http.StatusConflict
+		case errors.Is(err, domports.ErrNotFound):
+			return http.StatusNotFound
+		default:
+			// Default error for these end points (Error translation is pending)
+			return http.StatusBadRequest
+	}
+}
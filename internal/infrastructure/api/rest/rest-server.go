@@ -1,8 +1,10 @@
 package rest
 
 import (
+	"expvar"
 	"fmt"
 	"github.com/gabriel-98/bingo-backend/internal/application"
+	"github.com/gabriel-98/bingo-backend/internal/application/types"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
@@ -13,10 +15,24 @@ type RestServer struct {
 	router *gin.Engine
 	serviceGroup *application.ServiceGroup
 	db *gorm.DB
+
+	// protected is the route group that all non-authentication end points hang from.
+	// Its requests are authenticated by the AuthenticationMiddleware.
+	protected *gin.RouterGroup
+
+	// sessionOnly is the route group of the protected end points that reject API keys
+	// (see RequireSession).
+	sessionOnly *gin.RouterGroup
 }
 
-func NewServer(port int, serviceGroup *application.ServiceGroup, db *gorm.DB) *RestServer {
+// NewServer creates the REST server. The client IP of a request is only read from the
+// X-Forwarded-For and X-Real-IP headers when the request comes from one of the trusted
+// proxies (IP addresses or CIDRs); with no trusted proxies, it is the remote address.
+func NewServer(port int, trustedProxies []string, serviceGroup *application.ServiceGroup, db *gorm.DB) (*RestServer, error) {
 	router := gin.New()
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		return nil, fmt.Errorf("invalid trusted proxies: %w", err)
+	}
 	server := &RestServer{
 		port: port,
 		router: router,
@@ -25,7 +41,7 @@ func NewServer(port int, serviceGroup *application.ServiceGroup, db *gorm.DB) *R
 	}
 	server.loadMiddlewares()
 	server.loadEndPoints()
-	return server
+	return server, nil
 }
 
 func (server *RestServer) Run() {
@@ -33,14 +49,104 @@ func (server *RestServer) Run() {
 }
 
 func (server *RestServer) loadEndPoints() {
+	// Every end point other than the public authentication ones requires an
+	// authenticated user.
+	server.protected = server.router.Group("/", server.AuthenticationMiddleware)
+	server.sessionOnly = server.protected.Group("/", server.RequireSession)
+
 	server.loadAuthenticationEndPoints()
+	server.loadAccountEndPoints()
+	server.loadApiKeyEndPoints()
+	server.loadOrganizationEndPoints()
+	server.loadWalletEndPoints()
+	server.loadGameEndPoints()
+	server.loadAdminEndPoints()
 }
 
 func (server *RestServer) loadAuthenticationEndPoints() {
 	server.router.POST("/auth/signup", server.SignupEndPoint)
 	server.router.POST("/auth/login", server.LoginEndPoint)
+	server.router.POST("/auth/login/mfa", server.LoginMfaEndPoint)
+	server.router.POST("/auth/guest", server.GuestLoginEndPoint)
+	server.router.GET("/auth/oidc/:provider/login", server.StartOidcLoginEndPoint)
+	server.router.GET("/auth/oidc/:provider/callback", server.OidcCallbackEndPoint)
 	server.router.POST("/auth/logout", server.LogoutEndPoint)
 	server.router.POST("/auth/refresh-token", server.RefreshTokenEndPoint)
+	server.router.POST("/auth/email/verify", server.VerifyEmailEndPoint)
+	server.router.POST("/auth/password-reset", server.RequestPasswordResetEndPoint)
+	server.router.POST("/auth/password-reset/confirm", server.ConfirmPasswordResetEndPoint)
+	server.router.GET("/.well-known/jwks.json", server.JWKSEndPoint)
+	server.sessionOnly.POST("/auth/logout-all", server.LogoutAllEndPoint)
+	server.sessionOnly.GET("/auth/sessions", server.ListSessionsEndPoint)
+	server.sessionOnly.DELETE("/auth/sessions", server.RevokeOtherSessionsEndPoint)
+	server.sessionOnly.DELETE("/auth/sessions/:id", server.RevokeSessionEndPoint)
+	server.sessionOnly.POST("/auth/mfa/totp", server.EnrollTotpEndPoint)
+	server.sessionOnly.POST("/auth/mfa/totp/confirm", server.ConfirmTotpEndPoint)
+	server.sessionOnly.DELETE("/auth/mfa/totp", server.DisableTotpEndPoint)
+}
+
+func (server *RestServer) loadAccountEndPoints() {
+	server.sessionOnly.POST("/account/upgrade", server.UpgradeGuestEndPoint)
+	server.sessionOnly.PUT("/account/password", server.ChangePasswordEndPoint)
+	server.sessionOnly.PUT("/account/username", server.ChangeUsernameEndPoint)
+	server.sessionOnly.PUT("/account/email", server.ChangeEmailEndPoint)
+	server.sessionOnly.POST("/account/email/verification", server.ResendEmailVerificationEndPoint)
+	server.sessionOnly.DELETE("/account", server.DeleteAccountEndPoint)
+}
+
+func (server *RestServer) loadApiKeyEndPoints() {
+	server.sessionOnly.POST("/api-keys", server.CreateApiKeyEndPoint)
+	server.sessionOnly.GET("/api-keys", server.ListApiKeysEndPoint)
+	server.sessionOnly.DELETE("/api-keys/:id", server.RevokeApiKeyEndPoint)
+}
+
+func (server *RestServer) loadOrganizationEndPoints() {
+	manageOrganizations := server.RequirePermissions(types.PermissionManageOrganizations)
+	server.sessionOnly.POST("/organizations", manageOrganizations, server.CreateOrganizationEndPoint)
+	server.sessionOnly.GET("/organizations", server.ListOrganizationsEndPoint)
+	server.sessionOnly.PUT("/organizations/:id/members/:userId", server.SetOrganizationMemberEndPoint)
+	server.sessionOnly.DELETE("/organizations/:id/members/:userId", server.RemoveOrganizationMemberEndPoint)
+}
+
+func (server *RestServer) loadWalletEndPoints() {
+	server.protected.GET("/wallet", server.GetWalletEndPoint)
+}
+
+func (server *RestServer) loadGameEndPoints() {
+	createGames := server.RequirePermissions(types.PermissionCreateGames)
+	runGames := server.RequirePermissions(types.PermissionRunGames)
+	joinGames := server.RequirePermissions(types.PermissionJoinGames)
+	server.protected.POST("/games", createGames, server.CreateGameEndPoint)
+	server.protected.GET("/games/:id", server.GetGameEndPoint)
+	server.protected.POST("/games/:id/buying", runGames, server.OpenBuyingEndPoint)
+	server.protected.POST("/games/:id/start", runGames, server.StartGameEndPoint)
+	server.protected.POST("/games/:id/draws", runGames, server.DrawNumberEndPoint)
+	server.protected.POST("/games/:id/cancel", server.CancelGameEndPoint) // Host with games:run, or games:manage (checked by the service).
+	server.protected.GET("/games/:id/winners", server.GetWinnersEndPoint)
+	server.protected.POST("/games/:id/cards", joinGames, server.PurchaseCardsEndPoint)
+	server.protected.GET("/games/:id/cards", joinGames, server.ListCardsEndPoint)
+	server.protected.POST("/jackpots", createGames, server.CreateJackpotEndPoint)
+	server.protected.GET("/jackpots/:id", server.GetJackpotEndPoint)
+	server.protected.GET("/jackpots/:id/history", server.GetJackpotHistoryEndPoint)
+	server.protected.POST("/game-templates", createGames, server.CreateGameTemplateEndPoint)
+	server.protected.GET("/game-templates", createGames, server.ListGameTemplatesEndPoint)
+	server.protected.DELETE("/game-templates/:id", createGames, server.DisableGameTemplateEndPoint)
+}
+
+func (server *RestServer) loadAdminEndPoints() {
+	// Roles are managed only from sessions, so that a leaked API key cannot grant them.
+	manageUsers := server.RequirePermissions(types.PermissionManageUsers)
+	server.sessionOnly.GET("/admin/users/:id/roles", manageUsers, server.GetUserRolesEndPoint)
+	server.sessionOnly.PUT("/admin/users/:id/roles", manageUsers, server.SetUserRolesEndPoint)
+
+	// Adjustments require an Idempotency-Key header, so that retries are recorded once.
+	manageWallets := server.RequirePermissions(types.PermissionManageWallets)
+	server.sessionOnly.POST("/admin/users/:id/wallet/adjustments", manageWallets, server.AdjustBalanceEndPoint)
+	server.sessionOnly.POST("/admin/ledger/transactions/:id/refund", manageWallets, server.RefundTransactionEndPoint)
+
+	// Metrics of the application (expvar), e.g., the number of purged refresh tokens.
+	viewReports := server.RequirePermissions(types.PermissionViewReports)
+	server.protected.GET("/admin/metrics", viewReports, gin.WrapH(expvar.Handler()))
 }
 
 func (server *RestServer) loadMiddlewares() {
@@ -0,0 +1,61 @@
+package rest
+
+import (
+	"github.com/gabriel-98/bingo-backend/internal/application/dto"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+func (server *RestServer) GetUserRolesEndPoint(c *gin.Context) {
+	// Read the path parameters.
+	userId, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	// Call to the service layer.
+	userService := server.serviceGroup.UserService()
+	userRolesResponse, err := userService.GetRoles(c, userId)
+	if err != nil {
+		// Default error for this end point (Error translation is pending)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status": "roles not retrieved",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Write the response body.
+	c.JSON(http.StatusOK, userRolesResponse)
+}
+
+func (server *RestServer) SetUserRolesEndPoint(c *gin.Context) {
+	// Read the path parameters and the request body.
+	userId, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+	var userRolesRequest dto.UserRolesRequest
+	if err := c.ShouldBindJSON(&userRolesRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Call to the service layer.
+	userService := server.serviceGroup.UserService()
+	userRolesResponse, err := userService.SetRoles(c, userId, userRolesRequest)
+	if err != nil {
+		// Default error for this end point (Error translation is pending)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status": "roles not updated",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Write the response body.
+	c.JSON(http.StatusOK, userRolesResponse)
+}
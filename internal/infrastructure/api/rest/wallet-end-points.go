@@ -0,0 +1,114 @@
+package rest
+
+import (
+	"errors"
+	"github.com/gabriel-98/bingo-backend/internal/application/dto"
+	"github.com/gabriel-98/bingo-backend/internal/application/types"
+	domports "github.com/gabriel-98/bingo-backend/internal/domain/ports"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+func (server *RestServer) GetWalletEndPoint(c *gin.Context) {
+	// Read the authenticated user.
+	userAuthData, _ := UserAuthDataFromContext(c)
+
+	// Call to the service layer.
+	walletService := server.serviceGroup.WalletService()
+	walletResponse, err := walletService.GetWallet(c, *userAuthData)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status": "wallet not retrieved",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Write the response body.
+	c.JSON(http.StatusOK, walletResponse)
+}
+
+func (server *RestServer) AdjustBalanceEndPoint(c *gin.Context) {
+	// Read the authenticated user, the path parameters, the idempotency key and the
+	// request body.
+	userAuthData, _ := UserAuthDataFromContext(c)
+	userId, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+	var walletAdjustmentRequest dto.WalletAdjustmentRequest
+	if err := c.ShouldBindJSON(&walletAdjustmentRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	walletAdjustmentRequest.IdempotencyKey = c.GetHeader("Idempotency-Key")
+	if walletAdjustmentRequest.IdempotencyKey == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing Idempotency-Key header"})
+		return
+	}
+
+	// Call to the service layer.
+	walletService := server.serviceGroup.WalletService()
+	ledgerTransactionResponse, err := walletService.AdjustBalance(c, *userAuthData, userId, walletAdjustmentRequest)
+	if err != nil {
+		c.JSON(walletErrorStatus(err), gin.H{
+			"status": "balance not adjusted",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Write the response body.
+	c.JSON(ledgerTransactionStatus(ledgerTransactionResponse), ledgerTransactionResponse)
+}
+
+func (server *RestServer) RefundTransactionEndPoint(c *gin.Context) {
+	// Read the authenticated user and the path parameters.
+	userAuthData, _ := UserAuthDataFromContext(c)
+	transactionId, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid transaction id"})
+		return
+	}
+
+	// Call to the service layer.
+	walletService := server.serviceGroup.WalletService()
+	ledgerTransactionResponse, err := walletService.RefundTransaction(c, *userAuthData, transactionId)
+	if err != nil {
+		c.JSON(walletErrorStatus(err), gin.H{
+			"status": "transaction not refunded",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Write the response body.
+	c.JSON(ledgerTransactionStatus(ledgerTransactionResponse), ledgerTransactionResponse)
+}
+
+// ledgerTransactionStatus returns 201 for a new transaction, and 200 for a transaction
+// that had already been recorded with the same idempotency key.
+func ledgerTransactionStatus(ledgerTransactionResponse *dto.LedgerTransactionResponse) int {
+	if ledgerTransactionResponse.Replayed {
+		return http.StatusOK
+	}
+	return http.StatusCreated
+}
+
+// walletErrorStatus returns the HTTP status of an error of the wallet end points.
+func walletErrorStatus(err error) int {
+	switch {
+		case errors.Is(err, types.ErrInsufficientFunds):
+			return http.StatusPaymentRequired
+		case errors.Is(err, types.ErrIdempotencyKeyReused), errors.Is(err, types.ErrNotRefundable),
+			errors.Is(err, types.ErrInvalidGameStatus):
+			return http.StatusConflict
+		case errors.Is(err, domports.ErrNotFound):
+			return http.StatusNotFound
+		default:
+			// Default error for these end points (Error translation is pending)
+			return http.StatusBadRequest
+	}
+}
@@ -1,6 +1,8 @@
 package providers
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"github.com/gabriel-98/bingo-backend/internal/application/types"
@@ -10,66 +12,144 @@ import (
 	"time"
 )
 
-// An AuthTokenManager creates and validates access and refresh tokens.
+// Token types, set in the 'typ' claim of the tokens.
+const (
+	refreshTokenType = "refresh"
+	accessTokenType = "access"
+	mfaTokenType = "mfa"
+)
+
+// An AuthTokenManager creates and validates access and refresh tokens, and the MFA
+// tokens that represent a login waiting for its second factor.
 type AuthTokenManager struct {
-	refreshTokenDuration time.Duration
-	refreshSigningKey string
-	accessTokenDuration time.Duration
-	accessSigningKey string
+	refresh tokenSettings
+	access tokenSettings
+	mfa tokenSettings
 	issuer string
+	clockSkew time.Duration
+}
+
+// A tokenSettings contains the settings of a token type.
+type tokenSettings struct {
+	// TokenType is the value of the 'typ' claim, which prevents accepting a token of a
+	// type as a token of another type, even if both types share the keys.
+	tokenType string
+	duration time.Duration
+	audience string
+	keys *jwtKeySet
 }
 
 // NewAuthTokenManager creates a new AuthTokenManager using the configuration set
-// for the token durations (access and refresh), the signing key (also for each token
-// type), and the token issuer.
-func NewAuthTokenManager(config config.AuthConfig) *AuthTokenManager {
+// for the token durations, audiences, and signing and verification keys (for each
+// token type), the token issuer, and the clock skew tolerated when validating the time
+// claims.
+// A non-nil error is returned if a key cannot be loaded.
+func NewAuthTokenManager(config config.AuthConfig) (*AuthTokenManager, error) {
+	refreshKeys, err := newJwtKeySet(config.RefreshToken)
+	if err != nil {
+		return nil, fmt.Errorf("refresh token keys: %w", err)
+	}
+	accessKeys, err := newJwtKeySet(config.AccessToken)
+	if err != nil {
+		return nil, fmt.Errorf("access token keys: %w", err)
+	}
+	mfaKeys, err := newJwtKeySet(config.MfaToken)
+	if err != nil {
+		return nil, fmt.Errorf("mfa token keys: %w", err)
+	}
 	return &AuthTokenManager{
-		refreshTokenDuration: config.RefreshToken.Duration,
-		refreshSigningKey: config.RefreshToken.SigningKey,
-		accessTokenDuration: config.AccessToken.Duration,
-		accessSigningKey: config.AccessToken.SigningKey,
+		refresh: tokenSettings{
+			tokenType: refreshTokenType,
+			duration: config.RefreshToken.Duration,
+			audience: config.RefreshToken.Audience,
+			keys: refreshKeys,
+		},
+		access: tokenSettings{
+			tokenType: accessTokenType,
+			duration: config.AccessToken.Duration,
+			audience: config.AccessToken.Audience,
+			keys: accessKeys,
+		},
+		mfa: tokenSettings{
+			tokenType: mfaTokenType,
+			duration: config.MfaToken.Duration,
+			audience: config.MfaToken.Audience,
+			keys: mfaKeys,
+		},
 		issuer: config.Issuer,
+		clockSkew: config.ClockSkew,
+	}, nil
+}
+
+// newTokenId returns a random token identifier, used as the 'jti' claim so that two
+// tokens issued for the same user within the same second are still different.
+func newTokenId() (string, error) {
+	idBytes := make([]byte, 16)
+	if _, err := rand.Read(idBytes); err != nil {
+		return "", fmt.Errorf("failed to generate token id: %w", err)
 	}
+	return hex.EncodeToString(idBytes), nil
 }
 
 // newToken creates a new JWT token encapsulating an entities.UserAuthData and the claims
-// 'exp', 'iat' and 'iss'.
-// The token signature is generated using the signing method HS256.
-func (s *AuthTokenManager) newToken(tokenDuration time.Duration, signingKey string, userAuthData types.UserAuthData) (string, error) {
+// 'typ', 'sub', 'aud', 'exp', 'nbf', 'iat', 'iss' and 'jti'.
+// The token signature is generated using the signing key of the token type, whose ID
+// is set in the 'kid' header.
+func (s *AuthTokenManager) newToken(settings tokenSettings, userAuthData types.UserAuthData) (string, error) {
 	// Creates a jwt.Token containing the header and payload data.
+	tokenId, err := newTokenId()
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
 	claims := AuthTokenPayload{
 		UserAuthData: userAuthData,
+		TokenType: settings.tokenType,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(tokenDuration)),
-			IssuedAt: jwt.NewNumericDate(time.Now()),
+			Subject: strconv.FormatInt(userAuthData.UserId, 10),
+			ExpiresAt: jwt.NewNumericDate(now.Add(settings.duration)),
+			NotBefore: jwt.NewNumericDate(now),
+			IssuedAt: jwt.NewNumericDate(now),
 			Issuer: s.issuer,
+			ID: tokenId,
 		},
 	}
-	jwttoken := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	if settings.audience != "" {
+		claims.Audience = jwt.ClaimStrings{ settings.audience }
+	}
 
 	// Generates the token signature from the header and payload, and returns the token
 	// string.
-	token, err := jwttoken.SignedString([]byte(signingKey))
+	token, err := settings.keys.sign(claims)
 	if err != nil {
 		return "", fmt.Errorf("failed to sign JWT token: %w", err)
 	}
 	return token, nil
 }
 
-// validateToken validates a JWT token, which includes checking the existence of the
-// claims 'exp' and 'iss', validating the signature, and that the token has not yet
-// expired.
-func (s *AuthTokenManager) validateToken(signingKey string, token string) (*types.UserAuthData, error) {
+// validateToken validates a JWT token, which includes validating the signature with
+// the verification key identified by the 'kid' header, and checking:
+// - The claims 'exp', 'iat', 'nbf' (with the configured clock skew as leeway).
+// - The claims 'iss' and 'aud' (if an audience is configured).
+// - The claim 'typ' matches the expected token type.
+// - The claim 'sub' matches the user ID, and the claim 'jti' is present.
+func (s *AuthTokenManager) validateToken(settings tokenSettings, token string) (*types.UserAuthData, error) {
 	// Converts the token string to a jwt.Token and validates it.
+	parserOptions := []jwt.ParserOption{
+		jwt.WithValidMethods(settings.keys.validMethods()),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(s.clockSkew),
+		jwt.WithIssuer(s.issuer),
+	}
+	if settings.audience != "" {
+		parserOptions = append(parserOptions, jwt.WithAudience(settings.audience))
+	}
 	jwttoken, err := jwt.ParseWithClaims(
 		token,
 		&AuthTokenPayload{},
-		func(token *jwt.Token) (interface{}, error) {
-			return []byte(signingKey), nil
-		},
-		jwt.WithExpirationRequired(),
-		//jwt.WithIssuedAt(), // This method does not work.
-		jwt.WithIssuer(s.issuer),
+		settings.keys.keyFunc,
+		parserOptions...,
 	)
 	switch {
 		case errors.Is(err, jwt.ErrInvalidKey):
@@ -88,10 +168,10 @@ func (s *AuthTokenManager) validateToken(signingKey string, token string) (*type
 
 			expirationTimeND, _ := jwttoken.Claims.GetExpirationTime() // Error is ignored.
 			expirationTime := expirationTimeND.Time
-			return nil, fmt.Errorf("expired token error: user-id=%d, expiration-time=%s, current-time=%s",
-				userId, expirationTime, time.Now())
+			return nil, fmt.Errorf("%w: user-id=%s, expiration-time=%s, current-time=%s",
+				types.ErrTokenExpired, userId, expirationTime, time.Now())
 		case err != nil:
-			return nil, fmt.Errorf("invalid token: %w",err)
+			return nil, fmt.Errorf("%w: %w", types.ErrInvalidToken, err)
 	}
 
 	// Returns an entities.UserAuthData containing the token's custom fields.
@@ -103,41 +183,77 @@ func (s *AuthTokenManager) validateToken(signingKey string, token string) (*type
 			jwttoken.Claims,
 		)
 	}
+
+	// Validates the claims not covered by the parser.
+	switch {
+		case tokenPayload.TokenType != settings.tokenType:
+			return nil, fmt.Errorf("%w: expected a token of type %q, found %q",
+				types.ErrInvalidToken, settings.tokenType, tokenPayload.TokenType)
+		case tokenPayload.Subject != strconv.FormatInt(tokenPayload.UserId, 10):
+			return nil, fmt.Errorf("%w: subject does not match the user id", types.ErrInvalidToken)
+		case tokenPayload.ID == "":
+			return nil, fmt.Errorf("%w: token id is required", types.ErrInvalidToken)
+	}
 	return &tokenPayload.UserAuthData, nil
 }
 
 // NewRefreshToken creates a new refresh token (Long-lived token).
 // The returned token will contain the custom data provided by userAuthData and the
-// following claims: Expiration time, Issued at, and Issuer.
-// This method creates tokens with the duration and signing key defined for refresh
-// tokens.
+// following claims: Token type, Subject, Audience, Expiration time, Not before, Issued
+// at, Issuer, and JWT ID.
+// This method creates tokens with the duration, audience and signing key defined for
+// refresh tokens.
 func (s *AuthTokenManager) NewRefreshToken(userAuthData types.UserAuthData) (string, error) {
-	return s.newToken(s.refreshTokenDuration, s.refreshSigningKey, userAuthData)
+	return s.newToken(s.refresh, userAuthData)
 }
 
 // NewAccessToken creates a new access token (Short-lived token).
 // The returned token will contain the custom data provided by userAuthData and the
-// following claims: Expiration time, Issued at, and Issuer.
-// This method creates tokens with the duration and signing key defined for access
-// tokens.
+// following claims: Token type, Subject, Audience, Expiration time, Not before, Issued
+// at, Issuer, and JWT ID.
+// This method creates tokens with the duration, audience and signing key defined for
+// access tokens.
 func (s *AuthTokenManager) NewAccessToken(userAuthData types.UserAuthData) (string, error) {
-	return s.newToken(s.accessTokenDuration, s.accessSigningKey, userAuthData)
+	return s.newToken(s.access, userAuthData)
 }
 
-// ValidateRefreshToken validates a JWT token, which includes checking the existence of
-// the claims: Expiration time and Issuer, validating the signature, and that the token
-// has not yet expired.
-// This method uses the refresh signing key for validation.
+// ValidateRefreshToken validates a JWT token, which includes validating the signature,
+// the time claims (the token has not yet expired), the issuer and audience, and that
+// the token is a refresh token.
+// This method uses the refresh verification keys for validation.
 func (s *AuthTokenManager) ValidateRefreshToken(tokenString string) (*types.UserAuthData, error) {
-	return s.validateToken(s.refreshSigningKey, tokenString)
+	return s.validateToken(s.refresh, tokenString)
 }
 
-// ValidateAccessToken validates a JWT token, which includes checking the existence of
-// the claims: Expiration time and Issuer, validating the signature, and that the token
-// has not yet expired.
-// This method uses the access signing key for validation.
+// ValidateAccessToken validates a JWT token, which includes validating the signature,
+// the time claims (the token has not yet expired), the issuer and audience, and that
+// the token is an access token.
+// This method uses the access verification keys for validation.
 func (s *AuthTokenManager) ValidateAccessToken(tokenString string) (*types.UserAuthData, error) {
-	return s.validateToken(s.accessSigningKey, tokenString)
+	return s.validateToken(s.access, tokenString)
+}
+
+// NewMfaToken creates a new MFA token (Short-lived token), which proves that the user
+// passed the first factor of a login and must now pass the second one.
+// This method creates tokens with the duration, audience and signing key defined for
+// MFA tokens.
+func (s *AuthTokenManager) NewMfaToken(userAuthData types.UserAuthData) (string, error) {
+	return s.newToken(s.mfa, userAuthData)
+}
+
+// ValidateMfaToken validates a JWT token, which includes validating the signature, the
+// time claims (the token has not yet expired), the issuer and audience, and that the
+// token is an MFA token.
+// This method uses the MFA verification keys for validation.
+func (s *AuthTokenManager) ValidateMfaToken(tokenString string) (*types.UserAuthData, error) {
+	return s.validateToken(s.mfa, tokenString)
+}
+
+// AccessTokenPublicKeys returns the public keys that verify access tokens, as JSON Web
+// Keys, so that other services can verify access tokens without the signing key.
+// Shared secrets (HS256) are never returned.
+func (s *AuthTokenManager) AccessTokenPublicKeys() []types.JSONWebKey {
+	return s.access.keys.publicKeys()
 }
 
 // IssuedAtAndExpiresAt retrieves the issue and expiration time of a JWT token.
@@ -172,12 +288,27 @@ func (s *AuthTokenManager) IssuedAtAndExpiresAt(token string) (time.Time, time.T
 	return issuedAt.Time, expirationTime.Time, nil
 }
 
+// TokenId retrieves the JWT ID ('jti') of a JWT token.
+// The token signature is not validated, therefore, a non-nil error is returned only
+// because the token is malformed.
+func (s *AuthTokenManager) TokenId(token string) (string, error) {
+	payload := AuthTokenPayload{}
+	if _, _, err := jwt.NewParser().ParseUnverified(token, &payload); err != nil {
+		return "", err
+	}
+	return payload.ID, nil
+}
+
 // An AuthTokenPayload contains the data to be stored in the authentication tokens.
 // Additionally, AuthTokenPayload implements the jwt.Claims interface.
 type AuthTokenPayload struct {
 	// UserAuthData contains the custom fields of the token.
 	types.UserAuthData
 
+	// TokenType ('typ') indicates whether the token is an access, a refresh or an MFA
+	// token.
+	TokenType string `json:"typ"`
+
 	// RegisteredClaims implements jwt.Claims, and its claims are described below:
 	//
 	// - 'exp': Expiration time
@@ -205,5 +336,9 @@ type AuthTokenPayload struct {
 	//   One or more strings indicating the applications or services for which the token
 	//   is intended.
 	//   Access function: GetAudience()
+	//
+	// - 'jti': JWT ID
+	//   String uniquely identifying the token.
+	//   Access field: ID
 	jwt.RegisteredClaims
 }
\ No newline at end of file
@@ -0,0 +1,236 @@
+package providers
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"github.com/gabriel-98/bingo-backend/internal/application/types"
+	"github.com/gabriel-98/bingo-backend/internal/config"
+	"github.com/golang-jwt/jwt/v5"
+	"math/big"
+	"os"
+	"slices"
+	"strings"
+)
+
+// A jwtKey is a key used to sign or verify JWT tokens with a specific signing method.
+// For HMAC methods the key is the shared secret ([]byte); otherwise it is a private key
+// (signing) or a public key (verification).
+type jwtKey struct {
+	id string
+	method jwt.SigningMethod
+	key any
+}
+
+// A jwtKeySet contains the key used to sign new tokens of a token type, and every key
+// accepted to verify them, indexed by key ID ('kid' header).
+// Keeping several verification keys allows rotating the signing key: tokens signed
+// with the previous key remain valid until they expire.
+type jwtKeySet struct {
+	signingKey jwtKey
+	verificationKeys map[string]jwtKey
+}
+
+// newJwtKeySet creates a jwtKeySet from the configuration of a token type.
+// HMAC methods use the configured signing key as shared secret; RSA, ECDSA and EdDSA
+// methods load the private key (and derive its public key) from a PEM file. Extra
+// verification keys are loaded from PEM files containing public keys.
+func newJwtKeySet(config config.JwtTokenConfig) (*jwtKeySet, error) {
+	signingKey, verificationKey, err := loadJwtSigningKey(config)
+	if err != nil {
+		return nil, err
+	}
+	keySet := &jwtKeySet{
+		signingKey: signingKey,
+		verificationKeys: map[string]jwtKey{ verificationKey.id: verificationKey },
+	}
+	for _, keyConfig := range config.VerificationKeys {
+		verificationKey, err := loadJwtVerificationKey(keyConfig)
+		if err != nil {
+			return nil, err
+		}
+		if _, found := keySet.verificationKeys[verificationKey.id]; found {
+			return nil, fmt.Errorf("duplicated JWT key id: %q", verificationKey.id)
+		}
+		keySet.verificationKeys[verificationKey.id] = verificationKey
+	}
+	return keySet, nil
+}
+
+// signingMethod returns the jwt.SigningMethod for a supported algorithm name. An empty
+// name defaults to HS256.
+func signingMethod(name string) (jwt.SigningMethod, error) {
+	if name == "" {
+		return jwt.SigningMethodHS256, nil
+	}
+	switch name {
+		case "HS256", "RS256", "ES256", "EdDSA":
+			return jwt.GetSigningMethod(name), nil
+		default:
+			return nil, fmt.Errorf("unsupported JWT signing method: %q", name)
+	}
+}
+
+// loadJwtSigningKey loads the signing key of a token type, and returns it along with
+// the key used to verify its signatures.
+func loadJwtSigningKey(config config.JwtTokenConfig) (jwtKey, jwtKey, error) {
+	method, err := signingMethod(config.SigningMethod)
+	if err != nil {
+		return jwtKey{}, jwtKey{}, err
+	}
+	if method == jwt.SigningMethodHS256 {
+		if config.SigningKey == "" {
+			return jwtKey{}, jwtKey{}, fmt.Errorf("missing JWT signing key")
+		}
+		key := jwtKey{ id: config.KeyId, method: method, key: []byte(config.SigningKey) }
+		return key, key, nil
+	}
+
+	// Asymmetric methods.
+	pemBytes, err := os.ReadFile(config.PrivateKeyFile)
+	if err != nil {
+		return jwtKey{}, jwtKey{}, fmt.Errorf("failed to read JWT private key: %w", err)
+	}
+	var privateKey crypto.Signer
+	switch method {
+		case jwt.SigningMethodRS256:
+			privateKey, err = jwt.ParseRSAPrivateKeyFromPEM(pemBytes)
+		case jwt.SigningMethodES256:
+			var ecKey *ecdsa.PrivateKey
+			ecKey, err = jwt.ParseECPrivateKeyFromPEM(pemBytes)
+			if err == nil && ecKey.Curve != elliptic.P256() {
+				err = fmt.Errorf("ES256 requires a P-256 key")
+			}
+			privateKey = ecKey
+		case jwt.SigningMethodEdDSA:
+			var edKey crypto.PrivateKey
+			edKey, err = jwt.ParseEdPrivateKeyFromPEM(pemBytes)
+			privateKey, _ = edKey.(crypto.Signer)
+	}
+	if err != nil {
+		return jwtKey{}, jwtKey{}, fmt.Errorf("failed to parse JWT private key: %w", err)
+	}
+	if config.KeyId == "" {
+		return jwtKey{}, jwtKey{}, fmt.Errorf("missing JWT key id for signing method %s", method.Alg())
+	}
+	signingKey := jwtKey{ id: config.KeyId, method: method, key: privateKey }
+	verificationKey := jwtKey{ id: config.KeyId, method: method, key: privateKey.Public() }
+	return signingKey, verificationKey, nil
+}
+
+// loadJwtVerificationKey loads a public key used only to verify signatures.
+func loadJwtVerificationKey(config config.JwtVerificationKeyConfig) (jwtKey, error) {
+	method, err := signingMethod(config.SigningMethod)
+	if err != nil {
+		return jwtKey{}, err
+	}
+	if config.KeyId == "" {
+		return jwtKey{}, fmt.Errorf("missing JWT verification key id")
+	}
+	pemBytes, err := os.ReadFile(config.PublicKeyFile)
+	if err != nil {
+		return jwtKey{}, fmt.Errorf("failed to read JWT public key %q: %w", config.KeyId, err)
+	}
+	var publicKey any
+	switch method {
+		case jwt.SigningMethodRS256:
+			publicKey, err = jwt.ParseRSAPublicKeyFromPEM(pemBytes)
+		case jwt.SigningMethodES256:
+			var ecKey *ecdsa.PublicKey
+			ecKey, err = jwt.ParseECPublicKeyFromPEM(pemBytes)
+			if err == nil && ecKey.Curve != elliptic.P256() {
+				err = fmt.Errorf("ES256 requires a P-256 key")
+			}
+			publicKey = ecKey
+		case jwt.SigningMethodEdDSA:
+			publicKey, err = jwt.ParseEdPublicKeyFromPEM(pemBytes)
+		default:
+			err = fmt.Errorf("verification keys require an asymmetric signing method")
+	}
+	if err != nil {
+		return jwtKey{}, fmt.Errorf("failed to parse JWT public key %q: %w", config.KeyId, err)
+	}
+	return jwtKey{ id: config.KeyId, method: method, key: publicKey }, nil
+}
+
+// sign creates a token containing the claims, signed with the signing key. The 'kid'
+// header is set if the signing key has an ID.
+func (ks *jwtKeySet) sign(claims jwt.Claims) (string, error) {
+	jwttoken := jwt.NewWithClaims(ks.signingKey.method, claims)
+	if ks.signingKey.id != "" {
+		jwttoken.Header["kid"] = ks.signingKey.id
+	}
+	return jwttoken.SignedString(ks.signingKey.key)
+}
+
+// keyFunc returns the verification key identified by the 'kid' header of the token
+// (tokens without 'kid' use the key without ID, if any). It implements jwt.Keyfunc.
+func (ks *jwtKeySet) keyFunc(token *jwt.Token) (any, error) {
+	keyId, _ := token.Header["kid"].(string)
+	key, found := ks.verificationKeys[keyId]
+	if !found {
+		return nil, fmt.Errorf("unknown JWT key id: %q", keyId)
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method for key %q: %s", keyId, token.Method.Alg())
+	}
+	return key.key, nil
+}
+
+// validMethods returns the names of the signing methods of the verification keys.
+func (ks *jwtKeySet) validMethods() []string {
+	methods := []string{}
+	seen := map[string]bool{}
+	for _, key := range ks.verificationKeys {
+		if !seen[key.method.Alg()] {
+			seen[key.method.Alg()] = true
+			methods = append(methods, key.method.Alg())
+		}
+	}
+	return methods
+}
+
+// publicKeys returns the verification keys that are public keys, as JSON Web Keys.
+// Shared secrets (HMAC) are never published.
+func (ks *jwtKeySet) publicKeys() []types.JSONWebKey {
+	encode := func(b []byte) string {
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+	keys := []types.JSONWebKey{}
+	for _, key := range ks.verificationKeys {
+		jwk := types.JSONWebKey{ Use: "sig", Kid: key.id, Alg: key.method.Alg() }
+		switch publicKey := key.key.(type) {
+			case *rsa.PublicKey:
+				jwk.Kty = "RSA"
+				jwk.N = encode(publicKey.N.Bytes())
+				jwk.E = encode(big.NewInt(int64(publicKey.E)).Bytes())
+			case *ecdsa.PublicKey:
+				// The uncompressed point encoding is 0x04 || X || Y.
+				ecdhKey, err := publicKey.ECDH()
+				if err != nil {
+					continue
+				}
+				point := ecdhKey.Bytes()
+				byteLen := (len(point) - 1) / 2
+				jwk.Kty = "EC"
+				jwk.Crv = publicKey.Curve.Params().Name
+				jwk.X = encode(point[1:1+byteLen])
+				jwk.Y = encode(point[1+byteLen:])
+			case ed25519.PublicKey:
+				jwk.Kty = "OKP"
+				jwk.Crv = "Ed25519"
+				jwk.X = encode(publicKey)
+			default:
+				continue
+		}
+		keys = append(keys, jwk)
+	}
+	slices.SortFunc(keys, func(a, b types.JSONWebKey) int {
+		return strings.Compare(a.Kid, b.Kid)
+	})
+	return keys
+}
@@ -0,0 +1,172 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	aports "github.com/gabriel-98/bingo-backend/internal/application/ports"
+	"github.com/gabriel-98/bingo-backend/internal/application/types"
+	"github.com/gabriel-98/bingo-backend/internal/config"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// NewMailer creates the mailer selected by the configuration: "smtp", "file" or
+// "memory".
+// A non-nil error is returned if the mailer is not supported or its configuration is
+// invalid.
+func NewMailer(config config.MailConfig) (aports.Mailer, error) {
+	from, err := mail.ParseAddress(config.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mail sender address: %w", err)
+	}
+	switch config.Sender {
+		case "smtp":
+			return NewSmtpMailer(from, config.Smtp), nil
+		case "file":
+			return NewFileMailer(from, config.File.Path), nil
+		case "memory":
+			return NewMemoryMailer(), nil
+		default:
+			return nil, fmt.Errorf("unsupported mail sender: %q", config.Sender)
+	}
+}
+
+// A Logger receives the errors that cannot be returned to the caller (e.g., those of
+// the emails sent in the background).
+type Logger interface {
+	Errorf(format string, args ...any)
+}
+
+// An AsyncMailer sends the emails of another mailer in the background: Send returns
+// immediately, and the delivery errors are logged. Requests do not wait for the mail
+// server, so their response time does not depend on whether an email was sent.
+type AsyncMailer struct {
+	mailer aports.Mailer
+	log Logger
+}
+
+func NewAsyncMailer(mailer aports.Mailer, log Logger) *AsyncMailer {
+	return &AsyncMailer{
+		mailer: mailer,
+		log: log,
+	}
+}
+
+// Send starts sending the message in the background. The context is not passed to the
+// underlying mailer, since the delivery outlives the request.
+func (m *AsyncMailer) Send(ctx context.Context, message types.MailMessage) error {
+	go func() {
+		if err := m.mailer.Send(context.Background(), message); err != nil {
+			m.log.Errorf("Failed to send email %q: %s", message.Subject, err)
+		}
+	}()
+	return nil
+}
+
+// formatMailMessage formats a message as an RFC 5322 plain-text email.
+func formatMailMessage(from *mail.Address, message types.MailMessage) []byte {
+	var buffer bytes.Buffer
+	fmt.Fprintf(&buffer, "From: %s\r\n", from.String())
+	fmt.Fprintf(&buffer, "To: %s\r\n", message.To)
+	fmt.Fprintf(&buffer, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", message.Subject))
+	fmt.Fprintf(&buffer, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buffer.WriteString("MIME-Version: 1.0\r\n")
+	buffer.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buffer.WriteString("\r\n")
+	buffer.WriteString(message.Body)
+	buffer.WriteString("\r\n")
+	return buffer.Bytes()
+}
+
+// An SmtpMailer sends emails through an SMTP server. The connection is upgraded with
+// STARTTLS when the server supports it, and authenticated if a username is configured.
+type SmtpMailer struct {
+	from *mail.Address
+	address string
+	auth smtp.Auth
+}
+
+func NewSmtpMailer(from *mail.Address, config config.SmtpConfig) *SmtpMailer {
+	var auth smtp.Auth
+	if config.Username != "" {
+		auth = smtp.PlainAuth("", config.Username, config.Password, config.Host)
+	}
+	return &SmtpMailer{
+		from: from,
+		address: net.JoinHostPort(config.Host, strconv.Itoa(config.Port)),
+		auth: auth,
+	}
+}
+
+func (m *SmtpMailer) Send(ctx context.Context, message types.MailMessage) error {
+	to, err := mail.ParseAddress(message.To)
+	if err != nil {
+		return fmt.Errorf("invalid recipient address: %w", err)
+	}
+	err = smtp.SendMail(m.address, m.auth, m.from.Address, []string{ to.Address }, formatMailMessage(m.from, message))
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// A FileMailer appends the emails to a file instead of sending them, which is useful in
+// development.
+type FileMailer struct {
+	from *mail.Address
+	path string
+	mutex sync.Mutex
+}
+
+func NewFileMailer(from *mail.Address, path string) *FileMailer {
+	return &FileMailer{
+		from: from,
+		path: path,
+	}
+}
+
+func (m *FileMailer) Send(ctx context.Context, message types.MailMessage) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	file, err := os.OpenFile(m.path, os.O_APPEND | os.O_CREATE | os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to write email: %w", err)
+	}
+	defer file.Close()
+	if _, err := file.Write(append(formatMailMessage(m.from, message), "\r\n"...)); err != nil {
+		return fmt.Errorf("failed to write email: %w", err)
+	}
+	return nil
+}
+
+// A MemoryMailer keeps the emails in memory instead of sending them, so that tests can
+// inspect them.
+type MemoryMailer struct {
+	mutex sync.Mutex
+	messages []types.MailMessage
+}
+
+func NewMemoryMailer() *MemoryMailer {
+	return &MemoryMailer{}
+}
+
+func (m *MemoryMailer) Send(ctx context.Context, message types.MailMessage) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.messages = append(m.messages, message)
+	return nil
+}
+
+// Messages returns the emails sent so far, in order.
+func (m *MemoryMailer) Messages() []types.MailMessage {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return append([]types.MailMessage(nil), m.messages...)
+}
@@ -0,0 +1,360 @@
+package providers
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gabriel-98/bingo-backend/internal/application/types"
+	"github.com/gabriel-98/bingo-backend/internal/config"
+	"github.com/golang-jwt/jwt/v5"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Minimum time between two downloads of the keys of a provider, so that tokens with
+// unknown key IDs cannot be used to flood the provider with requests.
+const oidcKeysRefreshInterval = time.Minute
+
+// An OidcManager is an OpenID Connect relying party: it builds the authorization
+// requests of the authorization code flow (with PKCE) and exchanges the returned codes
+// for ID tokens, which are verified with the keys published by the provider.
+// The metadata of each provider is discovered from its issuer
+// (/.well-known/openid-configuration) on first use.
+type OidcManager struct {
+	providers map[string]*oidcProvider
+	httpClient *http.Client
+}
+
+// An oidcProvider holds the configuration of a provider, and its discovered metadata
+// and keys.
+type oidcProvider struct {
+	config config.OidcProviderConfig
+
+	mutex sync.Mutex
+	metadata *oidcMetadata
+	keys map[string]any
+	keysFetchedAt time.Time
+}
+
+// oidcMetadata contains the members of the provider metadata used by the OidcManager.
+type oidcMetadata struct {
+	Issuer string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint string `json:"token_endpoint"`
+	JwksUri string `json:"jwks_uri"`
+}
+
+// oidcIdTokenClaims contains the claims of the ID tokens used by the OidcManager.
+// Some providers encode email_verified as a string, so it is decoded separately.
+type oidcIdTokenClaims struct {
+	jwt.RegisteredClaims
+	Nonce string `json:"nonce"`
+	Email string `json:"email"`
+	EmailVerified any `json:"email_verified"`
+	Name string `json:"name"`
+	PreferredUsername string `json:"preferred_username"`
+}
+
+func NewOidcManager(config config.OidcConfig) (*OidcManager, error) {
+	manager := &OidcManager{
+		providers: map[string]*oidcProvider{},
+		httpClient: &http.Client{ Timeout: 10 * time.Second },
+	}
+	for _, providerConfig := range config.Providers {
+		if providerConfig.Name == "" || providerConfig.Issuer == "" || providerConfig.ClientId == "" || providerConfig.RedirectUrl == "" {
+			return nil, fmt.Errorf("incomplete configuration of OpenID Connect provider %q", providerConfig.Name)
+		}
+		if _, found := manager.providers[providerConfig.Name]; found {
+			return nil, fmt.Errorf("duplicated OpenID Connect provider: %q", providerConfig.Name)
+		}
+		manager.providers[providerConfig.Name] = &oidcProvider{ config: providerConfig }
+	}
+	return manager, nil
+}
+
+// AuthorizationUrl returns the URL of the provider's authorization end point where the
+// user is redirected to log in. The code challenge is the S256 challenge of the PKCE
+// code verifier later passed to ExchangeCode.
+func (m *OidcManager) AuthorizationUrl(ctx context.Context, provider string, state string, nonce string, codeChallenge string) (string, error) {
+	p, found := m.providers[provider]
+	if !found {
+		return "", types.ErrUnknownOidcProvider
+	}
+	metadata, err := m.discover(ctx, p)
+	if err != nil {
+		return "", err
+	}
+	scopes := p.config.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{ "openid", "email", "profile" }
+	}
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", p.config.ClientId)
+	query.Set("redirect_uri", p.config.RedirectUrl)
+	query.Set("scope", strings.Join(scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", codeChallenge)
+	query.Set("code_challenge_method", "S256")
+	separator := "?"
+	if strings.Contains(metadata.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return metadata.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+// ExchangeCode exchanges an authorization code for the tokens of the user at the
+// provider, and returns the identity asserted by the ID token. The ID token must be
+// signed by the provider, issued by it for this client, and not expired. Checking its
+// nonce is up to the caller.
+func (m *OidcManager) ExchangeCode(ctx context.Context, provider string, code string, codeVerifier string) (*types.OidcIdentity, error) {
+	p, found := m.providers[provider]
+	if !found {
+		return nil, types.ErrUnknownOidcProvider
+	}
+	metadata, err := m.discover(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+
+	// Request the tokens. The client authenticates with HTTP basic authentication
+	// (client_secret_basic) if it has a secret.
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.config.RedirectUrl)
+	form.Set("client_id", p.config.ClientId)
+	form.Set("code_verifier", codeVerifier)
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+	if p.config.ClientSecret != "" {
+		request.SetBasicAuth(url.QueryEscape(p.config.ClientId), url.QueryEscape(p.config.ClientSecret))
+	}
+	var tokenResponse struct {
+		IdToken string `json:"id_token"`
+		Error string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	status, err := m.doJson(request, &tokenResponse)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK || tokenResponse.IdToken == "" {
+		return nil, fmt.Errorf("%w: token request rejected (%d %s: %s)", types.ErrOidcLoginFailed, status, tokenResponse.Error, tokenResponse.ErrorDescription)
+	}
+
+	// Verify the ID token.
+	claims := &oidcIdTokenClaims{}
+	_, err = jwt.ParseWithClaims(
+		tokenResponse.IdToken,
+		claims,
+		func(token *jwt.Token) (any, error) { return m.verificationKey(ctx, p, metadata, token) },
+		jwt.WithValidMethods([]string{ "RS256", "RS384", "RS512", "PS256", "ES256", "ES384", "EdDSA" }),
+		jwt.WithIssuer(metadata.Issuer),
+		jwt.WithAudience(p.config.ClientId),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid ID token: %s", types.ErrOidcLoginFailed, err)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: ID token without subject", types.ErrOidcLoginFailed)
+	}
+	emailVerified := false
+	switch verified := claims.EmailVerified.(type) {
+		case bool:
+			emailVerified = verified
+		case string:
+			emailVerified = verified == "true"
+	}
+	identity := &types.OidcIdentity{
+		Provider: provider,
+		Subject: claims.Subject,
+		Email: claims.Email,
+		EmailVerified: emailVerified,
+		Name: claims.Name,
+		PreferredUsername: claims.PreferredUsername,
+		Nonce: claims.Nonce,
+	}
+	return identity, nil
+}
+
+// discover returns the metadata of the provider, fetching it on first use. The issuer
+// of the metadata must be the configured one.
+func (m *OidcManager) discover(ctx context.Context, p *oidcProvider) (*oidcMetadata, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.metadata != nil {
+		return p.metadata, nil
+	}
+	discoveryUrl := strings.TrimSuffix(p.config.Issuer, "/") + "/.well-known/openid-configuration"
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, discoveryUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+	metadata := &oidcMetadata{}
+	status, err := m.doJson(request, metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover OpenID Connect provider %q: %w", p.config.Name, err)
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("failed to discover OpenID Connect provider %q: status %d", p.config.Name, status)
+	}
+	if metadata.Issuer != p.config.Issuer {
+		return nil, fmt.Errorf("OpenID Connect provider %q has an unexpected issuer: %q", p.config.Name, metadata.Issuer)
+	}
+	if metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" || metadata.JwksUri == "" {
+		return nil, fmt.Errorf("OpenID Connect provider %q has incomplete metadata", p.config.Name)
+	}
+	p.metadata = metadata
+	return metadata, nil
+}
+
+// verificationKey returns the key of the provider identified by the 'kid' header of the
+// token. The keys are downloaded again when the key ID is unknown, since providers
+// rotate their keys, but at most once per oidcKeysRefreshInterval.
+func (m *OidcManager) verificationKey(ctx context.Context, p *oidcProvider, metadata *oidcMetadata, token *jwt.Token) (any, error) {
+	keyId, _ := token.Header["kid"].(string)
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	key, found := p.keys[keyId]
+	if !found && time.Since(p.keysFetchedAt) >= oidcKeysRefreshInterval {
+		keys, err := m.fetchKeys(ctx, metadata.JwksUri)
+		if err != nil {
+			return nil, err
+		}
+		p.keys = keys
+		p.keysFetchedAt = time.Now()
+		key, found = p.keys[keyId]
+	}
+	if !found {
+		return nil, fmt.Errorf("unknown key id: %q", keyId)
+	}
+	return key, nil
+}
+
+// fetchKeys downloads the JSON Web Key Set of a provider, and returns its signature keys
+// indexed by key ID. Keys of unsupported types are skipped.
+func (m *OidcManager) fetchKeys(ctx context.Context, jwksUri string) (map[string]any, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, jwksUri, nil)
+	if err != nil {
+		return nil, err
+	}
+	var keySet struct {
+		Keys []types.JSONWebKey `json:"keys"`
+	}
+	status, err := m.doJson(request, &keySet)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch OpenID Connect keys: %w", err)
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch OpenID Connect keys: status %d", status)
+	}
+	keys := map[string]any{}
+	for _, jwk := range keySet.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		if key, err := parseJSONWebKey(jwk); err == nil {
+			keys[jwk.Kid] = key
+		}
+	}
+	return keys, nil
+}
+
+// doJson sends a request and decodes its JSON response body, whatever its status, which
+// is returned.
+func (m *OidcManager) doJson(request *http.Request, body any) (int, error) {
+	response, err := m.httpClient.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	bodyBytes, err := io.ReadAll(io.LimitReader(response.Body, 1 << 20))
+	if err != nil {
+		return 0, err
+	}
+	if err := json.Unmarshal(bodyBytes, body); err != nil && response.StatusCode == http.StatusOK {
+		return 0, fmt.Errorf("invalid response body: %w", err)
+	}
+	return response.StatusCode, nil
+}
+
+// parseJSONWebKey returns the public key of a JSON Web Key: an RSA, EC (P-256, P-384 or
+// P-521) or OKP (Ed25519) key.
+func parseJSONWebKey(jwk types.JSONWebKey) (any, error) {
+	decode := func(s string) ([]byte, error) {
+		return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	}
+	switch jwk.Kty {
+		case "RSA":
+			n, err := decode(jwk.N)
+			if err != nil {
+				return nil, err
+			}
+			e, err := decode(jwk.E)
+			if err != nil {
+				return nil, err
+			}
+			exponent := new(big.Int).SetBytes(e)
+			if len(n) == 0 || !exponent.IsInt64() || exponent.Int64() > 1 << 31 {
+				return nil, errors.New("invalid RSA key")
+			}
+			return &rsa.PublicKey{ N: new(big.Int).SetBytes(n), E: int(exponent.Int64()) }, nil
+		case "EC":
+			var curve elliptic.Curve
+			switch jwk.Crv {
+				case "P-256":
+					curve = elliptic.P256()
+				case "P-384":
+					curve = elliptic.P384()
+				case "P-521":
+					curve = elliptic.P521()
+				default:
+					return nil, fmt.Errorf("unsupported curve: %q", jwk.Crv)
+			}
+			x, err := decode(jwk.X)
+			if err != nil {
+				return nil, err
+			}
+			y, err := decode(jwk.Y)
+			if err != nil {
+				return nil, err
+			}
+			publicKey := &ecdsa.PublicKey{ Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y) }
+
+			// The conversion fails if the point is not on the curve.
+			if _, err := publicKey.ECDH(); err != nil {
+				return nil, errors.New("invalid EC key")
+			}
+			return publicKey, nil
+		case "OKP":
+			x, err := decode(jwk.X)
+			if err != nil {
+				return nil, err
+			}
+			if jwk.Crv != "Ed25519" || len(x) != ed25519.PublicKeySize {
+				return nil, errors.New("invalid OKP key")
+			}
+			return ed25519.PublicKey(x), nil
+		default:
+			return nil, fmt.Errorf("unsupported key type: %q", jwk.Kty)
+	}
+}
@@ -0,0 +1,203 @@
+package providers
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"github.com/gabriel-98/bingo-backend/internal/config"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"strings"
+)
+
+// A passwordHasher hashes and verifies passwords using a specific algorithm.
+// Hashes are self-describing (modular crypt or PHC string format), so hashes generated
+// by different algorithms or parameters can coexist.
+type passwordHasher interface {
+	// hash returns the encoded hash of the password.
+	hash(password string) (string, error)
+
+	// identifies reports whether the encoded hash was generated by this algorithm.
+	identifies(hashedPassword string) bool
+
+	// check compares whether the encoded hash is a valid hash for the password.
+	check(hashedPassword string, password string) bool
+
+	// outdated reports whether the encoded hash was generated with parameters other
+	// than the configured ones.
+	outdated(hashedPassword string) bool
+}
+
+// newPasswordHasher returns the passwordHasher for the configured algorithm.
+func newPasswordHasher(config config.PasswordHashingConfig) (passwordHasher, error) {
+	switch config.Algorithm {
+		case "bcrypt":
+			return newBcryptHasher(config.Bcrypt), nil
+		case "argon2id":
+			return newArgon2idHasher(config.Argon2id), nil
+		default:
+			return nil, fmt.Errorf("unsupported password hashing algorithm: %q", config.Algorithm)
+	}
+}
+
+// A bcryptHasher hashes passwords using the bcrypt algorithm. Its hashes use the
+// modular crypt format ($2a$10$...).
+type bcryptHasher struct {
+	cost int
+}
+
+// validateBcryptConfig validates the cost is within the range supported by bcrypt.
+func validateBcryptConfig(config config.BcryptConfig) error {
+	if config.Cost < bcrypt.MinCost || config.Cost > bcrypt.MaxCost {
+		return fmt.Errorf("invalid bcrypt config: cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+	return nil
+}
+
+func newBcryptHasher(config config.BcryptConfig) *bcryptHasher {
+	return &bcryptHasher{ cost: config.Cost }
+}
+
+func (h *bcryptHasher) hash(password string) (string, error) {
+	hashedPasswordBytes, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashedPasswordBytes), nil
+}
+
+func (h *bcryptHasher) identifies(hashedPassword string) bool {
+	return strings.HasPrefix(hashedPassword, "$2a$") ||
+		strings.HasPrefix(hashedPassword, "$2b$") ||
+		strings.HasPrefix(hashedPassword, "$2y$")
+}
+
+func (h *bcryptHasher) check(hashedPassword string, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password)) == nil
+}
+
+func (h *bcryptHasher) outdated(hashedPassword string) bool {
+	cost, err := bcrypt.Cost([]byte(hashedPassword))
+	return err != nil || cost != h.cost
+}
+
+// An argon2idHasher hashes passwords using the argon2id algorithm. Its hashes use the
+// PHC string format:
+//
+//	$argon2id$v=19$m=<memory>,t=<iterations>,p=<parallelism>$<salt>$<hash>
+//
+// where the salt and hash are encoded in unpadded standard base64.
+type argon2idHasher struct {
+	params argon2idParams
+	saltLength uint32
+}
+
+type argon2idParams struct {
+	memory uint32
+	iterations uint32
+	parallelism uint8
+	keyLength uint32
+}
+
+// validateArgon2idConfig validates every parameter is set: argon2id panics with zero
+// iterations or parallelism, and would generate weak hashes with no memory, salt or key.
+func validateArgon2idConfig(config config.Argon2idConfig) error {
+	switch {
+		case config.Memory == 0:
+			return fmt.Errorf("invalid argon2id config: memory must be positive")
+		case config.Iterations == 0:
+			return fmt.Errorf("invalid argon2id config: iterations must be positive")
+		case config.Parallelism == 0:
+			return fmt.Errorf("invalid argon2id config: parallelism must be positive")
+		case config.SaltLength == 0:
+			return fmt.Errorf("invalid argon2id config: saltLength must be positive")
+		case config.KeyLength == 0:
+			return fmt.Errorf("invalid argon2id config: keyLength must be positive")
+		default:
+			return nil
+	}
+}
+
+func newArgon2idHasher(config config.Argon2idConfig) *argon2idHasher {
+	return &argon2idHasher{
+		params: argon2idParams{
+			memory: config.Memory,
+			iterations: config.Iterations,
+			parallelism: config.Parallelism,
+			keyLength: config.KeyLength,
+		},
+		saltLength: config.SaltLength,
+	}
+}
+
+func (h *argon2idHasher) hash(password string) (string, error) {
+	salt := make([]byte, h.saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, h.params.iterations, h.params.memory,
+		h.params.parallelism, h.params.keyLength)
+	return fmt.Sprintf(
+		"$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version,
+		h.params.memory,
+		h.params.iterations,
+		h.params.parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (h *argon2idHasher) identifies(hashedPassword string) bool {
+	return strings.HasPrefix(hashedPassword, "$argon2id$")
+}
+
+func (h *argon2idHasher) check(hashedPassword string, password string) bool {
+	params, salt, key, err := h.decode(hashedPassword)
+	if err != nil {
+		return false
+	}
+	otherKey := argon2.IDKey([]byte(password), salt, params.iterations, params.memory,
+		params.parallelism, params.keyLength)
+	return subtle.ConstantTimeCompare(key, otherKey) == 1
+}
+
+func (h *argon2idHasher) outdated(hashedPassword string) bool {
+	params, salt, _, err := h.decode(hashedPassword)
+	if err != nil {
+		return true
+	}
+	return params != h.params || uint32(len(salt)) != h.saltLength
+}
+
+// decode parses an argon2id hash in PHC string format, and returns its parameters, salt
+// and key.
+func (h *argon2idHasher) decode(hashedPassword string) (argon2idParams, []byte, []byte, error) {
+	parts := strings.Split(hashedPassword, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return argon2idParams{}, nil, nil, fmt.Errorf("invalid argon2id hash format")
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return argon2idParams{}, nil, nil, fmt.Errorf("invalid argon2id version: %w", err)
+	}
+	if version != argon2.Version {
+		return argon2idParams{}, nil, nil, fmt.Errorf("unsupported argon2id version: %d", version)
+	}
+	params := argon2idParams{}
+	_, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.iterations, &params.parallelism)
+	if err != nil {
+		return argon2idParams{}, nil, nil, fmt.Errorf("invalid argon2id parameters: %w", err)
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return argon2idParams{}, nil, nil, fmt.Errorf("invalid argon2id salt: %w", err)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return argon2idParams{}, nil, nil, fmt.Errorf("invalid argon2id key: %w", err)
+	}
+	params.keyLength = uint32(len(key))
+	return params, salt, key, nil
+}
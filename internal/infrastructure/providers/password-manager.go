@@ -2,25 +2,74 @@
 package providers
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/gabriel-98/bingo-backend/internal/application/types"
+	"github.com/gabriel-98/bingo-backend/internal/config"
 	"strings"
 )
 
 // A PasswordManager allows generating and verifying passwords.
-// PasswordManager uses the bcrypt algorithm internally to hash and
-// compare passwords.
+// PasswordManager hashes new passwords with the configured algorithm (bcrypt or
+// argon2id), and verifies hashes generated by any supported algorithm, so old and new
+// hashes can coexist.
 type PasswordManager struct {
-	// Cost specifies the computational cost for hashing passwords
-	// using the bcrypt algorithm.
-	cost int
+	// Hasher hashes new passwords using the configured algorithm and parameters.
+	hasher passwordHasher
+
+	// Hashers contains a hasher for each supported algorithm, used to verify hashes
+	// generated by any of them.
+	hashers []passwordHasher
+
+	// Policy specifies the rules that new passwords must satisfy.
+	policy *PasswordPolicy
+
+	// DummyHashes contains, for each hasher in hashers, a hash of a random password
+	// generated with that hasher. Every check runs one comparison per supported
+	// algorithm (the real one for the algorithm of the hash, and a dummy one for the
+	// rest), so the response time does not depend on the algorithm of the stored hash,
+	// nor on whether there is a hash at all.
+	dummyHashes []string
 }
 
-// NewPasswordManager creates a new PasswordManager.
-func NewPasswordManager(cost int) *PasswordManager {
-	return &PasswordManager{
-		cost: cost,
+// NewPasswordManager creates a new PasswordManager using the configured hashing
+// algorithm and its parameters, and the password policy.
+// A non-nil error is returned if the configured algorithm is not supported, or if the
+// parameters of a supported algorithm are invalid (every algorithm is used to verify
+// hashes, so all of them must be configured).
+func NewPasswordManager(config config.PasswordHashingConfig) (*PasswordManager, error) {
+	if err := validateBcryptConfig(config.Bcrypt); err != nil {
+		return nil, err
+	}
+	if err := validateArgon2idConfig(config.Argon2id); err != nil {
+		return nil, err
 	}
+	hasher, err := newPasswordHasher(config)
+	if err != nil {
+		return nil, err
+	}
+	hashers := []passwordHasher{
+		newBcryptHasher(config.Bcrypt),
+		newArgon2idHasher(config.Argon2id),
+	}
+	dummyPassword := make([]byte, 32)
+	if _, err := rand.Read(dummyPassword); err != nil {
+		return nil, err
+	}
+	dummyHashes := make([]string, len(hashers))
+	for i, h := range hashers {
+		dummyHashes[i], err = h.hash(hex.EncodeToString(dummyPassword))
+		if err != nil {
+			return nil, fmt.Errorf("password hashing error: %w", err)
+		}
+	}
+	return &PasswordManager{
+		hasher: hasher,
+		hashers: hashers,
+		policy: NewPasswordPolicy(config.Policy),
+		dummyHashes: dummyHashes,
+	}, nil
 }
 
 // validateRestrictions validates that the password meets the requirements
@@ -41,28 +90,63 @@ func (s *PasswordManager) validateRestrictions(password string) error {
 	return nil
 }
 
-// HashPassword returns the hashed password. The password length must be less than or
-// equal to 72 bytes and must not contain null characters; otherwise, a non-nil error will
-// be returned.
+// ValidatePassword validates that a new password satisfies the password policy. If any
+// rule is violated, a *types.PasswordPolicyError listing every violated rule is
+// returned.
+func (s *PasswordManager) ValidatePassword(username string, password string) error {
+	violations := s.policy.Validate(username, password)
+	if len(violations) > 0 {
+		return &types.PasswordPolicyError{ Violations: violations }
+	}
+	return nil
+}
+
+// HashPassword returns the hashed password, generated with the configured algorithm.
+// The password length must be less than or equal to 72 bytes and must not contain null
+// characters; otherwise, a non-nil error will be returned.
 func (s *PasswordManager) HashPassword(password string) (string, error) {
 	if err := s.validateRestrictions(password); err != nil {
 		return "", err
 	}
-	hashedPasswordBytes, err := bcrypt.GenerateFromPassword([]byte(password), s.cost)
+	hashedPassword, err := s.hasher.hash(password)
 	if err != nil {
 		return "", fmt.Errorf("password hashing error: %w", err)
 	}
-	return string(hashedPasswordBytes), nil
+	return hashedPassword, nil
 }
 
 // CheckPassword compares whether a hashed password is a valid hash for a plaintext
-// password.
+// password. The hashed password may have been generated by any supported algorithm.
+// The password is also compared with the dummy hash of every other algorithm, so the
+// time taken is the same for any stored hash, including an empty or unknown one (e.g.,
+// accounts without a password).
 func (s *PasswordManager) CheckPassword(hashedPassword string, password string) bool {
 	if err := s.validateRestrictions(password); err != nil {
 		return false
 	}
-	if err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password)); err != nil {
-		return false
+	matched := false
+	valid := false
+	for i, hasher := range s.hashers {
+		if !matched && hasher.identifies(hashedPassword) {
+			matched = true
+			valid = hasher.check(hashedPassword, password)
+		} else {
+			hasher.check(s.dummyHashes[i], password)
+		}
 	}
-	return true
-}
\ No newline at end of file
+	return valid
+}
+
+// SimulateCheckPassword does the same work as CheckPassword, and discards the result.
+// It is used when there is no hash to compare with (e.g., unknown username), so that
+// the response time does not reveal it.
+func (s *PasswordManager) SimulateCheckPassword(password string) {
+	s.CheckPassword("", password)
+}
+
+// NeedsRehash reports whether a hashed password was generated with an algorithm or
+// parameters (e.g., cost) other than the configured ones, and therefore should be
+// replaced by a new hash the next time the plaintext password is available.
+func (s *PasswordManager) NeedsRehash(hashedPassword string) bool {
+	return !s.hasher.identifies(hashedPassword) || s.hasher.outdated(hashedPassword)
+}
@@ -0,0 +1,87 @@
+package providers
+
+import (
+	"github.com/gabriel-98/bingo-backend/internal/config"
+	"slices"
+	"testing"
+	"time"
+)
+
+// medianDuration runs f n times and returns the median of the elapsed times.
+func medianDuration(n int, f func()) time.Duration {
+	durations := make([]time.Duration, n)
+	for i := range durations {
+		start := time.Now()
+		f()
+		durations[i] = time.Since(start)
+	}
+	slices.Sort(durations)
+	return durations[n / 2]
+}
+
+// TestCheckPasswordTiming verifies that checking a password against a real hash (of any
+// supported algorithm) or against an empty hash takes about the same time as
+// SimulateCheckPassword, so the response time does not reveal whether a user exists,
+// has a password, or has a legacy hash.
+func TestCheckPasswordTiming(t *testing.T) {
+	hashingConfig := config.PasswordHashingConfig{
+		Algorithm: "argon2id",
+		Bcrypt: config.BcryptConfig{ Cost: 8 },
+		Argon2id: config.Argon2idConfig{
+			Memory: 8192,
+			Iterations: 2,
+			Parallelism: 1,
+			SaltLength: 16,
+			KeyLength: 32,
+		},
+	}
+	passwordManager, err := NewPasswordManager(hashingConfig)
+	if err != nil {
+		t.Fatalf("NewPasswordManager: %v", err)
+	}
+	password := "Correct-Horse-42"
+	argon2idHash, err := passwordManager.HashPassword(password)
+	if err != nil {
+		t.Fatalf("HashPassword: %v", err)
+	}
+	bcryptHash, err := newBcryptHasher(hashingConfig.Bcrypt).hash(password)
+	if err != nil {
+		t.Fatalf("bcrypt hash: %v", err)
+	}
+
+	const runs = 15
+	const maxRatio = 2.0
+	simulated := medianDuration(runs, func() {
+		passwordManager.SimulateCheckPassword(password)
+	})
+	cases := []struct {
+		name string
+		hashedPassword string
+	}{
+		{ name: "argon2id", hashedPassword: argon2idHash },
+		{ name: "bcrypt", hashedPassword: bcryptHash },
+		{ name: "empty", hashedPassword: "" },
+	}
+	for _, c := range cases {
+		checked := medianDuration(runs, func() {
+			passwordManager.CheckPassword(c.hashedPassword, password)
+		})
+		ratio := float64(checked) / float64(simulated)
+		if ratio > maxRatio || ratio < 1 / maxRatio {
+			t.Errorf(
+				"%s: CheckPassword took %v, SimulateCheckPassword took %v (ratio %.2f)",
+				c.name, checked, simulated, ratio,
+			)
+		}
+	}
+
+	if !passwordManager.CheckPassword(argon2idHash, password) {
+		t.Errorf("argon2id: valid password rejected")
+	}
+	if !passwordManager.CheckPassword(bcryptHash, password) {
+		t.Errorf("bcrypt: valid password rejected")
+	}
+	if passwordManager.CheckPassword("", password) {
+		t.Errorf("empty: password accepted without a hash")
+	}
+}
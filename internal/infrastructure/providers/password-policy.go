@@ -0,0 +1,116 @@
+package providers
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"github.com/gabriel-98/bingo-backend/internal/application/types"
+	"github.com/gabriel-98/bingo-backend/internal/config"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// commonPasswords contains the bundled blocklist of common passwords, one per line,
+// sorted from the most to the least common.
+//
+//go:embed common-passwords.txt
+var commonPasswords string
+
+// A PasswordPolicy validates passwords against a configurable set of rules.
+type PasswordPolicy struct {
+	minLength int
+	requireUppercase bool
+	requireLowercase bool
+	requireDigit bool
+	requireSymbol bool
+	disallowUsername bool
+
+	// blocklist contains the top-N common passwords in lower case.
+	blocklist map[string]struct{}
+}
+
+// NewPasswordPolicy creates a new PasswordPolicy using the configured rules. The
+// blocklist contains the first CommonPasswordsBlocklistSize entries of the bundled
+// common password list (all of them if the size exceeds the list length).
+func NewPasswordPolicy(config config.PasswordPolicyConfig) *PasswordPolicy {
+	blocklist := make(map[string]struct{})
+	scanner := bufio.NewScanner(strings.NewReader(commonPasswords))
+	for scanner.Scan() && len(blocklist) < config.CommonPasswordsBlocklistSize {
+		if entry := strings.TrimSpace(scanner.Text()); entry != "" {
+			blocklist[strings.ToLower(entry)] = struct{}{}
+		}
+	}
+	return &PasswordPolicy{
+		minLength: config.MinLength,
+		requireUppercase: config.RequireUppercase,
+		requireLowercase: config.RequireLowercase,
+		requireDigit: config.RequireDigit,
+		requireSymbol: config.RequireSymbol,
+		disallowUsername: config.DisallowUsername,
+		blocklist: blocklist,
+	}
+}
+
+// Validate returns every rule violated by the password. The returned slice is empty if
+// the password satisfies the policy.
+// The username is only used by the rule that disallows the username inside the
+// password; comparisons against the username and the blocklist are case-insensitive.
+func (p *PasswordPolicy) Validate(username string, password string) []types.PasswordPolicyViolation {
+	violations := []types.PasswordPolicyViolation{}
+	addViolation := func(rule string, format string, args ...any) {
+		violations = append(violations, types.PasswordPolicyViolation{
+			Rule: rule,
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	// Hard restrictions imposed by the hashing algorithms.
+	if len(password) > 72 {
+		addViolation("max_length", "password is %d bytes, exceeds 72 bytes", len(password))
+	}
+	if strings.ContainsRune(password, '\x00') {
+		addViolation("null_character", "password contains null characters")
+	}
+
+	// Length and character classes.
+	if utf8.RuneCountInString(password) < p.minLength {
+		addViolation("min_length", "password must be at least %d characters long", p.minLength)
+	}
+	hasUppercase, hasLowercase, hasDigit, hasSymbol := false, false, false, false
+	for _, r := range password {
+		switch {
+			case unicode.IsUpper(r):
+				hasUppercase = true
+			case unicode.IsLower(r):
+				hasLowercase = true
+			case unicode.IsDigit(r):
+				hasDigit = true
+			case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+				hasSymbol = true
+		}
+	}
+	if p.requireUppercase && !hasUppercase {
+		addViolation("uppercase", "password must contain an uppercase letter")
+	}
+	if p.requireLowercase && !hasLowercase {
+		addViolation("lowercase", "password must contain a lowercase letter")
+	}
+	if p.requireDigit && !hasDigit {
+		addViolation("digit", "password must contain a digit")
+	}
+	if p.requireSymbol && !hasSymbol {
+		addViolation("symbol", "password must contain a symbol")
+	}
+
+	// Username and common passwords.
+	lowerPassword := strings.ToLower(password)
+	if p.disallowUsername && username != "" && strings.Contains(lowerPassword, strings.ToLower(username)) {
+		addViolation("contains_username", "password must not contain the username")
+	}
+	if _, found := p.blocklist[lowerPassword]; found {
+		addViolation("common_password", "password is too common")
+	}
+
+	return violations
+}
@@ -0,0 +1,136 @@
+package providers
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"github.com/gabriel-98/bingo-backend/internal/config"
+	"math/big"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// totpSecretSize is the size in bytes of the TOTP secrets (160 bits, as recommended by
+// RFC 4226 for HMAC-SHA1).
+const totpSecretSize = 20
+
+// recoveryCodeAlphabet contains the characters of the recovery codes, excluding those
+// that are easily confused (0, 1, i, l and o).
+const recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+// A TotpManager generates TOTP secrets and validates TOTP codes (RFC 6238, with
+// HMAC-SHA1), and generates the recovery codes of the two-factor authentication.
+type TotpManager struct {
+	issuer string
+	digits int
+	period time.Duration
+	skew int
+	recoveryCodes int
+}
+
+// NewTotpManager creates a new TotpManager using the configured issuer (shown by the
+// authenticator apps), number of digits, period, accepted clock skew (in periods) and
+// number of recovery codes.
+// A non-nil error is returned if the configuration is invalid.
+func NewTotpManager(config config.TotpConfig) (*TotpManager, error) {
+	if config.Digits < 6 || config.Digits > 8 {
+		return nil, fmt.Errorf("unsupported number of TOTP digits: %d", config.Digits)
+	}
+	if config.Period < time.Second {
+		return nil, fmt.Errorf("invalid TOTP period: %s", config.Period)
+	}
+	if config.Skew < 0 || config.RecoveryCodes <= 0 {
+		return nil, fmt.Errorf("invalid TOTP skew or number of recovery codes")
+	}
+	return &TotpManager{
+		issuer: config.Issuer,
+		digits: config.Digits,
+		period: config.Period,
+		skew: config.Skew,
+		recoveryCodes: config.RecoveryCodes,
+	}, nil
+}
+
+// NewSecret returns a random TOTP secret encoded in base32 (without padding), as
+// expected by the authenticator apps.
+func (s *TotpManager) NewSecret() (string, error) {
+	secret := make([]byte, totpSecretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate TOTP secret: %w", err)
+	}
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(secret), nil
+}
+
+// ProvisioningUri returns the otpauth:// URI of a secret, usually shown as a QR code to
+// be scanned by the authenticator apps.
+func (s *TotpManager) ProvisioningUri(accountName string, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", s.issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", strconv.Itoa(s.digits))
+	query.Set("period", strconv.Itoa(int(s.period / time.Second)))
+	label := url.PathEscape(s.issuer + ":" + accountName)
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// ValidateCode reports whether a code is valid for the secret at the given time,
+// accepting the codes of the configured number of periods before and after it. If the
+// code is valid, the time step (period number) it belongs to is also returned, so that
+// callers can reject the reuse of a code.
+func (s *TotpManager) ValidateCode(secret string, code string, at time.Time) (int64, bool) {
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.ToUpper(secret))
+	if err != nil || len(code) != s.digits {
+		return 0, false
+	}
+	step := at.Unix() / int64(s.period / time.Second)
+	for offset := -s.skew; offset <= s.skew; offset++ {
+		expected := s.hotp(key, step + int64(offset))
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step + int64(offset), true
+		}
+	}
+	return 0, false
+}
+
+// NewRecoveryCodes returns the configured number of random recovery codes, formatted
+// as two groups of five characters (e.g., "k7dm2-xq9pa").
+func (s *TotpManager) NewRecoveryCodes() ([]string, error) {
+	codes := make([]string, s.recoveryCodes)
+	for i := range codes {
+		code := make([]byte, 10)
+		for j := range code {
+			index, err := rand.Int(rand.Reader, big.NewInt(int64(len(recoveryCodeAlphabet))))
+			if err != nil {
+				return nil, fmt.Errorf("failed to generate recovery code: %w", err)
+			}
+			code[j] = recoveryCodeAlphabet[index.Int64()]
+		}
+		codes[i] = string(code[:5]) + "-" + string(code[5:])
+	}
+	return codes, nil
+}
+
+// hotp returns the HOTP code (RFC 4226) of the key for a counter.
+func (s *TotpManager) hotp(key []byte, counter int64) string {
+	message := make([]byte, 8)
+	binary.BigEndian.PutUint64(message, uint64(counter))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(message)
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation.
+	offset := sum[len(sum) - 1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset + 4]) & 0x7fffffff
+	modulo := uint32(1)
+	for i := 0; i < s.digits; i++ {
+		modulo *= 10
+	}
+	return fmt.Sprintf("%0*d", s.digits, value % modulo)
+}
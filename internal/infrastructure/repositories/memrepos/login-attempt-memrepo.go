@@ -0,0 +1,80 @@
+package memrepos
+
+import (
+	"context"
+	"github.com/gabriel-98/bingo-backend/internal/domain/entities"
+	"sync"
+	"time"
+)
+
+// A LoginAttemptMemRepo stores the failed login counters in memory. Counters are only
+// seen by the instance that registered them.
+type LoginAttemptMemRepo struct {
+	mutex sync.Mutex
+	loginAttempts map[string]entities.LoginAttempt
+}
+
+func NewLoginAttemptRepo() *LoginAttemptMemRepo {
+	return &LoginAttemptMemRepo{
+		loginAttempts: make(map[string]entities.LoginAttempt),
+	}
+}
+
+// Find returns the failed logins registered for the key, or an empty record if there
+// are none.
+func (repo *LoginAttemptMemRepo) Find(ctx context.Context, key string) (*entities.LoginAttempt, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	loginAttempt, found := repo.loginAttempts[key]
+	if !found {
+		loginAttempt = entities.LoginAttempt{ Key: key }
+	}
+	return &loginAttempt, nil
+}
+
+// RecordFailure increments the failure counter of the key and returns the updated
+// record. Failures older than resetBefore are forgotten, so the counter restarts at one.
+func (repo *LoginAttemptMemRepo) RecordFailure(ctx context.Context, key string, at time.Time, resetBefore time.Time) (*entities.LoginAttempt, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	loginAttempt, found := repo.loginAttempts[key]
+	if !found || loginAttempt.LastFailureAt.Before(resetBefore) {
+		loginAttempt = entities.LoginAttempt{ Key: key, LockedUntil: loginAttempt.LockedUntil }
+	}
+	loginAttempt.Failures++
+	loginAttempt.LastFailureAt = at
+	repo.loginAttempts[key] = loginAttempt
+	return &loginAttempt, nil
+}
+
+// Release decrements the failure counter of the key, undoing a failure recorded for an
+// attempt that did not fail.
+func (repo *LoginAttemptMemRepo) Release(ctx context.Context, key string) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	loginAttempt, found := repo.loginAttempts[key]
+	if found && loginAttempt.Failures > 0 {
+		loginAttempt.Failures--
+		repo.loginAttempts[key] = loginAttempt
+	}
+	return nil
+}
+
+// Lock locks the key out until the given time, and restarts its failure counter.
+func (repo *LoginAttemptMemRepo) Lock(ctx context.Context, key string, until time.Time) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	loginAttempt := repo.loginAttempts[key]
+	loginAttempt.Key = key
+	loginAttempt.Failures = 0
+	loginAttempt.LockedUntil = &until
+	repo.loginAttempts[key] = loginAttempt
+	return nil
+}
+
+func (repo *LoginAttemptMemRepo) Reset(ctx context.Context, key string) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	delete(repo.loginAttempts, key)
+	return nil
+}
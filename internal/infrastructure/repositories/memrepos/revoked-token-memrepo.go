@@ -0,0 +1,45 @@
+// Package memrepos provides in-memory implementations of repositories, suitable for
+// single-instance deployments.
+package memrepos
+
+import (
+	"context"
+	"github.com/gabriel-98/bingo-backend/internal/domain/entities"
+	"sync"
+	"time"
+)
+
+// A RevokedTokenMemRepo stores the revoked access tokens in memory. Revocations are
+// only seen by the instance that registered them.
+type RevokedTokenMemRepo struct {
+	mutex sync.Mutex
+	expiresAt map[string]time.Time
+}
+
+func NewRevokedTokenRepo() *RevokedTokenMemRepo {
+	return &RevokedTokenMemRepo{
+		expiresAt: make(map[string]time.Time),
+	}
+}
+
+// Create registers a revoked token, and forgets the revoked tokens that have already
+// expired, since expired tokens are rejected anyway.
+func (repo *RevokedTokenMemRepo) Create(ctx context.Context, revokedToken entities.RevokedToken) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	for tokenId, expiresAt := range repo.expiresAt {
+		if !expiresAt.After(revokedToken.RevokedAt) {
+			delete(repo.expiresAt, tokenId)
+		}
+	}
+	repo.expiresAt[revokedToken.TokenId] = revokedToken.ExpiresAt
+	return nil
+}
+
+// Exists reports whether a token has been revoked and has not expired at the given time.
+func (repo *RevokedTokenMemRepo) Exists(ctx context.Context, tokenId string, now time.Time) (bool, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+	expiresAt, found := repo.expiresAt[tokenId]
+	return found && expiresAt.After(now), nil
+}
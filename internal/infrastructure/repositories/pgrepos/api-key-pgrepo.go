@@ -0,0 +1,105 @@
+package pgrepos
+
+import (
+	"context"
+	"github.com/gabriel-98/bingo-backend/internal/domain/entities"
+	"time"
+)
+
+type ApiKeyPgRepo struct {}
+
+func NewApiKeyRepo() *ApiKeyPgRepo {
+	return &ApiKeyPgRepo{}
+}
+
+func (repo *ApiKeyPgRepo) Create(ctx context.Context, apiKey entities.ApiKey) (*entities.ApiKey, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return nil, err
+	}
+	result := db.Create(&apiKey)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &apiKey, nil
+}
+
+func (repo *ApiKeyPgRepo) FindById(ctx context.Context, id int64) (*entities.ApiKey, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return nil, err
+	}
+	var apiKey entities.ApiKey
+	result := db.Where("id = ?", id).First(&apiKey)
+	if result.Error != nil {
+		return nil, translateError(result.Error)
+	}
+	return &apiKey, nil
+}
+
+// FindByKey returns the API key with this digest.
+func (repo *ApiKeyPgRepo) FindByKey(ctx context.Context, keyHash string) (*entities.ApiKey, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return nil, err
+	}
+	var apiKey entities.ApiKey
+	result := db.Where("key_hash = ?", keyHash).First(&apiKey)
+	if result.Error != nil {
+		return nil, translateError(result.Error)
+	}
+	return &apiKey, nil
+}
+
+// FindByUserId returns the API keys of a user (not those of its organizations).
+func (repo *ApiKeyPgRepo) FindByUserId(ctx context.Context, userId int64) ([]entities.ApiKey, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return nil, err
+	}
+	var apiKeys []entities.ApiKey
+	result := db.Where("user_id = ?", userId).Order("created_at").Find(&apiKeys)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return apiKeys, nil
+}
+
+func (repo *ApiKeyPgRepo) FindByOrganizationId(ctx context.Context, organizationId int64) ([]entities.ApiKey, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return nil, err
+	}
+	var apiKeys []entities.ApiKey
+	result := db.Where("organization_id = ?", organizationId).Order("created_at").Find(&apiKeys)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return apiKeys, nil
+}
+
+// Revoke marks an API key as revoked. Revoking a key twice keeps the first time.
+func (repo *ApiKeyPgRepo) Revoke(ctx context.Context, id int64, revokedAt time.Time) error {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return err
+	}
+	result := db.Model(&entities.ApiKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", revokedAt)
+	return result.Error
+}
+
+// TouchLastUsed registers the use of an API key. The time of the last use is only
+// updated if it is older than the precision, so that busy keys do not cause a write on
+// every request.
+func (repo *ApiKeyPgRepo) TouchLastUsed(ctx context.Context, id int64, usedAt time.Time, precision time.Duration) error {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return err
+	}
+	result := db.Model(&entities.ApiKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", id, usedAt.Add(-precision)).
+		Update("last_used_at", usedAt)
+	return result.Error
+}
@@ -0,0 +1,118 @@
+package pgrepos
+
+import (
+	"context"
+	"github.com/gabriel-98/bingo-backend/internal/domain/entities"
+)
+
+type GameCardPgRepo struct {}
+
+func NewGameCardRepo() *GameCardPgRepo {
+	return &GameCardPgRepo{}
+}
+
+func (repo *GameCardPgRepo) CreateBatch(ctx context.Context, gameCards []entities.GameCard) ([]entities.GameCard, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return nil, err
+	}
+	if len(gameCards) == 0 {
+		return gameCards, nil
+	}
+	result := db.Create(&gameCards)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return gameCards, nil
+}
+
+func (repo *GameCardPgRepo) FindByGameId(ctx context.Context, gameId int64) ([]entities.GameCard, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return nil, err
+	}
+	var gameCards []entities.GameCard
+	result := db.Where("game_id = ?", gameId).Order("id").Find(&gameCards)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return gameCards, nil
+}
+
+func (repo *GameCardPgRepo) FindByGameIdAndUserId(ctx context.Context, gameId int64, userId int64) ([]entities.GameCard, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return nil, err
+	}
+	var gameCards []entities.GameCard
+	result := db.Where("game_id = ? AND user_id = ?", gameId, userId).Order("id").Find(&gameCards)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return gameCards, nil
+}
+
+func (repo *GameCardPgRepo) FindByTransactionId(ctx context.Context, transactionId int64) ([]entities.GameCard, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return nil, err
+	}
+	var gameCards []entities.GameCard
+	result := db.Where("transaction_id = ?", transactionId).Order("id").Find(&gameCards)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return gameCards, nil
+}
+
+// FindNumbersByGameId returns the numbers of every card of the game.
+func (repo *GameCardPgRepo) FindNumbersByGameId(ctx context.Context, gameId int64) ([]string, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return nil, err
+	}
+	var numbers []string
+	result := db.Model(&entities.GameCard{}).Where("game_id = ?", gameId).Pluck("numbers", &numbers)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return numbers, nil
+}
+
+func (repo *GameCardPgRepo) CountByGameId(ctx context.Context, gameId int64) (int, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return 0, err
+	}
+	var count int64
+	result := db.Model(&entities.GameCard{}).Where("game_id = ?", gameId).Count(&count)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return int(count), nil
+}
+
+func (repo *GameCardPgRepo) CountByGameIdAndUserId(ctx context.Context, gameId int64, userId int64) (int, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return 0, err
+	}
+	var count int64
+	result := db.Model(&entities.GameCard{}).Where("game_id = ? AND user_id = ?", gameId, userId).Count(&count)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return int(count), nil
+}
+
+// DeleteByTransactionId deletes the cards bought by a purchase.
+func (repo *GameCardPgRepo) DeleteByTransactionId(ctx context.Context, transactionId int64) error {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return err
+	}
+	if result := db.Where("transaction_id = ?", transactionId).Delete(&entities.GameCard{}); result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package pgrepos
+
+import (
+	"context"
+	"github.com/gabriel-98/bingo-backend/internal/domain/entities"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type GamePgRepo struct {}
+
+func NewGameRepo() *GamePgRepo {
+	return &GamePgRepo{}
+}
+
+func (repo *GamePgRepo) Create(ctx context.Context, game entities.Game) (*entities.Game, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return nil, err
+	}
+	result := db.Create(&game)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &game, nil
+}
+
+// CreateScheduled creates a game of a template, unless the template already has a game
+// scheduled at the same time.
+func (repo *GamePgRepo) CreateScheduled(ctx context.Context, game entities.Game) (bool, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return false, err
+	}
+	result := db.Clauses(clause.OnConflict{ DoNothing: true }).Create(&game)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (repo *GamePgRepo) FindById(ctx context.Context, id int64) (*entities.Game, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return nil, err
+	}
+	var game entities.Game
+	result := db.Where("id = ?", id).First(&game)
+	if result.Error != nil {
+		return nil, translateError(result.Error)
+	}
+	return &game, nil
+}
+
+// FindBuyingDue returns the scheduled games whose cards should be on sale by now.
+func (repo *GamePgRepo) FindBuyingDue(ctx context.Context, now time.Time, limit int) ([]entities.Game, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return nil, err
+	}
+	var games []entities.Game
+	result := db.Where("status = ? AND buying_opens_at <= ?", "scheduled", now).
+		Order("buying_opens_at").
+		Limit(limit).
+		Find(&games)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return games, nil
+}
+
+// FindStartDue returns the games of templates that are selling cards and should have
+// started by now.
+func (repo *GamePgRepo) FindStartDue(ctx context.Context, now time.Time, limit int) ([]entities.Game, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return nil, err
+	}
+	var games []entities.Game
+	result := db.Where("status = ? AND scheduled_at <= ?", "buying", now).
+		Order("scheduled_at").
+		Limit(limit).
+		Find(&games)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return games, nil
+}
+
+// LockById returns the game and locks it (SELECT ... FOR UPDATE) until the end of the
+// current transaction, so that its status and cards cannot change meanwhile.
+func (repo *GamePgRepo) LockById(ctx context.Context, id int64) (*entities.Game, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return nil, err
+	}
+	var game entities.Game
+	result := db.Clauses(clause.Locking{ Strength: "UPDATE" }).Where("id = ?", id).First(&game)
+	if result.Error != nil {
+		return nil, translateError(result.Error)
+	}
+	return &game, nil
+}
+
+func (repo *GamePgRepo) Update(ctx context.Context, game entities.Game) error {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return err
+	}
+	return db.Save(&game).Error
+}
@@ -0,0 +1,80 @@
+package pgrepos
+
+import (
+	"context"
+	"github.com/gabriel-98/bingo-backend/internal/domain/entities"
+	"time"
+)
+
+type GameTemplatePgRepo struct {}
+
+func NewGameTemplateRepo() *GameTemplatePgRepo {
+	return &GameTemplatePgRepo{}
+}
+
+func (repo *GameTemplatePgRepo) Create(ctx context.Context, gameTemplate entities.GameTemplate) (*entities.GameTemplate, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return nil, err
+	}
+	result := db.Create(&gameTemplate)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &gameTemplate, nil
+}
+
+func (repo *GameTemplatePgRepo) FindById(ctx context.Context, id int64) (*entities.GameTemplate, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return nil, err
+	}
+	var gameTemplate entities.GameTemplate
+	result := db.Where("id = ?", id).First(&gameTemplate)
+	if result.Error != nil {
+		return nil, translateError(result.Error)
+	}
+	return &gameTemplate, nil
+}
+
+func (repo *GameTemplatePgRepo) FindByHostId(ctx context.Context, hostId int64) ([]entities.GameTemplate, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return nil, err
+	}
+	var gameTemplates []entities.GameTemplate
+	result := db.Where("host_id = ?", hostId).Order("id").Find(&gameTemplates)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return gameTemplates, nil
+}
+
+func (repo *GameTemplatePgRepo) FindEnabled(ctx context.Context) ([]entities.GameTemplate, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return nil, err
+	}
+	var gameTemplates []entities.GameTemplate
+	result := db.Where("enabled").Order("id").Find(&gameTemplates)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return gameTemplates, nil
+}
+
+func (repo *GameTemplatePgRepo) Disable(ctx context.Context, id int64) error {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return err
+	}
+	return db.Model(&entities.GameTemplate{}).Where("id = ?", id).Update("enabled", false).Error
+}
+
+func (repo *GameTemplatePgRepo) UpdateScheduledUntil(ctx context.Context, id int64, scheduledUntil time.Time) error {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return err
+	}
+	return db.Model(&entities.GameTemplate{}).Where("id = ?", id).Update("scheduled_until", scheduledUntil).Error
+}
@@ -0,0 +1,36 @@
+package pgrepos
+
+import (
+	"context"
+	"github.com/gabriel-98/bingo-backend/internal/domain/entities"
+)
+
+type GameWinnerPgRepo struct {}
+
+func NewGameWinnerRepo() *GameWinnerPgRepo {
+	return &GameWinnerPgRepo{}
+}
+
+func (repo *GameWinnerPgRepo) CreateBatch(ctx context.Context, gameWinners []entities.GameWinner) error {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return err
+	}
+	if len(gameWinners) == 0 {
+		return nil
+	}
+	return db.Create(&gameWinners).Error
+}
+
+func (repo *GameWinnerPgRepo) FindByGameId(ctx context.Context, gameId int64) ([]entities.GameWinner, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return nil, err
+	}
+	var gameWinners []entities.GameWinner
+	result := db.Where("game_id = ?", gameId).Order("card_id").Find(&gameWinners)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return gameWinners, nil
+}
@@ -0,0 +1,61 @@
+package pgrepos
+
+import (
+	"context"
+	"github.com/gabriel-98/bingo-backend/internal/domain/entities"
+	"gorm.io/gorm/clause"
+)
+
+type JackpotPgRepo struct {}
+
+func NewJackpotRepo() *JackpotPgRepo {
+	return &JackpotPgRepo{}
+}
+
+func (repo *JackpotPgRepo) Create(ctx context.Context, jackpot entities.Jackpot) (*entities.Jackpot, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return nil, err
+	}
+	result := db.Create(&jackpot)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &jackpot, nil
+}
+
+func (repo *JackpotPgRepo) FindById(ctx context.Context, id int64) (*entities.Jackpot, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return nil, err
+	}
+	var jackpot entities.Jackpot
+	result := db.Where("id = ?", id).First(&jackpot)
+	if result.Error != nil {
+		return nil, translateError(result.Error)
+	}
+	return &jackpot, nil
+}
+
+// LockById returns the jackpot and locks it (SELECT ... FOR UPDATE) until the end of the
+// current transaction, so that concurrent games cannot update it meanwhile.
+func (repo *JackpotPgRepo) LockById(ctx context.Context, id int64) (*entities.Jackpot, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return nil, err
+	}
+	var jackpot entities.Jackpot
+	result := db.Clauses(clause.Locking{ Strength: "UPDATE" }).Where("id = ?", id).First(&jackpot)
+	if result.Error != nil {
+		return nil, translateError(result.Error)
+	}
+	return &jackpot, nil
+}
+
+func (repo *JackpotPgRepo) Update(ctx context.Context, jackpot entities.Jackpot) error {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return err
+	}
+	return db.Save(&jackpot).Error
+}
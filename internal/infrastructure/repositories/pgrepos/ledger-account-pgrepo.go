@@ -0,0 +1,81 @@
+package pgrepos
+
+import (
+	"context"
+	"github.com/gabriel-98/bingo-backend/internal/domain/entities"
+	"gorm.io/gorm/clause"
+)
+
+type LedgerAccountPgRepo struct {}
+
+func NewLedgerAccountRepo() *LedgerAccountPgRepo {
+	return &LedgerAccountPgRepo{}
+}
+
+// FindOrCreate returns the account with the code of the given account, creating it if it
+// does not exist yet.
+func (repo *LedgerAccountPgRepo) FindOrCreate(ctx context.Context, ledgerAccount entities.LedgerAccount) (*entities.LedgerAccount, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return nil, err
+	}
+	result := db.Clauses(clause.OnConflict{ DoNothing: true }).Create(&ledgerAccount)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	var storedAccount entities.LedgerAccount
+	result = db.Where("code = ?", ledgerAccount.Code).First(&storedAccount)
+	if result.Error != nil {
+		return nil, translateError(result.Error)
+	}
+	return &storedAccount, nil
+}
+
+func (repo *LedgerAccountPgRepo) FindByUserId(ctx context.Context, userId int64) (*entities.LedgerAccount, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return nil, err
+	}
+	var ledgerAccount entities.LedgerAccount
+	result := db.Where("user_id = ?", userId).First(&ledgerAccount)
+	if result.Error != nil {
+		return nil, translateError(result.Error)
+	}
+	return &ledgerAccount, nil
+}
+
+// LockByIds locks the accounts (SELECT ... FOR UPDATE) until the end of the current
+// transaction, so that their balances cannot change meanwhile. The accounts are locked
+// in the order of their ids, so that concurrent transactions cannot deadlock.
+func (repo *LedgerAccountPgRepo) LockByIds(ctx context.Context, ids []int64) ([]entities.LedgerAccount, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return nil, err
+	}
+	var ledgerAccounts []entities.LedgerAccount
+	result := db.Clauses(clause.Locking{ Strength: "UPDATE" }).
+		Where("id IN ?", ids).
+		Order("id").
+		Find(&ledgerAccounts)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return ledgerAccounts, nil
+}
+
+// Balance returns the balance of an account: the sum of its entries.
+func (repo *LedgerAccountPgRepo) Balance(ctx context.Context, id int64) (int64, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return 0, err
+	}
+	var balance int64
+	result := db.Model(&entities.LedgerEntry{}).
+		Where("account_id = ?", id).
+		Select("COALESCE(SUM(amount), 0)").
+		Scan(&balance)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return balance, nil
+}
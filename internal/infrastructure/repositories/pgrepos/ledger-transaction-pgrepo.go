@@ -0,0 +1,112 @@
+package pgrepos
+
+import (
+	"context"
+	"github.com/gabriel-98/bingo-backend/internal/domain/entities"
+	"gorm.io/gorm"
+)
+
+type LedgerTransactionPgRepo struct {}
+
+func NewLedgerTransactionRepo() *LedgerTransactionPgRepo {
+	return &LedgerTransactionPgRepo{}
+}
+
+// Create records a transaction along with its entries, atomically.
+func (repo *LedgerTransactionPgRepo) Create(ctx context.Context, ledgerTransaction entities.LedgerTransaction, entries []entities.LedgerEntry) (*entities.LedgerTransaction, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return nil, err
+	}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if result := tx.Create(&ledgerTransaction); result.Error != nil {
+			return result.Error
+		}
+		for i := range entries {
+			entries[i].TransactionId = ledgerTransaction.Id
+		}
+		return tx.Create(&entries).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ledgerTransaction, nil
+}
+
+func (repo *LedgerTransactionPgRepo) FindById(ctx context.Context, id int64) (*entities.LedgerTransaction, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return nil, err
+	}
+	var ledgerTransaction entities.LedgerTransaction
+	result := db.Where("id = ?", id).First(&ledgerTransaction)
+	if result.Error != nil {
+		return nil, translateError(result.Error)
+	}
+	return &ledgerTransaction, nil
+}
+
+func (repo *LedgerTransactionPgRepo) FindByIdempotencyKey(ctx context.Context, idempotencyKey string) (*entities.LedgerTransaction, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return nil, err
+	}
+	var ledgerTransaction entities.LedgerTransaction
+	result := db.Where("idempotency_key = ?", idempotencyKey).First(&ledgerTransaction)
+	if result.Error != nil {
+		return nil, translateError(result.Error)
+	}
+	return &ledgerTransaction, nil
+}
+
+func (repo *LedgerTransactionPgRepo) FindEntries(ctx context.Context, transactionId int64) ([]entities.LedgerEntry, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return nil, err
+	}
+	var entries []entities.LedgerEntry
+	result := db.Where("transaction_id = ?", transactionId).Order("id").Find(&entries)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return entries, nil
+}
+
+// FindEntriesByAccountId returns the last entries of an account, newest first, along with
+// their transactions.
+func (repo *LedgerTransactionPgRepo) FindEntriesByAccountId(ctx context.Context, accountId int64, limit int) ([]entities.LedgerEntry, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return nil, err
+	}
+	var entries []entities.LedgerEntry
+	result := db.Preload("Transaction").
+		Where("account_id = ?", accountId).
+		Order("id DESC").
+		Limit(limit).
+		Find(&entries)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return entries, nil
+}
+
+// SumEntries returns the sum of the entries of an account that belong to transactions
+// with the reference and one of the types.
+func (repo *LedgerTransactionPgRepo) SumEntries(ctx context.Context, accountId int64, reference string, transactionTypes []string) (int64, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return 0, err
+	}
+	var sum int64
+	result := db.Model(&entities.LedgerEntry{}).
+		Joins("JOIN ledger_transactions ON ledger_transactions.id = ledger_entries.transaction_id").
+		Where("ledger_entries.account_id = ?", accountId).
+		Where("ledger_transactions.reference = ? AND ledger_transactions.type IN ?", reference, transactionTypes).
+		Select("COALESCE(SUM(ledger_entries.amount), 0)").
+		Scan(&sum)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return sum, nil
+}
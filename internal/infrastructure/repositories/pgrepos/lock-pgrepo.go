@@ -0,0 +1,54 @@
+package pgrepos
+
+import (
+	"context"
+	"hash/fnv"
+)
+
+// A LockPgRepo provides locks shared by every instance of the application, based on
+// Postgres session-level advisory locks.
+type LockPgRepo struct {}
+
+func NewLockRepo() *LockPgRepo {
+	return &LockPgRepo{}
+}
+
+// TryLock tries to acquire the lock identified by name without waiting. If the lock is
+// acquired, the returned release function must be called to release it.
+// Advisory locks belong to a database session, so a connection is reserved until the
+// lock is released.
+func (repo *LockPgRepo) TryLock(ctx context.Context, name string) (func() error, bool, error) {
+	db, err := GetQueryExecutor(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, false, err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Advisory locks are identified by a 64-bit key.
+	hash := fnv.New64a()
+	hash.Write([]byte(name))
+	key := int64(hash.Sum64())
+
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&acquired); err != nil {
+		conn.Close()
+		return nil, false, err
+	}
+	if !acquired {
+		conn.Close()
+		return nil, false, nil
+	}
+	release := func() error {
+		defer conn.Close()
+		_, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", key)
+		return err
+	}
+	return release, true, nil
+}
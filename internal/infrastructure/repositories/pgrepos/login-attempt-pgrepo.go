@@ -0,0 +1,98 @@
+package pgrepos
+
+import (
+	"context"
+	"errors"
+	"github.com/gabriel-98/bingo-backend/internal/domain/entities"
+	"gorm.io/gorm"
+	"time"
+)
+
+// A LoginAttemptPgRepo stores the failed login counters in Postgres, so that every
+// instance of the application shares them.
+type LoginAttemptPgRepo struct {}
+
+func NewLoginAttemptRepo() *LoginAttemptPgRepo {
+	return &LoginAttemptPgRepo{}
+}
+
+// Find returns the failed logins registered for the key, or an empty record if there
+// are none.
+func (repo *LoginAttemptPgRepo) Find(ctx context.Context, key string) (*entities.LoginAttempt, error) {
+	db, err := GetQueryExecutor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var loginAttempt entities.LoginAttempt
+	result := db.Where("key = ?", key).First(&loginAttempt)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return &entities.LoginAttempt{ Key: key }, nil
+	}
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &loginAttempt, nil
+}
+
+// RecordFailure atomically increments the failure counter of the key and returns the
+// updated record. Failures older than resetBefore are forgotten, so the counter
+// restarts at one.
+func (repo *LoginAttemptPgRepo) RecordFailure(ctx context.Context, key string, at time.Time, resetBefore time.Time) (*entities.LoginAttempt, error) {
+	db, err := GetQueryExecutor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var loginAttempt entities.LoginAttempt
+	result := db.Raw(`
+		INSERT INTO login_attempts (key, failures, last_failure_at)
+		VALUES (?, 1, ?)
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE
+				WHEN login_attempts.last_failure_at < ? THEN 1
+				ELSE login_attempts.failures + 1
+			END,
+			last_failure_at = EXCLUDED.last_failure_at
+		RETURNING *`,
+		key, at, resetBefore,
+	).Scan(&loginAttempt)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &loginAttempt, nil
+}
+
+// Release atomically decrements the failure counter of the key, undoing a failure
+// recorded for an attempt that did not fail.
+func (repo *LoginAttemptPgRepo) Release(ctx context.Context, key string) error {
+	db, err := GetQueryExecutor(ctx)
+	if err != nil {
+		return err
+	}
+	result := db.Model(&entities.LoginAttempt{}).
+		Where("key = ? AND failures > 0", key).
+		Update("failures", gorm.Expr("failures - 1"))
+	return result.Error
+}
+
+// Lock locks the key out until the given time, and restarts its failure counter.
+func (repo *LoginAttemptPgRepo) Lock(ctx context.Context, key string, until time.Time) error {
+	db, err := GetQueryExecutor(ctx)
+	if err != nil {
+		return err
+	}
+	result := db.Model(&entities.LoginAttempt{}).
+		Where("key = ?", key).
+		Updates(map[string]any{ "failures": 0, "locked_until": until })
+	return result.Error
+}
+
+func (repo *LoginAttemptPgRepo) Reset(ctx context.Context, key string) error {
+	db, err := GetQueryExecutor(ctx)
+	if err != nil {
+		return err
+	}
+	if result := db.Where("key = ?", key).Delete(&entities.LoginAttempt{}); result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package pgrepos
+
+import (
+	"github.com/gabriel-98/bingo-backend/internal/domain/entities"
+	"gorm.io/gorm"
+)
+
+// MigrateRefreshTokenHashes migrates the refresh_tokens table from storing raw tokens
+// (primary key 'token') to storing their SHA-256 digests (primary key 'token_hash').
+// Existing tokens are hashed in place, so the sessions remain valid.
+// It must run before the automatic migration of the models, and it does nothing if the
+// table does not exist or was already migrated.
+func MigrateRefreshTokenHashes(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasTable("refresh_tokens") || !migrator.HasColumn("refresh_tokens", "token") {
+		return nil
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		statements := []string{
+			`ALTER TABLE refresh_tokens ADD COLUMN IF NOT EXISTS token_hash text`,
+			`UPDATE refresh_tokens SET token_hash = encode(sha256(convert_to(token, 'UTF8')), 'hex')`,
+			`ALTER TABLE refresh_tokens ADD COLUMN IF NOT EXISTS parent_token_hash text`,
+		}
+		if migrator.HasColumn("refresh_tokens", "parent_token") {
+			statements = append(statements,
+				`UPDATE refresh_tokens SET parent_token_hash = encode(sha256(convert_to(parent_token, 'UTF8')), 'hex') WHERE parent_token IS NOT NULL`,
+				`ALTER TABLE refresh_tokens DROP COLUMN parent_token`,
+			)
+		}
+		statements = append(statements,
+			`ALTER TABLE refresh_tokens DROP CONSTRAINT IF EXISTS refresh_tokens_pkey`,
+			`ALTER TABLE refresh_tokens DROP COLUMN token`,
+			`ALTER TABLE refresh_tokens ADD PRIMARY KEY (token_hash)`,
+		)
+		for _, statement := range statements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// MigrateRefreshTokenFamilies adds the family_id column to the refresh_tokens table,
+// which is required for every row: the rows created before token families existed
+// become single-token families, identified by their own digest.
+// It must run after MigrateRefreshTokenHashes and before the automatic migration of the
+// models, and it does nothing if the table does not exist or was already migrated.
+func MigrateRefreshTokenFamilies(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasTable("refresh_tokens") || migrator.HasColumn("refresh_tokens", "family_id") {
+		return nil
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		statements := []string{
+			`ALTER TABLE refresh_tokens ADD COLUMN family_id text`,
+			`UPDATE refresh_tokens SET family_id = token_hash`,
+		}
+		for _, statement := range statements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// MigrateUserRoles creates the user_roles table and assigns the player role to every
+// existing user, who were all players before roles existed.
+// It must run before the automatic migration of the models, and it does nothing if the
+// table already exists.
+func MigrateUserRoles(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if migrator.HasTable(&entities.UserRole{}) || !migrator.HasTable(&entities.User{}) {
+		return nil
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Migrator().CreateTable(&entities.UserRole{}); err != nil {
+			return err
+		}
+		return tx.Exec(`INSERT INTO user_roles (user_id, role, created_at) SELECT id, 'player', now() FROM users`).Error
+	})
+}
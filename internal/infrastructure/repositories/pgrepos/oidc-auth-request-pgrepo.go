@@ -0,0 +1,46 @@
+package pgrepos
+
+import (
+	"context"
+	"github.com/gabriel-98/bingo-backend/internal/domain/entities"
+	domports "github.com/gabriel-98/bingo-backend/internal/domain/ports"
+)
+
+type OidcAuthRequestPgRepo struct {}
+
+func NewOidcAuthRequestRepo() *OidcAuthRequestPgRepo {
+	return &OidcAuthRequestPgRepo{}
+}
+
+// Create registers a pending login, and deletes the ones that have already expired,
+// since their callbacks are rejected anyway.
+func (repo *OidcAuthRequestPgRepo) Create(ctx context.Context, oidcAuthRequest entities.OidcAuthRequest) error {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return err
+	}
+	result := db.Create(&oidcAuthRequest)
+	if result.Error != nil {
+		return result.Error
+	}
+	result = db.Where("expires_at <= ?", oidcAuthRequest.CreatedAt).Delete(&entities.OidcAuthRequest{})
+	return result.Error
+}
+
+// Take deletes and returns the pending login with this state digest, so that a state
+// can be used only once. domports.ErrNotFound is returned if there is none.
+func (repo *OidcAuthRequestPgRepo) Take(ctx context.Context, stateHash string) (*entities.OidcAuthRequest, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return nil, err
+	}
+	var oidcAuthRequest entities.OidcAuthRequest
+	result := db.Raw("DELETE FROM oidc_auth_requests WHERE state_hash = ? RETURNING *", stateHash).Scan(&oidcAuthRequest)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, domports.ErrNotFound
+	}
+	return &oidcAuthRequest, nil
+}
@@ -0,0 +1,82 @@
+package pgrepos
+
+import (
+	"context"
+	"github.com/gabriel-98/bingo-backend/internal/domain/entities"
+	"time"
+)
+
+type OneTimeTokenPgRepo struct {}
+
+func NewOneTimeTokenRepo() *OneTimeTokenPgRepo {
+	return &OneTimeTokenPgRepo{}
+}
+
+func (repo *OneTimeTokenPgRepo) Create(ctx context.Context, oneTimeToken entities.OneTimeToken) (*entities.OneTimeToken, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return nil, err
+	}
+	result := db.Create(&oneTimeToken)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &oneTimeToken, nil
+}
+
+// FindByToken returns the token with this digest, only if it was issued for the purpose.
+func (repo *OneTimeTokenPgRepo) FindByToken(ctx context.Context, tokenHash string, purpose string) (*entities.OneTimeToken, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return nil, err
+	}
+	var oneTimeToken entities.OneTimeToken
+	result := db.Where("token_hash = ? AND purpose = ?", tokenHash, purpose).First(&oneTimeToken)
+	if result.Error != nil {
+		return nil, translateError(result.Error)
+	}
+	return &oneTimeToken, nil
+}
+
+// FindLatestByUserId returns the last token of a user issued for the purpose.
+func (repo *OneTimeTokenPgRepo) FindLatestByUserId(ctx context.Context, userId int64, purpose string) (*entities.OneTimeToken, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return nil, err
+	}
+	var oneTimeToken entities.OneTimeToken
+	result := db.Where("user_id = ? AND purpose = ?", userId, purpose).Order("created_at DESC").First(&oneTimeToken)
+	if result.Error != nil {
+		return nil, translateError(result.Error)
+	}
+	return &oneTimeToken, nil
+}
+
+// MarkUsed marks a token as used, only if it was not used yet. It returns false if the
+// token was already used (e.g., by a concurrent request).
+func (repo *OneTimeTokenPgRepo) MarkUsed(ctx context.Context, tokenHash string, usedAt time.Time) (bool, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return false, err
+	}
+	result := db.Model(&entities.OneTimeToken{}).
+		Where("token_hash = ? AND used_at IS NULL", tokenHash).
+		Update("used_at", usedAt)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// DeleteByUserId deletes the tokens of a user issued for the purpose, so that only the
+// latest one remains valid.
+func (repo *OneTimeTokenPgRepo) DeleteByUserId(ctx context.Context, userId int64, purpose string) error {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return err
+	}
+	if result := db.Where("user_id = ? AND purpose = ?", userId, purpose).Delete(&entities.OneTimeToken{}); result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package pgrepos
+
+import (
+	"context"
+	"github.com/gabriel-98/bingo-backend/internal/domain/entities"
+	"gorm.io/gorm/clause"
+)
+
+type OrganizationMemberPgRepo struct {}
+
+func NewOrganizationMemberRepo() *OrganizationMemberPgRepo {
+	return &OrganizationMemberPgRepo{}
+}
+
+func (repo *OrganizationMemberPgRepo) Find(ctx context.Context, organizationId int64, userId int64) (*entities.OrganizationMember, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return nil, err
+	}
+	var organizationMember entities.OrganizationMember
+	result := db.Where("organization_id = ? AND user_id = ?", organizationId, userId).First(&organizationMember)
+	if result.Error != nil {
+		return nil, translateError(result.Error)
+	}
+	return &organizationMember, nil
+}
+
+func (repo *OrganizationMemberPgRepo) FindByUserId(ctx context.Context, userId int64) ([]entities.OrganizationMember, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return nil, err
+	}
+	var organizationMembers []entities.OrganizationMember
+	result := db.Where("user_id = ?", userId).Order("organization_id").Find(&organizationMembers)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return organizationMembers, nil
+}
+
+func (repo *OrganizationMemberPgRepo) FindByOrganizationId(ctx context.Context, organizationId int64) ([]entities.OrganizationMember, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return nil, err
+	}
+	var organizationMembers []entities.OrganizationMember
+	result := db.Where("organization_id = ?", organizationId).Order("user_id").Find(&organizationMembers)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return organizationMembers, nil
+}
+
+// Save adds a member to an organization, or replaces its role if the user is already a
+// member.
+func (repo *OrganizationMemberPgRepo) Save(ctx context.Context, organizationMember entities.OrganizationMember) (*entities.OrganizationMember, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return nil, err
+	}
+	result := db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{ Name: "organization_id" }, { Name: "user_id" }},
+		DoUpdates: clause.AssignmentColumns([]string{ "role" }),
+	}).Create(&organizationMember)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &organizationMember, nil
+}
+
+func (repo *OrganizationMemberPgRepo) Delete(ctx context.Context, organizationId int64, userId int64) error {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return err
+	}
+	result := db.Where("organization_id = ? AND user_id = ?", organizationId, userId).Delete(&entities.OrganizationMember{})
+	return result.Error
+}
@@ -0,0 +1,50 @@
+package pgrepos
+
+import (
+	"context"
+	"github.com/gabriel-98/bingo-backend/internal/domain/entities"
+)
+
+type OrganizationPgRepo struct {}
+
+func NewOrganizationRepo() *OrganizationPgRepo {
+	return &OrganizationPgRepo{}
+}
+
+func (repo *OrganizationPgRepo) Create(ctx context.Context, organization entities.Organization) (*entities.Organization, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return nil, err
+	}
+	result := db.Create(&organization)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &organization, nil
+}
+
+func (repo *OrganizationPgRepo) FindById(ctx context.Context, id int64) (*entities.Organization, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return nil, err
+	}
+	var organization entities.Organization
+	result := db.Where("id = ?", id).First(&organization)
+	if result.Error != nil {
+		return nil, translateError(result.Error)
+	}
+	return &organization, nil
+}
+
+func (repo *OrganizationPgRepo) FindByName(ctx context.Context, name string) (*entities.Organization, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return nil, err
+	}
+	var organization entities.Organization
+	result := db.Where("name = ?", name).First(&organization)
+	if result.Error != nil {
+		return nil, translateError(result.Error)
+	}
+	return &organization, nil
+}
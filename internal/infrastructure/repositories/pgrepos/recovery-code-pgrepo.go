@@ -0,0 +1,79 @@
+package pgrepos
+
+import (
+	"context"
+	"github.com/gabriel-98/bingo-backend/internal/domain/entities"
+	"gorm.io/gorm"
+	"time"
+)
+
+type RecoveryCodePgRepo struct {}
+
+func NewRecoveryCodeRepo() *RecoveryCodePgRepo {
+	return &RecoveryCodePgRepo{}
+}
+
+func (repo *RecoveryCodePgRepo) FindUnusedByUserId(ctx context.Context, userId int64) ([]entities.RecoveryCode, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return nil, err
+	}
+	var recoveryCodes []entities.RecoveryCode
+	result := db.Where("user_id = ? AND used_at IS NULL", userId).Order("id").Find(&recoveryCodes)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return recoveryCodes, nil
+}
+
+// Replace replaces all the recovery codes of a user in a single transaction.
+func (repo *RecoveryCodePgRepo) Replace(ctx context.Context, userId int64, codeHashes []string) ([]entities.RecoveryCode, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return nil, err
+	}
+	recoveryCodes := make([]entities.RecoveryCode, len(codeHashes))
+	for i, codeHash := range codeHashes {
+		recoveryCodes[i] = entities.RecoveryCode{ UserId: userId, CodeHash: codeHash }
+	}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if result := tx.Where("user_id = ?", userId).Delete(&entities.RecoveryCode{}); result.Error != nil {
+			return result.Error
+		}
+		if len(recoveryCodes) == 0 {
+			return nil
+		}
+		return tx.Create(&recoveryCodes).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return recoveryCodes, nil
+}
+
+// MarkUsed marks a recovery code as used, only if it was not used yet. It returns false
+// if the code was already used (e.g., by a concurrent login).
+func (repo *RecoveryCodePgRepo) MarkUsed(ctx context.Context, id int64, usedAt time.Time) (bool, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return false, err
+	}
+	result := db.Model(&entities.RecoveryCode{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", usedAt)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (repo *RecoveryCodePgRepo) DeleteByUserId(ctx context.Context, userId int64) error {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return err
+	}
+	if result := db.Where("user_id = ?", userId).Delete(&entities.RecoveryCode{}); result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
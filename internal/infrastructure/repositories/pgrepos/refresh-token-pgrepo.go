@@ -3,6 +3,7 @@ package pgrepos
 import (
 	"context"
 	"github.com/gabriel-98/bingo-backend/internal/domain/entities"
+	"time"
 )
 
 type RefreshTokenPgRepo struct {}
@@ -23,25 +24,68 @@ func (repo *RefreshTokenPgRepo) Create(ctx context.Context, refreshToken entitie
 	return &refreshToken, nil
 }
 
-func (repo *RefreshTokenPgRepo) FindByToken(ctx context.Context, token string) (*entities.RefreshToken, error) {
+func (repo *RefreshTokenPgRepo) FindByToken(ctx context.Context, tokenHash string) (*entities.RefreshToken, error) {
 	db, err := GetQueryExecutor(ctx);
 	if err != nil {
 		return nil, err
 	}
 	var refreshToken entities.RefreshToken
-	result := db.Where("token = ?", token).First(&refreshToken)
+	result := db.Where("token_hash = ?", tokenHash).First(&refreshToken)
 	if result.Error != nil {
 		return nil, result.Error
 	}
 	return &refreshToken, nil
 }
 
-func (repo *RefreshTokenPgRepo) Update(ctx context.Context, token string, refreshToken entities.RefreshToken) (*entities.RefreshToken, error) {
+func (repo *RefreshTokenPgRepo) FindByFamilyId(ctx context.Context, familyId string) ([]entities.RefreshToken, error) {
 	db, err := GetQueryExecutor(ctx);
 	if err != nil {
 		return nil, err
 	}
-	refreshToken.Token = token
+	var refreshTokens []entities.RefreshToken
+	result := db.Where("family_id = ?", familyId).Find(&refreshTokens)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return refreshTokens, nil
+}
+
+func (repo *RefreshTokenPgRepo) FindByUserId(ctx context.Context, userId int64) ([]entities.RefreshToken, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return nil, err
+	}
+	var refreshTokens []entities.RefreshToken
+	result := db.Where("user_id = ?", userId).Find(&refreshTokens)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return refreshTokens, nil
+}
+
+// FindActiveByUserId returns the tokens of a user that have been neither rotated nor
+// expired at the given time, which are the current tokens of the user's sessions.
+func (repo *RefreshTokenPgRepo) FindActiveByUserId(ctx context.Context, userId int64, now time.Time) ([]entities.RefreshToken, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return nil, err
+	}
+	var refreshTokens []entities.RefreshToken
+	result := db.Where("user_id = ? AND rotated_at IS NULL AND expires_at > ?", userId, now).
+		Order("last_used_at DESC").
+		Find(&refreshTokens)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return refreshTokens, nil
+}
+
+func (repo *RefreshTokenPgRepo) Update(ctx context.Context, tokenHash string, refreshToken entities.RefreshToken) (*entities.RefreshToken, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return nil, err
+	}
+	refreshToken.TokenHash = tokenHash
 	result := db.Save(&refreshToken)
 	if result.Error != nil {
 		return nil, result.Error
@@ -49,13 +93,70 @@ func (repo *RefreshTokenPgRepo) Update(ctx context.Context, token string, refres
 	return &refreshToken, nil
 }
 
-func (repo *RefreshTokenPgRepo) Delete(ctx context.Context, token string) error {
+// MarkRotated sets the rotation time of a token that has not been rotated yet. It
+// returns false if the token does not exist or was already rotated, so concurrent
+// rotations of the same token cannot both succeed.
+func (repo *RefreshTokenPgRepo) MarkRotated(ctx context.Context, tokenHash string, rotatedAt time.Time) (bool, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return false, err
+	}
+	result := db.Model(&entities.RefreshToken{}).
+		Where("token_hash = ? AND rotated_at IS NULL", tokenHash).
+		Update("rotated_at", rotatedAt)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (repo *RefreshTokenPgRepo) Delete(ctx context.Context, tokenHash string) error {
 	db, err := GetQueryExecutor(ctx);
 	if err != nil {
 		return err
 	}
-	if result := db.Where("token = ?", token).Delete(&entities.RefreshToken{}); result.Error != nil {
+	if result := db.Where("token_hash = ?", tokenHash).Delete(&entities.RefreshToken{}); result.Error != nil {
 		return result.Error
 	}
 	return nil
+}
+
+func (repo *RefreshTokenPgRepo) DeleteByFamilyId(ctx context.Context, familyId string) error {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return err
+	}
+	if result := db.Where("family_id = ?", familyId).Delete(&entities.RefreshToken{}); result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+func (repo *RefreshTokenPgRepo) DeleteByUserId(ctx context.Context, userId int64) error {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return err
+	}
+	if result := db.Where("user_id = ?", userId).Delete(&entities.RefreshToken{}); result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+// DeleteExpired deletes up to limit tokens that expired before the given time, and
+// returns the number of deleted tokens.
+func (repo *RefreshTokenPgRepo) DeleteExpired(ctx context.Context, before time.Time, limit int) (int64, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return 0, err
+	}
+	expiredTokens := db.Model(&entities.RefreshToken{}).
+		Select("token_hash").
+		Where("expires_at < ?", before).
+		Limit(limit)
+	result := db.Where("token_hash IN (?)", expiredTokens).Delete(&entities.RefreshToken{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
 }
\ No newline at end of file
@@ -0,0 +1,48 @@
+package pgrepos
+
+import (
+	"context"
+	"github.com/gabriel-98/bingo-backend/internal/domain/entities"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// A RevokedTokenPgRepo stores the revoked access tokens in Postgres, so that every
+// instance of the application sees the revocations.
+type RevokedTokenPgRepo struct {}
+
+func NewRevokedTokenRepo() *RevokedTokenPgRepo {
+	return &RevokedTokenPgRepo{}
+}
+
+// Create registers a revoked token (revoking a token twice has no effect), and deletes
+// the revoked tokens that have already expired, since expired tokens are rejected
+// anyway.
+func (repo *RevokedTokenPgRepo) Create(ctx context.Context, revokedToken entities.RevokedToken) error {
+	db, err := GetQueryExecutor(ctx)
+	if err != nil {
+		return err
+	}
+	result := db.Clauses(clause.OnConflict{ DoNothing: true }).Create(&revokedToken)
+	if result.Error != nil {
+		return result.Error
+	}
+	result = db.Where("expires_at <= ?", revokedToken.RevokedAt).Delete(&entities.RevokedToken{})
+	return result.Error
+}
+
+// Exists reports whether a token has been revoked and has not expired at the given time.
+func (repo *RevokedTokenPgRepo) Exists(ctx context.Context, tokenId string, now time.Time) (bool, error) {
+	db, err := GetQueryExecutor(ctx)
+	if err != nil {
+		return false, err
+	}
+	var count int64
+	result := db.Model(&entities.RevokedToken{}).
+		Where("token_id = ? AND expires_at > ?", tokenId, now).
+		Count(&count)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return count > 0, nil
+}
@@ -0,0 +1,27 @@
+package pgrepos
+
+import (
+	"context"
+	"gorm.io/gorm"
+)
+
+// A TransactionPgManager runs functions in Postgres transactions. Transactions started
+// inside another one are nested with savepoints.
+type TransactionPgManager struct {}
+
+func NewTransactionManager() *TransactionPgManager {
+	return &TransactionPgManager{}
+}
+
+// WithinTransaction runs fn in a transaction, which is committed if fn returns nil and
+// rolled back otherwise. The context passed to fn carries the transaction as the query
+// executor.
+func (manager *TransactionPgManager) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return err
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		return fn(WithQueryExecutor(ctx, tx))
+	})
+}
@@ -0,0 +1,62 @@
+package pgrepos
+
+import (
+	"context"
+	"github.com/gabriel-98/bingo-backend/internal/domain/entities"
+	"time"
+)
+
+type UserIdentityPgRepo struct {}
+
+func NewUserIdentityRepo() *UserIdentityPgRepo {
+	return &UserIdentityPgRepo{}
+}
+
+func (repo *UserIdentityPgRepo) Find(ctx context.Context, provider string, subject string) (*entities.UserIdentity, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return nil, err
+	}
+	var userIdentity entities.UserIdentity
+	result := db.Where("provider = ? AND subject = ?", provider, subject).First(&userIdentity)
+	if result.Error != nil {
+		return nil, translateError(result.Error)
+	}
+	return &userIdentity, nil
+}
+
+func (repo *UserIdentityPgRepo) Create(ctx context.Context, userIdentity entities.UserIdentity) (*entities.UserIdentity, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return nil, err
+	}
+	result := db.Create(&userIdentity)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &userIdentity, nil
+}
+
+// UpdateLastLogin registers a login with the identity, and the email asserted by the
+// provider on it.
+func (repo *UserIdentityPgRepo) UpdateLastLogin(ctx context.Context, provider string, subject string, email string, at time.Time) error {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return err
+	}
+	result := db.Model(&entities.UserIdentity{}).
+		Where("provider = ? AND subject = ?", provider, subject).
+		Updates(map[string]any{ "email": email, "last_login_at": at })
+	return result.Error
+}
+
+func (repo *UserIdentityPgRepo) DeleteByUserId(ctx context.Context, userId int64) error {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return err
+	}
+	if result := db.Where("user_id = ?", userId).Delete(&entities.UserIdentity{}); result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package pgrepos
 import (
 	"context"
 	"github.com/gabriel-98/bingo-backend/internal/domain/entities"
+	"time"
 )
 
 type UserPgRepo struct {}
@@ -31,7 +32,7 @@ func (repo *UserPgRepo) FindById(ctx context.Context, id int64) (*entities.User,
 	var user entities.User
 	result := db.First(&user, id)
 	if result.Error != nil {
-		return nil, result.Error
+		return nil, translateError(result.Error)
 	}
 	return &user, nil
 }
@@ -44,11 +45,54 @@ func (repo *UserPgRepo) FindByUsername(ctx context.Context, username string) (*e
 	var user entities.User
 	result := db.Where("username = ?", username).First(&user)
 	if result.Error != nil {
-		return nil, result.Error
+		return nil, translateError(result.Error)
+	}
+	return &user, nil
+}
+
+func (repo *UserPgRepo) FindByEmail(ctx context.Context, email string) (*entities.User, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return nil, err
+	}
+	var user entities.User
+	result := db.Where("email = ?", email).First(&user)
+	if result.Error != nil {
+		return nil, translateError(result.Error)
 	}
 	return &user, nil
 }
 
+// ExistsByUsername reports whether a user has the username, including the deleted users
+// that still keep it until they are anonymized, since the username remains unique.
+func (repo *UserPgRepo) ExistsByUsername(ctx context.Context, username string) (bool, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return false, err
+	}
+	var count int64
+	result := db.Unscoped().Model(&entities.User{}).Where("username = ?", username).Count(&count)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return count > 0, nil
+}
+
+// ExistsByEmail reports whether a user has the email, including the deleted users that
+// still keep it until they are anonymized, since the email remains unique.
+func (repo *UserPgRepo) ExistsByEmail(ctx context.Context, email string) (bool, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return false, err
+	}
+	var count int64
+	result := db.Unscoped().Model(&entities.User{}).Where("email = ?", email).Count(&count)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return count > 0, nil
+}
+
 func (repo *UserPgRepo) Update(ctx context.Context, id int64, user entities.User) (*entities.User, error) {
 	db, err := GetQueryExecutor(ctx);
 	if err != nil {
@@ -62,6 +106,8 @@ func (repo *UserPgRepo) Update(ctx context.Context, id int64, user entities.User
 	return &user, nil
 }
 
+// Delete soft deletes a user: the user is no longer found by the repository, but its row
+// is kept until it is anonymized.
 func (repo *UserPgRepo) Delete(ctx context.Context, id int64) error {
 	db, err := GetQueryExecutor(ctx);
 	if err != nil {
@@ -71,4 +117,109 @@ func (repo *UserPgRepo) Delete(ctx context.Context, id int64) error {
 		return result.Error
 	}
 	return nil
-}
\ No newline at end of file
+}
+
+// ReplacePassword replaces the hashed password of a user, only if it is still oldPassword,
+// so that a password changed concurrently is not overwritten. No other column is
+// written. It reports whether the password was replaced.
+func (repo *UserPgRepo) ReplacePassword(ctx context.Context, id int64, oldPassword string, newPassword string) (bool, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return false, err
+	}
+	result := db.Model(&entities.User{}).
+		Where("id = ? AND password = ?", id, oldPassword).
+		Update("password", newPassword)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// MarkEmailVerified sets the email verification time of a user, only if its email is
+// still the given one. No other column is written. It reports whether the email was
+// marked as verified.
+func (repo *UserPgRepo) MarkEmailVerified(ctx context.Context, id int64, email string, verifiedAt time.Time) (bool, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return false, err
+	}
+	result := db.Model(&entities.User{}).
+		Where("id = ? AND email = ?", id, email).
+		Update("email_verified_at", verifiedAt)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// FindDeletedBefore returns up to limit users deleted before the given time that have
+// not been anonymized yet.
+func (repo *UserPgRepo) FindDeletedBefore(ctx context.Context, before time.Time, limit int) ([]entities.User, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return nil, err
+	}
+	var users []entities.User
+	result := db.Unscoped().
+		Where("deleted_at < ? AND anonymized_at IS NULL", before).
+		Order("deleted_at").
+		Limit(limit).
+		Find(&users)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return users, nil
+}
+
+// Anonymize replaces the personal data of a deleted user: the username is replaced and
+// the password and email are erased, so that nobody can log in to the account.
+func (repo *UserPgRepo) Anonymize(ctx context.Context, id int64, username string, anonymizedAt time.Time) error {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return err
+	}
+	result := db.Unscoped().Model(&entities.User{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"username": username,
+			"password": "",
+			"email": nil,
+			"email_verified_at": nil,
+			"username_changed_at": nil,
+			"guest_expires_at": nil,
+			"anonymized_at": anonymizedAt,
+		})
+	return result.Error
+}
+
+// FindExpiredGuests returns up to limit guest users whose expiration has passed.
+func (repo *UserPgRepo) FindExpiredGuests(ctx context.Context, now time.Time, limit int) ([]entities.User, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return nil, err
+	}
+	var users []entities.User
+	result := db.
+		Where("guest_expires_at <= ?", now).
+		Order("guest_expires_at").
+		Limit(limit).
+		Find(&users)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return users, nil
+}
+
+// ExtendGuest postpones the expiration of a guest user. It has no effect on full
+// accounts.
+func (repo *UserPgRepo) ExtendGuest(ctx context.Context, id int64, expiresAt time.Time) error {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return err
+	}
+	result := db.Model(&entities.User{}).
+		Where("id = ? AND guest_expires_at IS NOT NULL", id).
+		Update("guest_expires_at", expiresAt)
+	return result.Error
+}
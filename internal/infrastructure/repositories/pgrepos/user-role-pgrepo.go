@@ -0,0 +1,64 @@
+package pgrepos
+
+import (
+	"context"
+	"github.com/gabriel-98/bingo-backend/internal/domain/entities"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type UserRolePgRepo struct {}
+
+func NewUserRoleRepo() *UserRolePgRepo {
+	return &UserRolePgRepo{}
+}
+
+func (repo *UserRolePgRepo) FindByUserId(ctx context.Context, userId int64) ([]entities.UserRole, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return nil, err
+	}
+	var userRoles []entities.UserRole
+	result := db.Where("user_id = ?", userId).Order("role").Find(&userRoles)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return userRoles, nil
+}
+
+// Replace replaces all the roles of a user in a single transaction.
+func (repo *UserRolePgRepo) Replace(ctx context.Context, userId int64, roles []string) ([]entities.UserRole, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return nil, err
+	}
+	userRoles := make([]entities.UserRole, len(roles))
+	for i, role := range roles {
+		userRoles[i] = entities.UserRole{ UserId: userId, Role: role }
+	}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if result := tx.Where("user_id = ?", userId).Delete(&entities.UserRole{}); result.Error != nil {
+			return result.Error
+		}
+		if len(userRoles) == 0 {
+			return nil
+		}
+		return tx.Create(&userRoles).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return userRoles, nil
+}
+
+// Add grants a role to a user, keeping its other roles. It does nothing if the user
+// already has the role.
+func (repo *UserRolePgRepo) Add(ctx context.Context, userId int64, role string) error {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return err
+	}
+	userRole := entities.UserRole{ UserId: userId, Role: role }
+	result := db.Clauses(clause.OnConflict{ DoNothing: true }).Create(&userRole)
+	return result.Error
+}
@@ -0,0 +1,70 @@
+package pgrepos
+
+import (
+	"context"
+	"github.com/gabriel-98/bingo-backend/internal/domain/entities"
+	"gorm.io/gorm/clause"
+)
+
+type UserTotpPgRepo struct {}
+
+func NewUserTotpRepo() *UserTotpPgRepo {
+	return &UserTotpPgRepo{}
+}
+
+func (repo *UserTotpPgRepo) FindByUserId(ctx context.Context, userId int64) (*entities.UserTotp, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return nil, err
+	}
+	var userTotp entities.UserTotp
+	result := db.Where("user_id = ?", userId).First(&userTotp)
+	if result.Error != nil {
+		return nil, translateError(result.Error)
+	}
+	return &userTotp, nil
+}
+
+// Save creates the TOTP secret of a user, or replaces it if the user already has one.
+func (repo *UserTotpPgRepo) Save(ctx context.Context, userTotp entities.UserTotp) (*entities.UserTotp, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return nil, err
+	}
+	result := db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{ Name: "user_id" }},
+		DoUpdates: clause.AssignmentColumns([]string{ "secret", "confirmed_at", "last_used_step", "created_at" }),
+	}).Create(&userTotp)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &userTotp, nil
+}
+
+// UseStep registers that the code of a time step has been accepted. It returns false if
+// a code of this or a later step was already accepted, which means the code is being
+// reused.
+func (repo *UserTotpPgRepo) UseStep(ctx context.Context, userId int64, step int64) (bool, error) {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return false, err
+	}
+	result := db.Model(&entities.UserTotp{}).
+		Where("user_id = ? AND last_used_step < ?", userId, step).
+		Update("last_used_step", step)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (repo *UserTotpPgRepo) Delete(ctx context.Context, userId int64) error {
+	db, err := GetQueryExecutor(ctx);
+	if err != nil {
+		return err
+	}
+	if result := db.Where("user_id = ?", userId).Delete(&entities.UserTotp{}); result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
@@ -2,10 +2,18 @@ package pgrepos
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	domports "github.com/gabriel-98/bingo-backend/internal/domain/ports"
 	"gorm.io/gorm"
 )
 
+// WithQueryExecutor returns a copy of the context that carries the query executor used
+// by the repositories (e.g., for work that does not run inside a REST request).
+func WithQueryExecutor(ctx context.Context, db *gorm.DB) context.Context {
+	return context.WithValue(ctx, "QueryExecutor", db)
+}
+
 func GetQueryExecutor(ctx context.Context) (*gorm.DB, error) {
 	queryExecutor := ctx.Value("QueryExecutor")
 	if queryExecutor == nil {
@@ -16,4 +24,13 @@ func GetQueryExecutor(ctx context.Context) (*gorm.DB, error) {
 		return nil, fmt.Errorf("QueryExecutor is of invalid type")
 	}
 	return db, nil
+}
+
+// translateError translates the storage errors that the repository ports define (e.g.,
+// gorm.ErrRecordNotFound into ports.ErrNotFound), and returns any other error as is.
+func translateError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domports.ErrNotFound
+	}
+	return err
 }
\ No newline at end of file